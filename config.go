@@ -3,11 +3,21 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"maps"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/goccy/go-yaml"
 )
 
@@ -17,42 +27,269 @@ const (
 	SyncModeOverwrite = "overwrite"
 )
 
+// DefaultTimeout is the overall run timeout used when neither the config nor the command line sets one
+const DefaultTimeout = 5 * time.Minute
+
+// Lock modes for preventing concurrent runs against the same tables
+const (
+	LockModeGetLock = "getlock" // MySQL GET_LOCK named locks (default)
+	LockModeTable   = "table"   // Rows in a lock table, for databases without named locks
+	LockModeNone    = "none"    // No locking
+)
+
+// DefaultLockTable is the lock table used by LockModeTable when none is configured
+const DefaultLockTable = "mydatasyncer_locks"
+
+// LockConfig represents the lock that keeps concurrent runs from syncing the same tables
+type LockConfig struct {
+	Mode        string        `yaml:"mode"`        // "getlock" (default), "table" or "none"
+	WaitTimeout time.Duration `yaml:"waitTimeout"` // How long to wait for another run to release its locks; 0 fails immediately
+	Table       string        `yaml:"table"`       // Lock table for the "table" mode (default: mydatasyncer_locks)
+}
+
+// Defaults for retrying transactions that failed with a transient error
+const (
+	DefaultRetryMaxAttempts    = 3
+	DefaultRetryInitialBackoff = time.Second
+	DefaultRetryMaxBackoff     = 30 * time.Second
+)
+
+// RetryConfig represents how transactions that failed with a deadlock, lock wait timeout
+// or dropped connection are retried
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"maxAttempts"`    // Total attempts including the first one (default 3; 1 disables retries)
+	InitialBackoff time.Duration `yaml:"initialBackoff"` // Wait before the first retry, doubled for each further retry (default 1s)
+	MaxBackoff     time.Duration `yaml:"maxBackoff"`     // Upper bound for the wait between attempts (default 30s)
+}
+
+// MetricsConfig represents where the Prometheus metrics of each run are written
+type MetricsConfig struct {
+	Textfile    string            `yaml:"textfile"`    // File for the node_exporter textfile collector (e.g. /var/lib/node_exporter/textfile/mydatasyncer.prom)
+	Pushgateway string            `yaml:"pushgateway"` // Pushgateway base URL (e.g. http://localhost:9091)
+	Job         string            `yaml:"job"`         // Pushgateway job name (default: mydatasyncer)
+	Labels      map[string]string `yaml:"labels"`      // Additional Pushgateway grouping labels (e.g. instance: batch-01)
+	Timeout     time.Duration     `yaml:"timeout"`     // Pushgateway request timeout (default: 10s)
+}
+
+// ReportConfig represents where the end-of-run summary report is written
+type ReportConfig struct {
+	JSON string `yaml:"json"` // Path of the JSON run summary
+	HTML string `yaml:"html"` // Path of the self-contained HTML report
+}
+
+// Notification payload formats for webhooks
+const (
+	NotifyFormatGeneric = "generic"
+	NotifyFormatSlack   = "slack"
+	NotifyFormatTeams   = "teams"
+)
+
+// Defaults for sending notifications
+const (
+	DefaultNotifyTimeout     = 10 * time.Second
+	DefaultNotifyMaxAttempts = 3
+)
+
+// NotifyConfig represents the notifications sent at the end of a run
+type NotifyConfig struct {
+	OnSuccess   []NotifyTarget `yaml:"onSuccess"`   // Sent when the run succeeded
+	OnFailure   []NotifyTarget `yaml:"onFailure"`   // Sent when the run failed
+	OnDrift     []NotifyTarget `yaml:"onDrift"`     // Sent when a successful run found differences (planned ones in dry-run mode)
+	Timeout     time.Duration  `yaml:"timeout"`     // Time limit for each attempt (default: 10s)
+	MaxAttempts int            `yaml:"maxAttempts"` // Attempts per notification including the first one (default: 3)
+}
+
+// NotifyTarget is a webhook or a local command that receives a notification
+type NotifyTarget struct {
+	Webhook string            `yaml:"webhook"` // URL that receives the notification as a JSON POST
+	Format  string            `yaml:"format"`  // Webhook payload: generic (default), slack or teams
+	Headers map[string]string `yaml:"headers"` // Additional HTTP headers (e.g. Authorization)
+	Command []string          `yaml:"command"` // Command and arguments; the run summary is passed as JSON on stdin
+}
+
 // DBConfig represents database connection settings
+// The DSN is taken from dsn, then dsnFile, and is otherwise assembled from the individual connection fields.
 type DBConfig struct {
-	DSN string `yaml:"dsn"` // Data Source Name (example: "user:password@tcp(127.0.0.1:3306)/dbname")
+	DSN          string            `yaml:"dsn"`          // Data Source Name (example: "user:password@tcp(127.0.0.1:3306)/dbname")
+	DSNFile      string            `yaml:"dsnFile"`      // File containing the DSN (e.g. a mounted secret)
+	User         string            `yaml:"user"`         // Database user name
+	Password     string            `yaml:"password"`     // Database password (prefer passwordFile or ${VAR} expansion)
+	PasswordFile string            `yaml:"passwordFile"` // File containing the database password
+	Host         string            `yaml:"host"`         // Database host, optionally with port (example: "127.0.0.1:3306")
+	Database     string            `yaml:"database"`     // Database name
+	Params       map[string]string `yaml:"params"`       // Additional DSN parameters (example: parseTime: "true")
+	Timezone     string            `yaml:"timezone"`     // Time zone of the database session for DATETIME values (e.g. Asia/Tokyo or +09:00; default UTC)
+}
+
+// Validation rule severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidationRule checks one column of every loaded record before the sync
+// Empty values only fail notNull; every other check applies to non-empty values.
+type ValidationRule struct {
+	Column     string   `yaml:"column"`     // Column to check
+	Severity   string   `yaml:"severity"`   // "error" (default) aborts the sync, "warning" only reports the records
+	NotNull    bool     `yaml:"notNull"`    // The value must not be missing or empty
+	Regex      string   `yaml:"regex"`      // The value must match the regular expression (anchor with ^...$ to match the whole value)
+	Min        *float64 `yaml:"min"`        // The value must be a number not below min
+	Max        *float64 `yaml:"max"`        // The value must be a number not above max
+	MinLength  *int     `yaml:"minLength"`  // The value must have at least minLength characters
+	MaxLength  *int     `yaml:"maxLength"`  // The value must have at most maxLength characters
+	Enum       []string `yaml:"enum"`       // The value must be one of these values
+	Unique     bool     `yaml:"unique"`     // The value must not repeat in the file
+	DateFormat string   `yaml:"dateFormat"` // The value must be a date in this Go layout (e.g. "2006-01-02")
+}
+
+// CSVOptions holds the dialect of a CSV or TSV file
+type CSVOptions struct {
+	Delimiter        string `yaml:"delimiter"`        // Field delimiter, one character (default "," or a tab for .tsv files)
+	Quote            string `yaml:"quote"`            // Quote character, one ASCII character (default '"')
+	LazyQuotes       bool   `yaml:"lazyQuotes"`       // Allow quotes in unquoted fields and unescaped quotes in quoted fields
+	TrimLeadingSpace bool   `yaml:"trimLeadingSpace"` // Ignore leading white space in fields
+	Comment          string `yaml:"comment"`          // Lines starting with this character are skipped
+	SkipLines        int    `yaml:"skipLines"`        // Number of lines skipped at the start of the file, before the header
+	Header           *bool  `yaml:"header"`           // Whether the first line is a header row (default true); without one, fields map to columns by position
+}
+
+// JSONOptions locates the records and nested values of JSON and NDJSON files
+type JSONOptions struct {
+	RecordsPath string            `yaml:"recordsPath"` // Path of the array of records, e.g. data.items (default: the top-level array; .json only)
+	Sources     map[string]string `yaml:"sources"`     // Path of each column's value within a record, e.g. price: price.amount
+}
+
+// XLSXOptions selects the sheet and cells of an Excel workbook
+type XLSXOptions struct {
+	Sheet      string `yaml:"sheet"`      // Name of the sheet (default: the first sheet)
+	SheetIndex int    `yaml:"sheetIndex"` // 1-based position of the sheet, instead of its name
+	HeaderRow  int    `yaml:"headerRow"`  // 1-based row number of the header row (default: the first row of the range)
+	Range      string `yaml:"range"`      // Cell range of the header and data rows, e.g. B3:F200 (default: the whole sheet)
+}
+
+// TransformRule computes the value of one column by applying its steps in order
+type TransformRule struct {
+	Column string          `yaml:"column"` // Column to transform or compute
+	Steps  []TransformStep `yaml:"steps"`  // Functions applied in order; each step sets exactly one function
+}
+
+// TransformStep is one function of a transform pipeline
+// Except for default and concat, steps leave NULL values unchanged.
+type TransformStep struct {
+	Trim    bool              `yaml:"trim"`    // Remove leading and trailing whitespace
+	Upper   bool              `yaml:"upper"`   // Convert to upper case
+	Lower   bool              `yaml:"lower"`   // Convert to lower case
+	Replace *ReplaceStep      `yaml:"replace"` // Replace every occurrence of a substring
+	Extract string            `yaml:"extract"` // Keep the first capture group (or the whole match) of a regular expression; "" if it does not match
+	Map     map[string]string `yaml:"map"`     // Replace values found in the map; other values are kept
+	Default *string           `yaml:"default"` // Value used when the value is NULL or empty
+	Concat  string            `yaml:"concat"`  // Template with {column} placeholders, e.g. "{last_name}, {first_name}"
+	Date    *DateStep         `yaml:"date"`    // Parse a date in one layout and format it in another
+	Number  *NumberStep       `yaml:"number"`  // Parse a localized number into plain digits with a '.' decimal point
+}
+
+// ReplaceStep replaces every occurrence of Old with New
+type ReplaceStep struct {
+	Old string `yaml:"old"`
+	New string `yaml:"new"`
+}
+
+// DateStep reformats dates; layouts use the Go reference time (e.g. "02/01/2006")
+type DateStep struct {
+	From string `yaml:"from"` // Layout of the file values
+	To   string `yaml:"to"`   // Layout of the result (default: "2006-01-02 15:04:05")
+}
+
+// NumberStep parses numbers written with locale-specific separators (e.g. "1.234,5")
+type NumberStep struct {
+	Decimal   string `yaml:"decimal"`   // Decimal separator (default: ".")
+	Thousands string `yaml:"thousands"` // Thousands separator, removed before parsing (default: none)
+}
+
+// Primary key validation modes
+const (
+	PrimaryKeyModeStrict = "strict"
+	PrimaryKeyModeWarn   = "warn"
+)
+
+// PrimaryKeyValidationConfig represents how the primary keys of the loaded records are validated
+type PrimaryKeyValidationConfig struct {
+	MaxLength      int      `yaml:"maxLength"`      // Maximum key length (default: 255)
+	Mode           string   `yaml:"mode"`           // "strict" (default) aborts the sync; "warn" skips invalid records and syncs the rest
+	NullTokens     []string `yaml:"nullTokens"`     // Values treated as NULL, case-insensitive (default: null, nil, \N, n/a, na, none, undefined; [] for none)
+	Pattern        string   `yaml:"pattern"`        // Regular expression every key must match (anchor with ^...$ to match the whole key)
+	TrimWhitespace bool     `yaml:"trimWhitespace"` // Trim leading and trailing whitespace from keys instead of rejecting them
 }
 
 // SyncConfig represents data synchronization settings (legacy single table config)
 type SyncConfig struct {
-	FilePath         string   `yaml:"filePath"`         // Input file path
-	TableName        string   `yaml:"tableName"`        // Target table name
-	Columns          []string `yaml:"columns"`          // DB column names corresponding to file columns (order is important)
-	TimestampColumns []string `yaml:"timestampColumns"` // Column names to set current timestamp on insert/update
-	ImmutableColumns []string `yaml:"immutableColumns"` // Column names that should not be updated in diff mode
-	PrimaryKey       string   `yaml:"primaryKey"`       // Primary key column name (required for differential update)
-	SyncMode         string   `yaml:"syncMode"`         // "overwrite" or "diff" (differential)
-	DeleteNotInFile  bool     `yaml:"deleteNotInFile"`  // Whether to delete records not in file when using diff mode
+	FilePath         string            `yaml:"filePath"`         // Input file path
+	TableName        string            `yaml:"tableName"`        // Target table name
+	Columns          []string          `yaml:"columns"`          // DB column names corresponding to file columns (order is important)
+	TimestampColumns []string          `yaml:"timestampColumns"` // Column names to set current timestamp on insert/update
+	ImmutableColumns []string          `yaml:"immutableColumns"` // Column names that should not be updated in diff mode
+	PrimaryKey       string            `yaml:"primaryKey"`       // Primary key column name (required for differential update)
+	SyncMode         string            `yaml:"syncMode"`         // "overwrite" or "diff" (differential)
+	DeleteNotInFile  bool              `yaml:"deleteNotInFile"`  // Whether to delete records not in file when using diff mode
+	Validate         []ValidationRule  `yaml:"validate"`         // Column validation rules checked before the sync
+	SchemaCheck      bool              `yaml:"schemaCheck"`      // Check every value against the column types of the table before the sync
+	Transform        []TransformRule   `yaml:"transform"`        // Value transformations applied to the loaded records before the sync
+	Constants        map[string]string `yaml:"constants"`        // Values written to these columns in every row; they also limit the sync to the matching rows
+	Scope            map[string]string `yaml:"scope"`            // Only rows with these column values are synced; file rows outside the scope are rejected
+	Encoding         string            `yaml:"encoding"`         // Character encoding of the file (e.g. cp932); empty detects a byte order mark
+	CSV              CSVOptions        `yaml:"csv"`              // Dialect of CSV and TSV files
+	JSON             JSONOptions       `yaml:"json"`             // Record and value paths of JSON and NDJSON files
+	XLSX             XLSXOptions       `yaml:"xlsx"`             // Sheet, header row and cell range of Excel files
+	DateFormats      map[string]string `yaml:"dateFormats"`      // Go layout (e.g. "2006/01/02"), unix or unixms of date columns in the file
+	Timezone         string            `yaml:"timezone"`         // Time zone of file dates without an offset (default: the database time zone)
+
+	PrimaryKeyValidation PrimaryKeyValidationConfig `yaml:"primaryKeyValidation,omitempty"` // Primary key validation settings
 }
 
 // TableSyncConfig represents synchronization settings for a single table
 type TableSyncConfig struct {
-	Name             string   `yaml:"name"`             // Target table name
-	FilePath         string   `yaml:"filePath"`         // Input file path
-	Columns          []string `yaml:"columns"`          // DB column names corresponding to file columns (order is important)
-	TimestampColumns []string `yaml:"timestampColumns"` // Column names to set current timestamp on insert/update
-	ImmutableColumns []string `yaml:"immutableColumns"` // Column names that should not be updated in diff mode
-	PrimaryKey       string   `yaml:"primaryKey"`       // Primary key column name (required for differential update)
-	SyncMode         string   `yaml:"syncMode"`         // "overwrite" or "diff" (differential)
-	DeleteNotInFile  bool     `yaml:"deleteNotInFile"`  // Whether to delete records not in file when using diff mode
-	Dependencies     []string `yaml:"dependencies"`     // List of table names this table depends on (foreign key parents)
+	Name             string            `yaml:"name"`             // Target table name
+	FilePath         string            `yaml:"filePath"`         // Input file path
+	Columns          []string          `yaml:"columns"`          // DB column names corresponding to file columns (order is important)
+	TimestampColumns []string          `yaml:"timestampColumns"` // Column names to set current timestamp on insert/update
+	ImmutableColumns []string          `yaml:"immutableColumns"` // Column names that should not be updated in diff mode
+	PrimaryKey       string            `yaml:"primaryKey"`       // Primary key column name (required for differential update)
+	SyncMode         string            `yaml:"syncMode"`         // "overwrite" or "diff" (differential)
+	DeleteNotInFile  bool              `yaml:"deleteNotInFile"`  // Whether to delete records not in file when using diff mode
+	Dependencies     []string          `yaml:"dependencies"`     // List of table names this table depends on (foreign key parents)
+	Timeout          time.Duration     `yaml:"timeout"`          // Time limit for each sync phase of this table (e.g. "10m"); 0 means only the overall timeout applies
+	Validate         []ValidationRule  `yaml:"validate"`         // Column validation rules checked before the sync
+	SchemaCheck      bool              `yaml:"schemaCheck"`      // Check every value against the column types of the table before the sync
+	Transform        []TransformRule   `yaml:"transform"`        // Value transformations applied to the loaded records before the sync
+	Constants        map[string]string `yaml:"constants"`        // Values written to these columns in every row; they also limit the sync to the matching rows
+	Scope            map[string]string `yaml:"scope"`            // Only rows with these column values are synced; file rows outside the scope are rejected
+	Encoding         string            `yaml:"encoding"`         // Character encoding of the file (e.g. cp932); empty detects a byte order mark
+	CSV              CSVOptions        `yaml:"csv"`              // Dialect of CSV and TSV files
+	JSON             JSONOptions       `yaml:"json"`             // Record and value paths of JSON and NDJSON files
+	XLSX             XLSXOptions       `yaml:"xlsx"`             // Sheet, header row and cell range of Excel files
+	DateFormats      map[string]string `yaml:"dateFormats"`      // Go layout (e.g. "2006/01/02"), unix or unixms of date columns in the file
+	Timezone         string            `yaml:"timezone"`         // Time zone of file dates without an offset (default: the database time zone)
+
+	PrimaryKeyValidation PrimaryKeyValidationConfig `yaml:"primaryKeyValidation,omitempty"` // Primary key validation settings
 }
 
 // Config represents configuration information
 type Config struct {
-	DB     DBConfig          `yaml:"db"`
-	Sync   SyncConfig        `yaml:"sync"`             // Legacy single table sync config (for backward compatibility)
-	Tables []TableSyncConfig `yaml:"tables,omitempty"` // Multi-table sync config
-	DryRun bool              `yaml:"dryRun"`           // Enable dry-run mode
+	Include  []string          `yaml:"include,omitempty"`  // Config files merged before this file (paths relative to this file)
+	Defaults TableSyncConfig   `yaml:"defaults,omitempty"` // Settings inherited by every entry in tables unless the entry sets them
+	DB       DBConfig          `yaml:"db"`
+	Sync     SyncConfig        `yaml:"sync,omitempty"`   // Legacy single table sync config (for backward compatibility)
+	Tables   []TableSyncConfig `yaml:"tables,omitempty"` // Multi-table sync config
+	DryRun   bool              `yaml:"dryRun"`           // Enable dry-run mode
+
+	Timeout          time.Duration `yaml:"timeout"`           // Overall time limit for the run (e.g. "2h"); default DefaultTimeout
+	StatementTimeout time.Duration `yaml:"statementTimeout"`  // Time limit for each SQL statement (e.g. "30s"); 0 means no limit
+	Lock             LockConfig    `yaml:"lock,omitempty"`    // Lock against concurrent runs on the same tables
+	Retry            RetryConfig   `yaml:"retry,omitempty"`   // Retry of transactions failed by transient errors
+	Metrics          MetricsConfig `yaml:"metrics,omitempty"` // Prometheus metrics output
+	Report           ReportConfig  `yaml:"report,omitempty"`  // End-of-run summary report
+	Notify           NotifyConfig  `yaml:"notify,omitempty"`  // Notifications at the end of the run
 }
 
 // NewDefaultConfig returns a Config struct with default values
@@ -75,41 +312,384 @@ func NewDefaultConfig() Config {
 }
 
 // LoadConfig loads configuration from file specified by configPath
-// Falls back to default configuration if the file doesn't exist or contains errors
-func LoadConfig(configPath string) Config {
+// Unknown keys are rejected with line-numbered errors. A missing file is reported as an error
+// wrapping os.ErrNotExist so that the caller can decide whether falling back to NewDefaultConfig is acceptable.
+func LoadConfig(configPath string) (Config, error) {
+	return LoadConfigForEnv(configPath, "")
+}
+
+// defaultConfigPath is the config file used when no path is given
+const defaultConfigPath = "mydatasyncer.yml"
+
+// LoadConfigForEnv loads configuration from configPath and applies the overlay for the given environment.
+// The merge order is: included files, the config file itself, the environment overlay
+// (<name>.<env>.yml next to the config file), and finally the defaults block for every table.
+func LoadConfigForEnv(configPath string, env string) (Config, error) {
 	// If no config path is provided, use the default
 	if configPath == "" {
-		configPath = "mydatasyncer.yml"
+		configPath = defaultConfigPath
 	}
 
-	// Check for the config file
-	data, err := os.ReadFile(configPath)
-
-	// If config file not found or error reading, use default configuration
+	merged, err := loadConfigTree(configPath, nil)
 	if err != nil {
-		if os.IsNotExist(err) {
-			fmt.Printf("Config file '%s' not found. Using default configuration.\n", configPath)
-		} else {
-			fmt.Printf("Warning: Error reading config file %s: %v\n", configPath, err)
-			fmt.Println("Using default configuration")
+		return Config{}, err
+	}
+
+	slog.Info("Using config file", "path", configPath)
+
+	if env != "" {
+		overlayPath := envOverlayPath(configPath, env)
+		overlay, err := loadConfigTree(overlayPath, nil)
+		if err != nil {
+			return Config{}, fmt.Errorf("error loading overlay for environment '%s': %w", env, err)
 		}
-		return NewDefaultConfig()
+		slog.Info("Using environment overlay", "path", overlayPath)
+		merged = mergeConfigMaps(merged, overlay)
 	}
 
-	fmt.Printf("Using config file: %s\n", configPath)
+	if err := applyTableDefaults(merged); err != nil {
+		return Config{}, fmt.Errorf("invalid defaults in config file '%s': %w", configPath, err)
+	}
 
-	var cfg Config
-	err = yaml.Unmarshal(data, &cfg)
+	// Decode the merged document; every source file has already been checked for unknown keys
+	// JSON style quotes every string, so values such as a tab delimiter survive the round trip.
+	data, err := yaml.MarshalWithOptions(merged, yaml.JSON())
 	if err != nil {
-		fmt.Printf("Warning: Could not parse config file %s: %v\n", configPath, err)
-		fmt.Println("Using default configuration")
-		return NewDefaultConfig()
+		return Config{}, fmt.Errorf("error merging config file '%s': %w", configPath, err)
+	}
+	var cfg Config
+	if err := yaml.UnmarshalWithOptions(data, &cfg, yaml.Strict()); err != nil {
+		return Config{}, fmt.Errorf("could not parse merged config for '%s':\n%s", configPath, yaml.FormatError(err, false, true))
 	}
 
+	// Expand ${VAR} references and assemble the DSN from its parts
+	if err := expandConfigEnv(&cfg); err != nil {
+		return Config{}, fmt.Errorf("could not expand environment variables in config file '%s': %w", configPath, err)
+	}
+	if err := resolveDSN(&cfg.DB); err != nil {
+		return Config{}, fmt.Errorf("could not resolve database DSN from config file '%s': %w", configPath, err)
+	}
+	registerNotifySecrets(cfg.Notify)
+
 	// Set default values for fields not specified in the config file
 	setDefaultsIfNeeded(&cfg)
 
-	return cfg
+	return cfg, nil
+}
+
+// loadConfigTree reads one config file, validates it strictly and merges its include files into it.
+// includeStack holds the absolute paths of the files currently being included, to detect include cycles.
+func loadConfigTree(configPath string, includeStack []string) (map[string]any, error) {
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving config file path '%s': %w", configPath, err)
+	}
+	if slices.Contains(includeStack, absPath) {
+		return nil, fmt.Errorf("include cycle detected: %s -> %s", strings.Join(includeStack, " -> "), absPath)
+	}
+	includeStack = append(includeStack, absPath)
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("config file '%s' not found: %w", configPath, err)
+		}
+		return nil, fmt.Errorf("error reading config file '%s': %w", configPath, err)
+	}
+
+	// Strict decoding rejects unknown keys (typos) instead of silently ignoring them
+	var fileCfg Config
+	if err := yaml.UnmarshalWithOptions(data, &fileCfg, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("could not parse config file '%s':\n%s", configPath, yaml.FormatError(err, false, true))
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("could not parse config file '%s': %w", configPath, err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+	delete(doc, "include")
+
+	merged := map[string]any{}
+	for _, include := range fileCfg.Include {
+		includePath := include
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(configPath), includePath)
+		}
+		included, err := loadConfigTree(includePath, includeStack)
+		if err != nil {
+			return nil, fmt.Errorf("error including '%s' from '%s': %w", include, configPath, err)
+		}
+		merged = mergeConfigMaps(merged, included)
+	}
+
+	// The including file overrides anything it includes
+	return mergeConfigMaps(merged, doc), nil
+}
+
+// envOverlayPath returns the overlay file for an environment, e.g. config.yml + prod -> config.prod.yml
+func envOverlayPath(configPath string, env string) string {
+	ext := filepath.Ext(configPath)
+	return strings.TrimSuffix(configPath, ext) + "." + env + ext
+}
+
+// mergeConfigMaps merges override into base and returns the result.
+// Maps are merged recursively, table entries are merged by name, and any other value in override replaces base.
+func mergeConfigMaps(base, override map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(override))
+	maps.Copy(result, base)
+
+	for key, overrideVal := range override {
+		baseVal, exists := result[key]
+		if !exists {
+			result[key] = overrideVal
+			continue
+		}
+
+		baseMap, baseIsMap := baseVal.(map[string]any)
+		overrideMap, overrideIsMap := overrideVal.(map[string]any)
+		if baseIsMap && overrideIsMap {
+			result[key] = mergeConfigMaps(baseMap, overrideMap)
+			continue
+		}
+
+		baseList, baseIsList := baseVal.([]any)
+		overrideList, overrideIsList := overrideVal.([]any)
+		if key == "tables" && baseIsList && overrideIsList {
+			result[key] = mergeTableLists(baseList, overrideList)
+			continue
+		}
+
+		result[key] = overrideVal
+	}
+
+	return result
+}
+
+// mergeTableLists merges table entries by name; entries without a match are appended in order
+func mergeTableLists(base, override []any) []any {
+	result := slices.Clone(base)
+	for _, overrideEntry := range override {
+		overrideTable, ok := overrideEntry.(map[string]any)
+		if !ok {
+			result = append(result, overrideEntry)
+			continue
+		}
+
+		merged := false
+		for i, baseEntry := range result {
+			baseTable, ok := baseEntry.(map[string]any)
+			if ok && baseTable["name"] != nil && baseTable["name"] == overrideTable["name"] {
+				result[i] = mergeConfigMaps(baseTable, overrideTable)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			result = append(result, overrideTable)
+		}
+	}
+	return result
+}
+
+// applyTableDefaults copies every key of the defaults block into table entries that do not set it
+func applyTableDefaults(doc map[string]any) error {
+	rawDefaults, exists := doc["defaults"]
+	delete(doc, "defaults")
+	if !exists || rawDefaults == nil {
+		return nil
+	}
+
+	defaults, ok := rawDefaults.(map[string]any)
+	if !ok {
+		return fmt.Errorf("defaults must be a mapping")
+	}
+	for _, key := range []string{"name", "filePath", "dependencies"} {
+		if _, exists := defaults[key]; exists {
+			return fmt.Errorf("'%s' is table-specific and cannot be set in defaults", key)
+		}
+	}
+
+	tables, _ := doc["tables"].([]any)
+	for i, entry := range tables {
+		table, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		tables[i] = mergeConfigMaps(defaults, table)
+	}
+	return nil
+}
+
+// PrintConfig writes the fully merged configuration as YAML with secrets masked
+func PrintConfig(w io.Writer, cfg Config) error {
+	printable := cfg
+	if IsMultiTableConfig(cfg) {
+		// Legacy sync settings are ignored for multi-table configs; only their defaults would be shown
+		printable.Sync = SyncConfig{}
+	}
+
+	data, err := yaml.MarshalWithOptions(printable, yaml.OmitEmpty())
+	if err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	_, err = io.WriteString(w, maskSecrets(string(data)))
+	return err
+}
+
+// envVarPattern matches ${VAR} and ${VAR:-default} references in config values
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv replaces ${VAR} and ${VAR:-default} references in s with environment variable values
+// A variable that is not set and has no default is an error, so a missing secret never becomes an empty string
+func expandEnv(s string) (string, error) {
+	var missing []string
+	expanded := envVarPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == runIDPlaceholder {
+			return ref // Reserved; replaced by the run ID when the constants are applied
+		}
+		m := envVarPattern.FindStringSubmatch(ref)
+		val, ok := os.LookupEnv(m[1])
+		switch {
+		case ok && (val != "" || m[2] == ""):
+			return val
+		case m[2] != "":
+			return m[3] // ${VAR:-default} uses the default when VAR is unset or empty
+		default:
+			missing = append(missing, m[1])
+			return ref
+		}
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable(s) not set: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// expandConfigEnv expands environment variable references in every string field of the config
+func expandConfigEnv(cfg *Config) error {
+	return expandEnvInValue(reflect.ValueOf(cfg).Elem(), "")
+}
+
+// expandEnvInValue walks structs, slices and maps and expands every string it finds
+func expandEnvInValue(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.String:
+		expanded, err := expandEnv(v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		v.SetString(expanded)
+	case reflect.Struct:
+		t := v.Type()
+		for i := range v.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if err := expandEnvInValue(v.Field(i), joinFieldPath(path, field)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			if err := expandEnvInValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range v.MapKeys() {
+			expanded, err := expandEnv(v.MapIndex(key).String())
+			if err != nil {
+				return fmt.Errorf("%s.%v: %w", path, key.Interface(), err)
+			}
+			v.SetMapIndex(key, reflect.ValueOf(expanded).Convert(v.Type().Elem()))
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return expandEnvInValue(v.Elem(), path)
+		}
+	}
+	return nil
+}
+
+// joinFieldPath builds a dotted config path using the YAML key of the field
+func joinFieldPath(path string, field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// resolveDSN determines the final DSN from dsn, dsnFile or the individual connection fields
+// The password is registered as a secret so that it is masked in all log output and error messages
+func resolveDSN(db *DBConfig) error {
+	if db.DSN != "" && db.DSNFile != "" {
+		return fmt.Errorf("only one of dsn and dsnFile can be set")
+	}
+	if db.Password != "" && db.PasswordFile != "" {
+		return fmt.Errorf("only one of password and passwordFile can be set")
+	}
+
+	switch {
+	case db.DSN != "":
+		// DSN given directly
+	case db.DSNFile != "":
+		dsn, err := readSecretFile(db.DSNFile)
+		if err != nil {
+			return fmt.Errorf("error reading dsnFile: %w", err)
+		}
+		db.DSN = dsn
+	case db.Host != "" || db.Database != "" || db.User != "":
+		password := db.Password
+		if db.PasswordFile != "" {
+			p, err := readSecretFile(db.PasswordFile)
+			if err != nil {
+				return fmt.Errorf("error reading passwordFile: %w", err)
+			}
+			password = p
+		}
+		registerSecret(password)
+
+		mysqlCfg := mysql.NewConfig()
+		mysqlCfg.User = db.User
+		mysqlCfg.Passwd = password
+		mysqlCfg.Net = "tcp"
+		mysqlCfg.Addr = db.Host
+		mysqlCfg.DBName = db.Database
+		if len(db.Params) > 0 {
+			mysqlCfg.Params = maps.Clone(db.Params)
+		}
+		db.DSN = mysqlCfg.FormatDSN()
+	default:
+		// Nothing configured; ValidateConfig reports the missing DSN
+		return nil
+	}
+
+	registerDSNSecrets(db.DSN)
+	return nil
+}
+
+// readSecretFile reads a secret from a file, trimming the trailing newline most secret stores add
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimRight(string(data), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("file '%s' is empty", path)
+	}
+	return secret, nil
 }
 
 // setDefaultsIfNeeded sets default values for fields that are not specified in the config file
@@ -144,6 +724,31 @@ func ValidateConfig(cfg Config) error {
 	if cfg.DB.DSN == "" {
 		return fmt.Errorf("database DSN is required")
 	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if cfg.StatementTimeout < 0 {
+		return fmt.Errorf("statementTimeout must not be negative")
+	}
+	if err := validateLockConfig(cfg.Lock); err != nil {
+		return err
+	}
+	if err := validateRetryConfig(cfg.Retry); err != nil {
+		return err
+	}
+	if err := validateMetricsConfig(cfg.Metrics); err != nil {
+		return err
+	}
+	if err := validateNotifyConfig(cfg.Notify); err != nil {
+		return err
+	}
+	if _, err := loadTimezone(cfg.DB.Timezone); err != nil {
+		return fmt.Errorf("db timezone: %w", err)
+	}
+	if cfg.DB.Timezone == "Local" {
+		// The zone is also the session time_zone, and MySQL does not know Local
+		return fmt.Errorf("db timezone: 'Local' is not a MySQL time zone; use an IANA name or a UTC offset")
+	}
 
 	// Check if using multi-table sync or legacy single table sync
 	if len(cfg.Tables) == 0 && (cfg.Sync.FilePath != "" || cfg.Sync.TableName != "") {
@@ -155,6 +760,103 @@ func ValidateConfig(cfg Config) error {
 	return validateMultiTableConfig(cfg)
 }
 
+// validIdentifier matches table and column names that can be used in SQL without quoting
+var validIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateLockConfig validates the lock settings
+func validateLockConfig(lock LockConfig) error {
+	switch lock.Mode {
+	case "", LockModeGetLock, LockModeTable, LockModeNone:
+	default:
+		return fmt.Errorf("lock mode must be one of '%s', '%s' or '%s'", LockModeGetLock, LockModeTable, LockModeNone)
+	}
+	if lock.WaitTimeout < 0 {
+		return fmt.Errorf("lock waitTimeout must not be negative")
+	}
+	if lock.Table != "" && !validIdentifier.MatchString(lock.Table) {
+		return fmt.Errorf("lock table '%s' is not a valid table name", lock.Table)
+	}
+	return nil
+}
+
+// validateRetryConfig validates the retry settings
+func validateRetryConfig(retry RetryConfig) error {
+	if retry.MaxAttempts < 0 {
+		return fmt.Errorf("retry maxAttempts must not be negative")
+	}
+	if retry.InitialBackoff < 0 || retry.MaxBackoff < 0 {
+		return fmt.Errorf("retry backoff must not be negative")
+	}
+	if retry.InitialBackoff > 0 && retry.MaxBackoff > 0 && retry.MaxBackoff < retry.InitialBackoff {
+		return fmt.Errorf("retry maxBackoff must not be less than initialBackoff")
+	}
+	return nil
+}
+
+// validateMetricsConfig validates the metrics settings
+func validateMetricsConfig(metrics MetricsConfig) error {
+	if metrics.Pushgateway != "" {
+		u, err := url.Parse(metrics.Pushgateway)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("metrics pushgateway must be an http(s) URL, got '%s'", metrics.Pushgateway)
+		}
+	}
+	if metrics.Timeout < 0 {
+		return fmt.Errorf("metrics timeout must not be negative")
+	}
+	return nil
+}
+
+// validateNotifyConfig validates the notification settings
+func validateNotifyConfig(notify NotifyConfig) error {
+	if notify.Timeout < 0 {
+		return fmt.Errorf("notify timeout must not be negative")
+	}
+	if notify.MaxAttempts < 0 {
+		return fmt.Errorf("notify maxAttempts must not be negative")
+	}
+	for _, hook := range []struct {
+		name    string
+		targets []NotifyTarget
+	}{
+		{"onSuccess", notify.OnSuccess},
+		{"onFailure", notify.OnFailure},
+		{"onDrift", notify.OnDrift},
+	} {
+		for i, target := range hook.targets {
+			if err := validateNotifyTarget(target); err != nil {
+				return fmt.Errorf("notify %s[%d]: %w", hook.name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNotifyTarget validates a single webhook or command target
+func validateNotifyTarget(target NotifyTarget) error {
+	if (target.Webhook == "") == (len(target.Command) == 0) {
+		return fmt.Errorf("exactly one of webhook and command must be set")
+	}
+	if len(target.Command) > 0 {
+		if target.Format != "" || len(target.Headers) > 0 {
+			return fmt.Errorf("format and headers only apply to webhooks")
+		}
+		return nil
+	}
+	u, err := url.Parse(target.Webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		// The URL often contains a token, so it is not repeated in the error
+		return fmt.Errorf("webhook must be an http(s) URL")
+	}
+	switch target.Format {
+	case "", NotifyFormatGeneric, NotifyFormatSlack, NotifyFormatTeams:
+	default:
+		return fmt.Errorf("unknown webhook format '%s' (expected '%s', '%s' or '%s')",
+			target.Format, NotifyFormatGeneric, NotifyFormatSlack, NotifyFormatTeams)
+	}
+	return nil
+}
+
 // validateSingleTableConfig validates legacy single table configuration
 func validateSingleTableConfig(cfg Config) error {
 	// Check Sync configuration
@@ -171,7 +873,37 @@ func validateSingleTableConfig(cfg Config) error {
 	if cfg.Sync.SyncMode == SyncModeDiff && cfg.Sync.PrimaryKey == "" {
 		return fmt.Errorf("primary key is required for diff sync mode")
 	}
-	return nil
+	if _, err := NewPrimaryKeyValidatorFromConfig(cfg.Sync.PrimaryKeyValidation); err != nil {
+		return fmt.Errorf("primaryKeyValidation: %w", err)
+	}
+	if err := validateWarnModeDeletes(cfg.Sync.PrimaryKeyValidation, cfg.Sync.SyncMode, cfg.Sync.DeleteNotInFile); err != nil {
+		return err
+	}
+	if err := validateTransformRules(cfg.Sync.Transform, cfg.Sync.Columns); err != nil {
+		return err
+	}
+	if err := validateConstants(cfg.Sync.Constants, cfg.Sync.Columns, cfg.Sync.PrimaryKey, cfg.Sync.Transform); err != nil {
+		return err
+	}
+	if err := validateScope(cfg.Sync.Scope, cfg.Sync.Constants, cfg.Sync.Columns); err != nil {
+		return err
+	}
+	if err := validateFileEncoding(cfg.Sync.Encoding, cfg.Sync.FilePath); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+	if err := validateCSVOptions(cfg.Sync.CSV, cfg.Sync.FilePath, cfg.Sync.Columns, cfg.Sync.Transform, cfg.Sync.Constants); err != nil {
+		return err
+	}
+	if err := validateJSONOptions(cfg.Sync.JSON, cfg.Sync.FilePath, cfg.Sync.Columns); err != nil {
+		return err
+	}
+	if err := validateXLSXOptions(cfg.Sync.XLSX, cfg.Sync.FilePath); err != nil {
+		return err
+	}
+	if err := validateDateFormats(cfg.Sync.DateFormats, cfg.Sync.Timezone, cfg.Sync.Columns); err != nil {
+		return err
+	}
+	return validateValidationRules(cfg.Sync.Validate, cfg.Sync.Columns)
 }
 
 // validateMultiTableConfig validates multi-table configuration
@@ -206,6 +938,42 @@ func validateTablesBasicFields(tables []TableSyncConfig) error {
 		if table.SyncMode == SyncModeDiff && table.PrimaryKey == "" {
 			return fmt.Errorf("table[%d] (%s): primary key is required for diff sync mode", i, table.Name)
 		}
+		if table.Timeout < 0 {
+			return fmt.Errorf("table[%d] (%s): timeout must not be negative", i, table.Name)
+		}
+		if err := validateTransformRules(table.Transform, table.Columns); err != nil {
+			return fmt.Errorf("table[%d] (%s): %w", i, table.Name, err)
+		}
+		if err := validateConstants(table.Constants, table.Columns, table.PrimaryKey, table.Transform); err != nil {
+			return fmt.Errorf("table[%d] (%s): %w", i, table.Name, err)
+		}
+		if err := validateScope(table.Scope, table.Constants, table.Columns); err != nil {
+			return fmt.Errorf("table[%d] (%s): %w", i, table.Name, err)
+		}
+		if err := validateFileEncoding(table.Encoding, table.FilePath); err != nil {
+			return fmt.Errorf("table[%d] (%s): encoding: %w", i, table.Name, err)
+		}
+		if err := validateCSVOptions(table.CSV, table.FilePath, table.Columns, table.Transform, table.Constants); err != nil {
+			return fmt.Errorf("table[%d] (%s): %w", i, table.Name, err)
+		}
+		if err := validateJSONOptions(table.JSON, table.FilePath, table.Columns); err != nil {
+			return fmt.Errorf("table[%d] (%s): %w", i, table.Name, err)
+		}
+		if err := validateXLSXOptions(table.XLSX, table.FilePath); err != nil {
+			return fmt.Errorf("table[%d] (%s): %w", i, table.Name, err)
+		}
+		if err := validateDateFormats(table.DateFormats, table.Timezone, table.Columns); err != nil {
+			return fmt.Errorf("table[%d] (%s): %w", i, table.Name, err)
+		}
+		if err := validateValidationRules(table.Validate, table.Columns); err != nil {
+			return fmt.Errorf("table[%d] (%s): %w", i, table.Name, err)
+		}
+		if _, err := NewPrimaryKeyValidatorFromConfig(table.PrimaryKeyValidation); err != nil {
+			return fmt.Errorf("table[%d] (%s): primaryKeyValidation: %w", i, table.Name, err)
+		}
+		if err := validateWarnModeDeletes(table.PrimaryKeyValidation, table.SyncMode, table.DeleteNotInFile); err != nil {
+			return fmt.Errorf("table[%d] (%s): %w", i, table.Name, err)
+		}
 
 		// Check for duplicate table names
 		if tableNames[table.Name] {
@@ -217,6 +985,190 @@ func validateTablesBasicFields(tables []TableSyncConfig) error {
 	return nil
 }
 
+// validateValidationRules checks that every rule names a known column, has a check and compiles
+// columns is the configured column list; when it is empty the columns come from the file and are not checked here.
+func validateValidationRules(rules []ValidationRule, columns []string) error {
+	for i, rule := range rules {
+		if rule.Column == "" {
+			return fmt.Errorf("validate[%d]: column is required", i)
+		}
+		if len(columns) > 0 && !slices.Contains(columns, rule.Column) {
+			return fmt.Errorf("validate[%d]: column '%s' is not in columns", i, rule.Column)
+		}
+		if _, err := NewRecordValidator([]ValidationRule{rule}); err != nil {
+			return fmt.Errorf("validate[%d] (%s): %w", i, rule.Column, err)
+		}
+	}
+	return nil
+}
+
+// validateTransformRules checks the transform rules of a table
+func validateTransformRules(rules []TransformRule, columns []string) error {
+	for i, rule := range rules {
+		if rule.Column == "" {
+			return fmt.Errorf("transform[%d]: column is required", i)
+		}
+		if len(columns) > 0 && !slices.Contains(columns, rule.Column) {
+			return fmt.Errorf("transform[%d]: column '%s' is not in columns", i, rule.Column)
+		}
+		if _, err := NewTransformer([]TransformRule{rule}); err != nil {
+			return fmt.Errorf("transform[%d] (%s): %w", i, rule.Column, err)
+		}
+	}
+	return nil
+}
+
+// validateConstants checks the constant columns of a table
+// Constants are set before the transforms run, so a transform of a constant column would change the
+// value that scopes the sync.
+func validateConstants(constants map[string]string, columns []string, primaryKey string, rules []TransformRule) error {
+	for _, column := range slices.Sorted(maps.Keys(constants)) {
+		if !validIdentifier.MatchString(column) {
+			return fmt.Errorf("constants: column '%s' is not a valid column name", column)
+		}
+		if column == primaryKey {
+			return fmt.Errorf("constants: column '%s' is the primary key", column)
+		}
+		if len(columns) > 0 && !slices.Contains(columns, column) {
+			return fmt.Errorf("constants: column '%s' is not in columns", column)
+		}
+		if slices.ContainsFunc(rules, func(rule TransformRule) bool { return rule.Column == column }) {
+			return fmt.Errorf("constants: column '%s' cannot also be transformed", column)
+		}
+	}
+	return nil
+}
+
+// validateScope checks the scope filter of a table
+// Scope columns must be loaded from the file so that rows outside the scope can be rejected.
+func validateScope(scope, constants map[string]string, columns []string) error {
+	for _, column := range slices.Sorted(maps.Keys(scope)) {
+		if !validIdentifier.MatchString(column) {
+			return fmt.Errorf("scope: column '%s' is not a valid column name", column)
+		}
+		if len(columns) > 0 && !slices.Contains(columns, column) {
+			return fmt.Errorf("scope: column '%s' is not in columns", column)
+		}
+		if value, ok := constants[column]; ok && value != scope[column] {
+			return fmt.Errorf("scope: column '%s' is also a constant with a different value", column)
+		}
+	}
+	return nil
+}
+
+// validateWarnModeDeletes rejects warn mode in a diff sync that deletes rows not in the file:
+// the records it skips are missing from the file's keys, so their rows would be deleted
+func validateWarnModeDeletes(cfg PrimaryKeyValidationConfig, syncMode string, deleteNotInFile bool) error {
+	if cfg.Mode == PrimaryKeyModeWarn && syncMode == SyncModeDiff && deleteNotInFile {
+		return fmt.Errorf("primaryKeyValidation: mode 'warn' cannot be combined with deleteNotInFile, since the rows of skipped records would be deleted")
+	}
+	return nil
+}
+
+// validateCSVOptions checks the CSV dialect of a file
+// Without a header row the fields map to columns by position, so columns must all be read from the file:
+// constant and concat columns would leave gaps in the positions.
+func validateCSVOptions(opts CSVOptions, filePath string, columns []string, rules []TransformRule, constants map[string]string) error {
+	if opts == (CSVOptions{}) {
+		return nil
+	}
+	if ext := strings.ToLower(filepath.Ext(filePath)); ext != ".csv" && ext != ".tsv" {
+		return fmt.Errorf("csv: options only apply to .csv and .tsv files, not '%s'", ext)
+	}
+	for _, option := range []struct{ name, value string }{{"delimiter", opts.Delimiter}, {"quote", opts.Quote}, {"comment", opts.Comment}} {
+		if utf8.RuneCountInString(option.value) > 1 {
+			return fmt.Errorf("csv: %s must be a single character, got '%s'", option.name, option.value)
+		}
+		if option.value == "\r" || option.value == "\n" {
+			return fmt.Errorf("csv: %s must not be a line break", option.name)
+		}
+	}
+	if len(opts.Quote) > 1 {
+		return fmt.Errorf("csv: quote must be an ASCII character, got '%s'", opts.Quote)
+	}
+	if opts.Delimiter != "" && (opts.Delimiter == opts.Quote || opts.Delimiter == opts.Comment) {
+		return fmt.Errorf("csv: delimiter, quote and comment must differ")
+	}
+	if opts.Quote != "" && opts.Quote == opts.Comment {
+		return fmt.Errorf("csv: delimiter, quote and comment must differ")
+	}
+	if opts.SkipLines < 0 {
+		return fmt.Errorf("csv: skipLines must not be negative")
+	}
+	if opts.Header != nil && !*opts.Header {
+		if len(columns) == 0 {
+			return fmt.Errorf("csv: columns are required when header is false")
+		}
+		if len(constants) > 0 {
+			return fmt.Errorf("csv: header false cannot be combined with constants")
+		}
+		if slices.ContainsFunc(rules, func(rule TransformRule) bool {
+			return slices.ContainsFunc(rule.Steps, func(step TransformStep) bool { return step.Concat != "" })
+		}) {
+			return fmt.Errorf("csv: header false cannot be combined with concat transforms")
+		}
+	}
+	return nil
+}
+
+// validateJSONOptions checks the record and value paths of a JSON or NDJSON file
+func validateJSONOptions(opts JSONOptions, filePath string, columns []string) error {
+	if opts.RecordsPath == "" && len(opts.Sources) == 0 {
+		return nil
+	}
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext != ".json" && ext != ".ndjson" && ext != ".jsonl" {
+		return fmt.Errorf("json: options only apply to .json, .ndjson and .jsonl files, not '%s'", ext)
+	}
+	if opts.RecordsPath != "" {
+		if ext != ".json" {
+			return fmt.Errorf("json: recordsPath only applies to .json files; NDJSON files hold one record per line")
+		}
+		if _, err := parseJSONPath(opts.RecordsPath); err != nil {
+			return fmt.Errorf("json: recordsPath: %w", err)
+		}
+	}
+	for _, column := range slices.Sorted(maps.Keys(opts.Sources)) {
+		if len(columns) > 0 && !slices.Contains(columns, column) {
+			return fmt.Errorf("json: sources: column '%s' is not in columns", column)
+		}
+	}
+	if _, err := parseJSONSources(opts.Sources); err != nil {
+		return fmt.Errorf("json: sources: %w", err)
+	}
+	return nil
+}
+
+// validateXLSXOptions checks the sheet, header row and cell range of an Excel file
+func validateXLSXOptions(opts XLSXOptions, filePath string) error {
+	if opts == (XLSXOptions{}) {
+		return nil
+	}
+	if ext := strings.ToLower(filepath.Ext(filePath)); ext != ".xlsx" {
+		return fmt.Errorf("xlsx: options only apply to .xlsx files, not '%s'", ext)
+	}
+	if opts.Sheet != "" && opts.SheetIndex != 0 {
+		return fmt.Errorf("xlsx: set either sheet or sheetIndex, not both")
+	}
+	if opts.SheetIndex < 0 {
+		return fmt.Errorf("xlsx: sheetIndex must not be negative")
+	}
+	if opts.HeaderRow < 0 {
+		return fmt.Errorf("xlsx: headerRow must not be negative")
+	}
+	if opts.Range == "" {
+		return nil
+	}
+	area, err := parseXLSXRange(opts.Range)
+	if err != nil {
+		return fmt.Errorf("xlsx: range: %w", err)
+	}
+	if opts.HeaderRow != 0 && (opts.HeaderRow < area.firstRow || opts.HeaderRow >= area.lastRow) {
+		return fmt.Errorf("xlsx: headerRow %d must be a row of range '%s' above its last row", opts.HeaderRow, opts.Range)
+	}
+	return nil
+}
+
 // DependencyError represents an error with missing dependency information
 type DependencyError struct {
 	TableName         string
@@ -366,14 +1318,18 @@ func formatCycle(cycle []string) string {
 
 // DependencyGraph represents a dependency graph for table synchronization
 type DependencyGraph struct {
-	adjacencyList map[string][]string
+	adjacencyList map[string][]string // parent -> children
+	parents       map[string][]string // child -> parents
 	inDegree      map[string]int
 }
 
 // NewDependencyGraph creates a new dependency graph from table configurations
+// Dependencies on tables that are not part of tables are ignored, so the graph can be
+// built for a subset of the configured tables.
 func NewDependencyGraph(tables []TableSyncConfig) *DependencyGraph {
 	graph := &DependencyGraph{
 		adjacencyList: make(map[string][]string),
+		parents:       make(map[string][]string),
 		inDegree:      make(map[string]int),
 	}
 
@@ -386,7 +1342,12 @@ func NewDependencyGraph(tables []TableSyncConfig) *DependencyGraph {
 	// Build dependency edges (parent -> child)
 	for _, table := range tables {
 		for _, dep := range table.Dependencies {
+			// Dependencies on tables outside the graph, such as unselected parents, are no edges
+			if _, exists := graph.inDegree[dep]; !exists {
+				continue
+			}
 			graph.adjacencyList[dep] = append(graph.adjacencyList[dep], table.Name)
+			graph.parents[table.Name] = append(graph.parents[table.Name], dep)
 			graph.inDegree[table.Name]++
 		}
 	}
@@ -394,6 +1355,35 @@ func NewDependencyGraph(tables []TableSyncConfig) *DependencyGraph {
 	return graph
 }
 
+// Ancestors returns all tables that tableName depends on, directly or transitively
+func (g *DependencyGraph) Ancestors(tableName string) []string {
+	return g.walk(tableName, g.parents)
+}
+
+// Descendants returns all tables that depend on tableName, directly or transitively
+func (g *DependencyGraph) Descendants(tableName string) []string {
+	return g.walk(tableName, g.adjacencyList)
+}
+
+// walk collects every node reachable from start through edges, in sorted order
+func (g *DependencyGraph) walk(start string, edges map[string][]string) []string {
+	visited := map[string]bool{start: true}
+	queue := slices.Clone(edges[start])
+	var result []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		result = append(result, current)
+		queue = append(queue, edges[current]...)
+	}
+	sort.Strings(result)
+	return result
+}
+
 // DetectCycles detects circular dependencies and returns detailed error information
 func (g *DependencyGraph) DetectCycles(tables []TableSyncConfig) error {
 	// Create a copy of inDegree to avoid modifying the original
@@ -559,6 +1549,7 @@ func (g *DependencyGraph) GetTopologicalOrder() ([]string, error) {
 
 // GetSyncOrder determines the order of table synchronization based on dependencies
 // Returns two slices: insertOrder (parent->child) and deleteOrder (child->parent)
+// Only the given tables are ordered; dependencies on tables outside the set are ignored.
 func GetSyncOrder(tables []TableSyncConfig) (insertOrder []string, deleteOrder []string, err error) {
 	if len(tables) == 0 {
 		return nil, nil, fmt.Errorf("no tables provided")
@@ -583,6 +1574,90 @@ func GetSyncOrder(tables []TableSyncConfig) (insertOrder []string, deleteOrder [
 	return insertOrder, deleteOrder, nil
 }
 
+// TableSelection describes which tables of a multi-table configuration to synchronize
+type TableSelection struct {
+	Tables           []string // Tables to synchronize (empty means all configured tables)
+	ExcludeTables    []string // Tables to leave out, applied after the dependency expansion
+	WithDependencies bool     // Also synchronize the tables the selected tables depend on
+	WithDependents   bool     // Also synchronize the tables that depend on the selected tables
+}
+
+// IsEmpty returns true if the selection keeps every configured table
+func (s TableSelection) IsEmpty() bool {
+	return len(s.Tables) == 0 && len(s.ExcludeTables) == 0
+}
+
+// SelectTables returns the configured tables chosen by selection, in configuration order.
+// The returned warnings describe foreign key relationships that cross the selection boundary,
+// because syncing one side without the other can fail or leave orphaned rows.
+func SelectTables(tables []TableSyncConfig, selection TableSelection) ([]TableSyncConfig, []string, error) {
+	// The graph leaves out unknown dependencies, so a misspelled one would otherwise go unnoticed
+	if err := validateTableDependencies(tables); err != nil {
+		return nil, nil, err
+	}
+	available := make([]string, 0, len(tables))
+	for _, table := range tables {
+		available = append(available, table.Name)
+	}
+	for _, name := range slices.Concat(selection.Tables, selection.ExcludeTables) {
+		if !slices.Contains(available, name) {
+			sorted := slices.Sorted(slices.Values(available))
+			return nil, nil, fmt.Errorf("unknown table '%s' in table selection (available tables: %s)", name, strings.Join(sorted, ", "))
+		}
+	}
+
+	graph := NewDependencyGraph(tables)
+	selected := make(map[string]bool)
+	if len(selection.Tables) == 0 {
+		for _, name := range available {
+			selected[name] = true
+		}
+	}
+	for _, name := range selection.Tables {
+		selected[name] = true
+		if selection.WithDependencies {
+			for _, ancestor := range graph.Ancestors(name) {
+				selected[ancestor] = true
+			}
+		}
+		if selection.WithDependents {
+			for _, descendant := range graph.Descendants(name) {
+				selected[descendant] = true
+			}
+		}
+	}
+	for _, name := range selection.ExcludeTables {
+		delete(selected, name)
+	}
+
+	var result []TableSyncConfig
+	var warnings []string
+	for _, table := range tables {
+		if !selected[table.Name] {
+			continue
+		}
+		result = append(result, table)
+
+		for _, parent := range graph.parents[table.Name] {
+			if !selected[parent] {
+				warnings = append(warnings, fmt.Sprintf("table '%s' depends on '%s', which is not selected; rows referencing parent rows that are not yet in the database will fail foreign key checks", table.Name, parent))
+			}
+		}
+		if table.SyncMode == SyncModeOverwrite || table.DeleteNotInFile {
+			for _, child := range graph.adjacencyList[table.Name] {
+				if !selected[child] {
+					warnings = append(warnings, fmt.Sprintf("table '%s' can delete rows but its dependent table '%s' is not selected; deletes may fail foreign key checks", table.Name, child))
+				}
+			}
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, nil, fmt.Errorf("table selection is empty: no tables left to synchronize")
+	}
+	return result, warnings, nil
+}
+
 // GetTableConfig returns the configuration for a specific table by name
 func GetTableConfig(tables []TableSyncConfig, tableName string) (*TableSyncConfig, error) {
 	for _, table := range tables {
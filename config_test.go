@@ -4,12 +4,16 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestLoadConfig(t *testing.T) {
-	t.Run("empty config path uses default", func(t *testing.T) {
+	t.Run("empty config path fails when default file is missing", func(t *testing.T) {
 		// Move to a temporary directory where mydatasyncer.yml doesn't exist
 		tempDir := t.TempDir()
 		originalWd, err := os.Getwd()
@@ -20,33 +24,29 @@ func TestLoadConfig(t *testing.T) {
 		t.Chdir(tempDir)
 
 		// This will try to load "mydatasyncer.yml" which doesn't exist in temp dir
-		// Should fall back to default config
-		cfg := LoadConfig("")
-
-		// Should get default config
-		defaultCfg := NewDefaultConfig()
-		if cfg.DB.DSN != defaultCfg.DB.DSN {
-			t.Errorf("Expected default DSN %q, got %q", defaultCfg.DB.DSN, cfg.DB.DSN)
+		_, err = LoadConfig("")
+		if err == nil {
+			t.Fatal("Expected error for missing default config file")
 		}
-		if cfg.Sync.FilePath != defaultCfg.Sync.FilePath {
-			t.Errorf("Expected default FilePath %q, got %q", defaultCfg.Sync.FilePath, cfg.Sync.FilePath)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected error to wrap os.ErrNotExist, got: %v", err)
+		}
+		if !strings.Contains(err.Error(), "mydatasyncer.yml") {
+			t.Errorf("Expected error to mention default config file, got: %v", err)
 		}
 	})
 
-	t.Run("non-existent file falls back to default", func(t *testing.T) {
-		cfg := LoadConfig("non_existent_file.yml")
-
-		// Should get default config
-		defaultCfg := NewDefaultConfig()
-		if cfg.DB.DSN != defaultCfg.DB.DSN {
-			t.Errorf("Expected default DSN %q, got %q", defaultCfg.DB.DSN, cfg.DB.DSN)
+	t.Run("non-existent file returns not-exist error", func(t *testing.T) {
+		_, err := LoadConfig("non_existent_file.yml")
+		if err == nil {
+			t.Fatal("Expected error for non-existent config file")
 		}
-		if cfg.Sync.FilePath != defaultCfg.Sync.FilePath {
-			t.Errorf("Expected default FilePath %q, got %q", defaultCfg.Sync.FilePath, cfg.Sync.FilePath)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected error to wrap os.ErrNotExist, got: %v", err)
 		}
 	})
 
-	t.Run("invalid YAML falls back to default", func(t *testing.T) {
+	t.Run("invalid YAML returns error", func(t *testing.T) {
 		// Create a temporary file with invalid YAML
 		tempDir := t.TempDir()
 		tempFile := filepath.Join(tempDir, "invalid.yml")
@@ -63,15 +63,68 @@ invalid yaml structure:
 			t.Fatalf("Failed to create test file: %v", err)
 		}
 
-		cfg := LoadConfig(tempFile)
+		_, err = LoadConfig(tempFile)
+		if err == nil {
+			t.Fatal("Expected error for invalid YAML")
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Parse error must not be reported as a missing file: %v", err)
+		}
+		if !strings.Contains(err.Error(), "could not parse config file") {
+			t.Errorf("Expected parse error, got: %v", err)
+		}
+	})
 
-		// Should fall back to default config
-		defaultCfg := NewDefaultConfig()
-		if cfg.DB.DSN != defaultCfg.DB.DSN {
-			t.Errorf("Expected default DSN %q, got %q", defaultCfg.DB.DSN, cfg.DB.DSN)
+	t.Run("unknown key is rejected with line number", func(t *testing.T) {
+		tempDir := t.TempDir()
+		tempFile := filepath.Join(tempDir, "typo.yml")
+
+		typoYAML := `db:
+  dsn: "test:password@tcp(localhost:3306)/testdb"
+sync:
+  filePath: "test.csv"
+  tableNam: "test_table"
+`
+		err := os.WriteFile(tempFile, []byte(typoYAML), 0644)
+		if err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
 		}
-		if cfg.Sync.FilePath != defaultCfg.Sync.FilePath {
-			t.Errorf("Expected default FilePath %q, got %q", defaultCfg.Sync.FilePath, cfg.Sync.FilePath)
+
+		_, err = LoadConfig(tempFile)
+		if err == nil {
+			t.Fatal("Expected error for unknown key")
+		}
+		if !strings.Contains(err.Error(), `unknown field "tableNam"`) {
+			t.Errorf("Expected unknown field error, got: %v", err)
+		}
+		if !strings.Contains(err.Error(), "[5:3]") {
+			t.Errorf("Expected line and column of the unknown key, got: %v", err)
+		}
+	})
+
+	t.Run("unknown key in table entry is rejected", func(t *testing.T) {
+		tempDir := t.TempDir()
+		tempFile := filepath.Join(tempDir, "table_typo.yml")
+
+		typoYAML := `db:
+  dsn: "test:password@tcp(localhost:3306)/testdb"
+tables:
+  - name: "products"
+    filePath: "products.csv"
+    syncMode: "overwrite"
+    deleteNotInFil: true
+`
+		err := os.WriteFile(tempFile, []byte(typoYAML), 0644)
+		if err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		_, err = LoadConfig(tempFile)
+		if err == nil {
+			t.Fatal("Expected error for unknown key in table entry")
+		}
+		if !strings.Contains(err.Error(), `unknown field "deleteNotInFil"`) || !strings.Contains(err.Error(), "[7:5]") {
+			t.Errorf("Expected line-numbered unknown field error, got: %v", err)
 		}
 	})
 
@@ -95,7 +148,10 @@ sync:
 			t.Fatalf("Failed to create test file: %v", err)
 		}
 
-		cfg := LoadConfig(tempFile)
+		cfg, err := LoadConfig(tempFile)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
 
 		if cfg.DB.DSN != "test:password@tcp(localhost:3306)/testdb" {
 			t.Errorf("Expected DSN from file, got %q", cfg.DB.DSN)
@@ -114,7 +170,20 @@ sync:
 		}
 	})
 
-	t.Run("file permission error falls back to default", func(t *testing.T) {
+	t.Run("repository config files are valid", func(t *testing.T) {
+		files, err := filepath.Glob("testdata/*.yml")
+		if err != nil {
+			t.Fatalf("Failed to list config files: %v", err)
+		}
+		files = append(files, "mydatasyncer.yml")
+		for _, file := range files {
+			if _, err := LoadConfig(file); err != nil {
+				t.Errorf("Config file %s failed to load: %v", file, err)
+			}
+		}
+	})
+
+	t.Run("file permission error returns error", func(t *testing.T) {
 		if os.Getuid() == 0 {
 			t.Skip("Skipping permission test when running as root")
 		}
@@ -134,15 +203,12 @@ sync:
 		}
 		defer os.Chmod(tempFile, 0644) // Restore permissions for cleanup
 
-		cfg := LoadConfig(tempFile)
-
-		// Should fall back to default config
-		defaultCfg := NewDefaultConfig()
-		if cfg.DB.DSN != defaultCfg.DB.DSN {
-			t.Errorf("Expected default DSN %q, got %q", defaultCfg.DB.DSN, cfg.DB.DSN)
+		_, err = LoadConfig(tempFile)
+		if err == nil {
+			t.Fatal("Expected error for unreadable config file")
 		}
-		if cfg.Sync.FilePath != defaultCfg.Sync.FilePath {
-			t.Errorf("Expected default FilePath %q, got %q", defaultCfg.Sync.FilePath, cfg.Sync.FilePath)
+		if errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Permission error must not be reported as a missing file: %v", err)
 		}
 	})
 }
@@ -394,6 +460,84 @@ func TestValidateConfig(t *testing.T) {
 	})
 }
 
+func TestValidateConfigRunSettings(t *testing.T) {
+	base := func() Config {
+		return Config{
+			DB: DBConfig{DSN: "user:pass@tcp(localhost:3306)/db"},
+			Tables: []TableSyncConfig{
+				{Name: "users", FilePath: "users.csv", PrimaryKey: "id", SyncMode: SyncModeDiff},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "positive timeouts are valid", modify: func(c *Config) {
+			c.Timeout = time.Hour
+			c.StatementTimeout = 30 * time.Second
+			c.Tables[0].Timeout = 10 * time.Minute
+		}},
+		{name: "negative overall timeout", modify: func(c *Config) { c.Timeout = -time.Second }, wantErr: "timeout must not be negative"},
+		{name: "negative statement timeout", modify: func(c *Config) { c.StatementTimeout = -time.Second }, wantErr: "statementTimeout must not be negative"},
+		{name: "negative table timeout", modify: func(c *Config) { c.Tables[0].Timeout = -time.Second }, wantErr: "table[0] (users): timeout must not be negative"},
+		{name: "valid lock settings", modify: func(c *Config) {
+			c.Lock = LockConfig{Mode: LockModeTable, WaitTimeout: time.Minute, Table: "sync_locks"}
+		}},
+		{name: "unknown lock mode", modify: func(c *Config) { c.Lock.Mode = "advisory" }, wantErr: "lock mode must be one of"},
+		{name: "negative lock wait timeout", modify: func(c *Config) { c.Lock.WaitTimeout = -time.Second }, wantErr: "lock waitTimeout must not be negative"},
+		{name: "invalid lock table name", modify: func(c *Config) { c.Lock.Table = "locks; DROP TABLE users" }, wantErr: "is not a valid table name"},
+		{name: "valid retry settings", modify: func(c *Config) {
+			c.Retry = RetryConfig{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: time.Minute}
+		}},
+		{name: "negative retry attempts", modify: func(c *Config) { c.Retry.MaxAttempts = -1 }, wantErr: "retry maxAttempts must not be negative"},
+		{name: "negative retry backoff", modify: func(c *Config) { c.Retry.InitialBackoff = -time.Second }, wantErr: "retry backoff must not be negative"},
+		{name: "valid metrics settings", modify: func(c *Config) {
+			c.Metrics = MetricsConfig{Textfile: "/tmp/mydatasyncer.prom", Pushgateway: "http://localhost:9091", Timeout: time.Second}
+		}},
+		{name: "valid notify settings", modify: func(c *Config) {
+			c.Notify = NotifyConfig{
+				OnFailure: []NotifyTarget{{Webhook: "https://hooks.example.com/x", Format: NotifyFormatSlack}},
+				OnDrift:   []NotifyTarget{{Command: []string{"/usr/local/bin/page-oncall"}}},
+			}
+		}},
+		{name: "notify target without webhook or command", modify: func(c *Config) { c.Notify.OnSuccess = []NotifyTarget{{}} },
+			wantErr: "notify onSuccess[0]: exactly one of webhook and command must be set"},
+		{name: "notify target with webhook and command", modify: func(c *Config) {
+			c.Notify.OnFailure = []NotifyTarget{{Webhook: "https://hooks.example.com/x", Command: []string{"true"}}}
+		}, wantErr: "exactly one of webhook and command must be set"},
+		{name: "unknown webhook format", modify: func(c *Config) {
+			c.Notify.OnDrift = []NotifyTarget{{Webhook: "https://hooks.example.com/x", Format: "discord"}}
+		}, wantErr: "notify onDrift[0]: unknown webhook format 'discord'"},
+		{name: "invalid webhook URL", modify: func(c *Config) { c.Notify.OnFailure = []NotifyTarget{{Webhook: "hooks.example.com"}} },
+			wantErr: "webhook must be an http(s) URL"},
+		{name: "negative notify timeout", modify: func(c *Config) { c.Notify.Timeout = -time.Second }, wantErr: "notify timeout must not be negative"},
+		{name: "invalid pushgateway URL", modify: func(c *Config) { c.Metrics.Pushgateway = "localhost:9091" }, wantErr: "metrics pushgateway must be an http(s) URL"},
+		{name: "max backoff below initial backoff", modify: func(c *Config) {
+			c.Retry = RetryConfig{InitialBackoff: time.Minute, MaxBackoff: time.Second}
+		}, wantErr: "retry maxBackoff must not be less than initialBackoff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.modify(&cfg)
+			err := ValidateConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 // Multi-table configuration tests
 func TestValidateMultiTableConfig(t *testing.T) {
 	tests := []struct {
@@ -846,3 +990,1167 @@ func isValidTopologicalOrder(order []string, tables []TableSyncConfig) bool {
 
 	return true
 }
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("MDS_TEST_USER", "alice")
+	t.Setenv("MDS_TEST_EMPTY", "")
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{name: "no references", input: "plain value", expected: "plain value"},
+		{name: "simple reference", input: "${MDS_TEST_USER}:pw", expected: "alice:pw"},
+		{name: "default used when unset", input: "${MDS_TEST_UNSET:-fallback}", expected: "fallback"},
+		{name: "default used when empty", input: "${MDS_TEST_EMPTY:-fallback}", expected: "fallback"},
+		{name: "default ignored when set", input: "${MDS_TEST_USER:-bob}", expected: "alice"},
+		{name: "empty default allowed", input: "x${MDS_TEST_UNSET:-}y", expected: "xy"},
+		{name: "empty variable without default", input: "${MDS_TEST_EMPTY}", expected: ""},
+		{name: "bare dollar is untouched", input: "^[a-z]+$", expected: "^[a-z]+$"},
+		{name: "RUN_ID is reserved", input: "batch-${RUN_ID}", expected: "batch-${RUN_ID}"},
+		{name: "unset variable without default", input: "${MDS_TEST_UNSET}", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEnv(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %q", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expandEnv(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExpandConfigEnv(t *testing.T) {
+	t.Setenv("MDS_TEST_TABLE", "products")
+	t.Setenv("MDS_TEST_DIR", "/data")
+
+	cfg := Config{
+		DB: DBConfig{
+			Host:   "${MDS_TEST_HOST:-127.0.0.1:3306}",
+			Params: map[string]string{"parseTime": "${MDS_TEST_PARSE_TIME:-true}"},
+		},
+		Tables: []TableSyncConfig{
+			{
+				Name:     "${MDS_TEST_TABLE}",
+				FilePath: "${MDS_TEST_DIR}/products.csv",
+				Columns:  []string{"id", "${MDS_TEST_COLUMN:-name}"},
+			},
+		},
+	}
+
+	if err := expandConfigEnv(&cfg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.DB.Host != "127.0.0.1:3306" {
+		t.Errorf("Expected host default, got %q", cfg.DB.Host)
+	}
+	if cfg.DB.Params["parseTime"] != "true" {
+		t.Errorf("Expected params to be expanded, got %v", cfg.DB.Params)
+	}
+	if cfg.Tables[0].Name != "products" || cfg.Tables[0].FilePath != "/data/products.csv" {
+		t.Errorf("Expected table fields to be expanded, got %+v", cfg.Tables[0])
+	}
+	if cfg.Tables[0].Columns[1] != "name" {
+		t.Errorf("Expected column default, got %q", cfg.Tables[0].Columns[1])
+	}
+
+	t.Run("error names the config field", func(t *testing.T) {
+		cfg := Config{Tables: []TableSyncConfig{{FilePath: "${MDS_TEST_UNSET}"}}}
+		err := expandConfigEnv(&cfg)
+		if err == nil {
+			t.Fatal("Expected error for unset variable")
+		}
+		if !strings.Contains(err.Error(), "tables[0].filePath") || !strings.Contains(err.Error(), "MDS_TEST_UNSET") {
+			t.Errorf("Expected error to name field and variable, got: %v", err)
+		}
+	})
+}
+
+func TestResolveDSN(t *testing.T) {
+	t.Run("dsn is kept as is", func(t *testing.T) {
+		db := DBConfig{DSN: "user:dsnsecret@tcp(localhost:3306)/db"}
+		if err := resolveDSN(&db); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if db.DSN != "user:dsnsecret@tcp(localhost:3306)/db" {
+			t.Errorf("DSN was modified: %q", db.DSN)
+		}
+		if masked := maskSecrets(db.DSN); strings.Contains(masked, "dsnsecret") {
+			t.Errorf("Expected DSN password to be registered as secret, got %q", masked)
+		}
+	})
+
+	t.Run("dsn is read from dsnFile", func(t *testing.T) {
+		dsnFile := filepath.Join(t.TempDir(), "dsn")
+		if err := os.WriteFile(dsnFile, []byte("user:filesecret@tcp(localhost:3306)/db\n"), 0600); err != nil {
+			t.Fatalf("Failed to create dsn file: %v", err)
+		}
+		db := DBConfig{DSNFile: dsnFile}
+		if err := resolveDSN(&db); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if db.DSN != "user:filesecret@tcp(localhost:3306)/db" {
+			t.Errorf("Expected DSN from file, got %q", db.DSN)
+		}
+	})
+
+	t.Run("dsn is assembled from parts", func(t *testing.T) {
+		passwordFile := filepath.Join(t.TempDir(), "password")
+		if err := os.WriteFile(passwordFile, []byte("partsecret\n"), 0600); err != nil {
+			t.Fatalf("Failed to create password file: %v", err)
+		}
+		db := DBConfig{
+			User:         "app",
+			PasswordFile: passwordFile,
+			Host:         "db.example.com:3307",
+			Database:     "sales",
+			Params:       map[string]string{"parseTime": "true"},
+		}
+		if err := resolveDSN(&db); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := "app:partsecret@tcp(db.example.com:3307)/sales?parseTime=true"
+		if db.DSN != expected {
+			t.Errorf("Expected DSN %q, got %q", expected, db.DSN)
+		}
+		if masked := maskSecrets("secret is partsecret"); masked != "secret is "+secretMask {
+			t.Errorf("Expected password file content to be masked, got %q", masked)
+		}
+	})
+
+	t.Run("conflicting fields fail", func(t *testing.T) {
+		db := DBConfig{DSN: "user:pw@tcp(localhost:3306)/db", DSNFile: "dsn"}
+		if err := resolveDSN(&db); err == nil {
+			t.Error("Expected error when both dsn and dsnFile are set")
+		}
+		db = DBConfig{Host: "localhost", Password: "pw", PasswordFile: "password"}
+		if err := resolveDSN(&db); err == nil {
+			t.Error("Expected error when both password and passwordFile are set")
+		}
+	})
+
+	t.Run("missing password file fails", func(t *testing.T) {
+		db := DBConfig{Host: "localhost", PasswordFile: filepath.Join(t.TempDir(), "missing")}
+		if err := resolveDSN(&db); err == nil {
+			t.Error("Expected error for missing password file")
+		}
+	})
+
+	t.Run("nothing configured leaves DSN empty", func(t *testing.T) {
+		db := DBConfig{}
+		if err := resolveDSN(&db); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if db.DSN != "" {
+			t.Errorf("Expected empty DSN, got %q", db.DSN)
+		}
+	})
+}
+
+func TestLoadConfigExpandsEnv(t *testing.T) {
+	t.Setenv("MDS_TEST_DB_PASSWORD", "envsecret")
+	tempFile := filepath.Join(t.TempDir(), "env.yml")
+	configYAML := `
+db:
+  user: "app"
+  password: "${MDS_TEST_DB_PASSWORD}"
+  host: "${MDS_TEST_DB_HOST:-localhost:3306}"
+  database: "testdb"
+sync:
+  filePath: "${MDS_TEST_DATA_DIR:-./data}/test.csv"
+  tableName: "test_table"
+`
+	if err := os.WriteFile(tempFile, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tempFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.DB.DSN != "app:envsecret@tcp(localhost:3306)/testdb" {
+		t.Errorf("Expected assembled DSN, got %q", cfg.DB.DSN)
+	}
+	if cfg.Sync.FilePath != "./data/test.csv" {
+		t.Errorf("Expected expanded file path, got %q", cfg.Sync.FilePath)
+	}
+}
+
+func TestLoadConfigTimeouts(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "timeouts.yml")
+	configYAML := `
+db:
+  dsn: "user:pass@tcp(localhost:3306)/db"
+timeout: 2h
+statementTimeout: 30s
+tables:
+  - name: orders
+    filePath: orders.csv
+    primaryKey: id
+    syncMode: diff
+    timeout: 45m
+`
+	if err := os.WriteFile(tempFile, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tempFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Timeout != 2*time.Hour {
+		t.Errorf("Expected timeout 2h, got %s", cfg.Timeout)
+	}
+	if cfg.StatementTimeout != 30*time.Second {
+		t.Errorf("Expected statement timeout 30s, got %s", cfg.StatementTimeout)
+	}
+	if cfg.Tables[0].Timeout != 45*time.Minute {
+		t.Errorf("Expected table timeout 45m, got %s", cfg.Tables[0].Timeout)
+	}
+}
+
+func TestLoadConfigValidationRules(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "validate.yml")
+	configYAML := `
+db:
+  dsn: "user:pass@tcp(localhost:3306)/db"
+defaults:
+  validate:
+    - column: id
+      notNull: true
+tables:
+  - name: orders
+    filePath: orders.csv
+    primaryKey: id
+    syncMode: diff
+    validate:
+      - column: price
+        min: 0
+        max: 1000000
+      - column: status
+        enum: [new, paid, shipped]
+        severity: warning
+      - column: ordered_at
+        dateFormat: "2006-01-02"
+  - name: customers
+    filePath: customers.csv
+    primaryKey: id
+    syncMode: diff
+`
+	if err := os.WriteFile(tempFile, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tempFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	zero, million := 0.0, 1000000.0
+	want := []ValidationRule{
+		{Column: "price", Min: &zero, Max: &million},
+		{Column: "status", Enum: []string{"new", "paid", "shipped"}, Severity: SeverityWarning},
+		{Column: "ordered_at", DateFormat: "2006-01-02"},
+	}
+	if diff := cmp.Diff(want, cfg.Tables[0].Validate); diff != "" {
+		t.Errorf("orders rules mismatch (-want +got):\n%s", diff)
+	}
+	// Tables without their own rules inherit the defaults
+	if diff := cmp.Diff([]ValidationRule{{Column: "id", NotNull: true}}, cfg.Tables[1].Validate); diff != "" {
+		t.Errorf("customers rules mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadConfigPrimaryKeyValidation(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "pk.yml")
+	configYAML := `
+db:
+  dsn: "user:pass@tcp(localhost:3306)/db"
+defaults:
+  primaryKeyValidation:
+    mode: warn
+    trimWhitespace: true
+tables:
+  - name: products
+    filePath: products.csv
+    primaryKey: code
+    syncMode: diff
+    primaryKeyValidation:
+      maxLength: 8
+      nullTokens: ["", "-"]
+      pattern: "^[A-Z0-9]+$"
+  - name: countries
+    filePath: countries.csv
+    primaryKey: iso
+    syncMode: diff
+    primaryKeyValidation:
+      mode: strict
+      nullTokens: []
+`
+	if err := os.WriteFile(tempFile, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tempFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	// Keys a table does not set are inherited from the defaults block
+	want := []PrimaryKeyValidationConfig{
+		{MaxLength: 8, Mode: PrimaryKeyModeWarn, NullTokens: []string{"", "-"}, Pattern: "^[A-Z0-9]+$", TrimWhitespace: true},
+		{Mode: PrimaryKeyModeStrict, NullTokens: []string{}, TrimWhitespace: true},
+	}
+	for i, table := range cfg.Tables {
+		if diff := cmp.Diff(want[i], table.PrimaryKeyValidation); diff != "" {
+			t.Errorf("%s primaryKeyValidation mismatch (-want +got):\n%s", table.Name, diff)
+		}
+	}
+}
+
+func TestLoadConfigTransformRules(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "transform.yml")
+	configYAML := `
+db:
+  dsn: "user:pass@tcp(localhost:3306)/db"
+tables:
+  - name: users
+    filePath: users.csv
+    primaryKey: id
+    syncMode: diff
+    transform:
+      - column: code
+        steps:
+          - trim: true
+          - upper: true
+      - column: active
+        steps:
+          - map: {"有効": 1, "無効": 0}
+          - default: "0"
+      - column: full_name
+        steps:
+          - concat: "{last_name} {first_name}"
+      - column: joined_on
+        steps:
+          - date: {from: "2006/01/02", to: "2006-01-02"}
+      - column: salary
+        steps:
+          - number: {decimal: ",", thousands: "."}
+`
+	if err := os.WriteFile(tempFile, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tempFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	zero := "0"
+	want := []TransformRule{
+		{Column: "code", Steps: []TransformStep{{Trim: true}, {Upper: true}}},
+		{Column: "active", Steps: []TransformStep{{Map: map[string]string{"有効": "1", "無効": "0"}}, {Default: &zero}}},
+		{Column: "full_name", Steps: []TransformStep{{Concat: "{last_name} {first_name}"}}},
+		{Column: "joined_on", Steps: []TransformStep{{Date: &DateStep{From: "2006/01/02", To: "2006-01-02"}}}},
+		{Column: "salary", Steps: []TransformStep{{Number: &NumberStep{Decimal: ",", Thousands: "."}}}},
+	}
+	if diff := cmp.Diff(want, cfg.Tables[0].Transform); diff != "" {
+		t.Errorf("transform mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadConfigConstants(t *testing.T) {
+	t.Setenv("MDS_TEST_SOURCE", "erp")
+	tempFile := filepath.Join(t.TempDir(), "constants.yml")
+	configYAML := `
+db:
+  dsn: "user:pass@tcp(localhost:3306)/db"
+tables:
+  - name: orders
+    filePath: orders.csv
+    primaryKey: id
+    syncMode: diff
+    constants:
+      tenant_id: 42
+      source: ${MDS_TEST_SOURCE}
+      batch_id: ${RUN_ID}
+`
+	if err := os.WriteFile(tempFile, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tempFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	want := map[string]string{"tenant_id": "42", "source": "erp", "batch_id": "${RUN_ID}"}
+	if diff := cmp.Diff(want, cfg.Tables[0].Constants); diff != "" {
+		t.Errorf("constants mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadConfigCSVOptions(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "csv.yml")
+	configYAML := `
+db:
+  dsn: "user:pass@tcp(localhost:3306)/db"
+defaults:
+  csv:
+    delimiter: "\t"
+tables:
+  - name: items
+    filePath: items.csv
+    syncMode: overwrite
+    columns: [id, name]
+    csv:
+      quote: "'"
+      comment: "#"
+      skipLines: 2
+      header: false
+`
+	if err := os.WriteFile(tempFile, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tempFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	noHeader := false
+	want := CSVOptions{Delimiter: "\t", Quote: "'", Comment: "#", SkipLines: 2, Header: &noHeader}
+	if diff := cmp.Diff(want, cfg.Tables[0].CSV); diff != "" {
+		t.Errorf("csv options mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidateConfigValidationRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{
+			name: "rule without column",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "orders", FilePath: "orders.csv", SyncMode: SyncModeOverwrite, Validate: []ValidationRule{{NotNull: true}}},
+			}},
+			wantErr: "table[0] (orders): validate[0]: column is required",
+		},
+		{
+			name: "rule for a column that is not synced",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "orders", FilePath: "orders.csv", SyncMode: SyncModeOverwrite, Columns: []string{"id"},
+					Validate: []ValidationRule{{Column: "price", NotNull: true}}},
+			}},
+			wantErr: "validate[0]: column 'price' is not in columns",
+		},
+		{
+			name: "invalid regex in legacy config",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				Validate: []ValidationRule{{Column: "email", Regex: "[a-z"}}}},
+			wantErr: "validate[0] (email): invalid regex",
+		},
+		{
+			name: "unknown severity",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				Validate: []ValidationRule{{Column: "email", NotNull: true, Severity: "info"}}}},
+			wantErr: "severity must be either 'error' or 'warning'",
+		},
+		{
+			name: "unknown primary key validation mode",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "orders", FilePath: "orders.csv", SyncMode: SyncModeOverwrite,
+					PrimaryKeyValidation: PrimaryKeyValidationConfig{Mode: "skip"}},
+			}},
+			wantErr: "table[0] (orders): primaryKeyValidation: mode must be either 'strict' or 'warn'",
+		},
+		{
+			name: "warn primary key mode with deleteNotInFile",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "orders", FilePath: "orders.csv", PrimaryKey: "id", SyncMode: SyncModeDiff, DeleteNotInFile: true,
+					PrimaryKeyValidation: PrimaryKeyValidationConfig{Mode: PrimaryKeyModeWarn}},
+			}},
+			wantErr: "table[0] (orders): primaryKeyValidation: mode 'warn' cannot be combined with deleteNotInFile",
+		},
+		{
+			name: "warn primary key mode with deleteNotInFile in legacy config",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", PrimaryKey: "id", SyncMode: SyncModeDiff, DeleteNotInFile: true,
+				PrimaryKeyValidation: PrimaryKeyValidationConfig{Mode: PrimaryKeyModeWarn}}},
+			wantErr: "primaryKeyValidation: mode 'warn' cannot be combined with deleteNotInFile",
+		},
+		{
+			name: "invalid primary key pattern in legacy config",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				PrimaryKeyValidation: PrimaryKeyValidationConfig{Pattern: "[0-9"}}},
+			wantErr: "primaryKeyValidation: invalid pattern",
+		},
+		{
+			name: "transform rule for a column that is not synced",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "users", FilePath: "users.csv", SyncMode: SyncModeOverwrite, Columns: []string{"id"},
+					Transform: []TransformRule{{Column: "code", Steps: []TransformStep{{Trim: true}}}}},
+			}},
+			wantErr: "table[0] (users): transform[0]: column 'code' is not in columns",
+		},
+		{
+			name: "transform step with two functions in legacy config",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				Transform: []TransformRule{{Column: "code", Steps: []TransformStep{{Trim: true, Upper: true}}}}}},
+			wantErr: "transform[0] (code): steps[0]: exactly one function must be set, got 2",
+		},
+		{
+			name: "constant for a column that is not synced",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "users", FilePath: "users.csv", SyncMode: SyncModeOverwrite, Columns: []string{"id"},
+					Constants: map[string]string{"tenant_id": "42"}},
+			}},
+			wantErr: "table[0] (users): constants: column 'tenant_id' is not in columns",
+		},
+		{
+			name: "constant for the primary key in legacy config",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeDiff,
+				PrimaryKey: "id", Constants: map[string]string{"id": "1"}}},
+			wantErr: "constants: column 'id' is the primary key",
+		},
+		{
+			name: "constant column that is also transformed",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "users", FilePath: "users.csv", SyncMode: SyncModeOverwrite, Columns: []string{"id", "tenant_id"},
+					Constants: map[string]string{"tenant_id": "42"},
+					Transform: []TransformRule{{Column: "tenant_id", Steps: []TransformStep{{Trim: true}}}}},
+			}},
+			wantErr: "table[0] (users): constants: column 'tenant_id' cannot also be transformed",
+		},
+		{
+			name: "scope column that is not synced",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "sales", FilePath: "sales.csv", SyncMode: SyncModeOverwrite, Columns: []string{"id", "amount"},
+					Scope: map[string]string{"region": "JP"}},
+			}},
+			wantErr: "table[0] (sales): scope: column 'region' is not in columns",
+		},
+		{
+			name: "constant column that is not an identifier",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "users", FilePath: "users.csv", SyncMode: SyncModeOverwrite,
+					Constants: map[string]string{"tenant_id = 1 OR 1": "42"}},
+			}},
+			wantErr: "table[0] (users): constants: column 'tenant_id = 1 OR 1' is not a valid column name",
+		},
+		{
+			name: "scope column that is not an identifier in legacy config",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				Scope: map[string]string{"region`": "JP"}}},
+			wantErr: "scope: column 'region`' is not a valid column name",
+		},
+		{
+			name: "scope conflicting with a constant",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				Constants: map[string]string{"tenant_id": "42"}, Scope: map[string]string{"tenant_id": "7"}}},
+			wantErr: "scope: column 'tenant_id' is also a constant with a different value",
+		},
+		{
+			name: "unsupported encoding",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "users", FilePath: "users.csv", SyncMode: SyncModeOverwrite, Encoding: "latin1"},
+			}},
+			wantErr: "table[0] (users): encoding: unsupported encoding 'latin1'",
+		},
+		{
+			name: "encoding for an Excel file",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "users", FilePath: "users.xlsx", SyncMode: SyncModeOverwrite, Encoding: "cp932"},
+			}},
+			wantErr: "table[0] (users): encoding: only applies to text files, not '.xlsx'",
+		},
+		{
+			name: "encoding for a Parquet file in legacy config",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "users.parquet", TableName: "users", SyncMode: SyncModeOverwrite,
+				Encoding: "utf-8"}},
+			wantErr: "encoding: only applies to text files, not '.parquet'",
+		},
+		{
+			name: "csv options for a JSON file",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "users", FilePath: "users.json", SyncMode: SyncModeOverwrite, CSV: CSVOptions{Delimiter: ";"}},
+			}},
+			wantErr: "table[0] (users): csv: options only apply to .csv and .tsv files, not '.json'",
+		},
+		{
+			name: "csv delimiter with several characters",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				CSV: CSVOptions{Delimiter: "||"}}},
+			wantErr: "csv: delimiter must be a single character, got '||'",
+		},
+		{
+			name: "csv quote that is not ASCII",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				CSV: CSVOptions{Quote: "「"}}},
+			wantErr: "csv: quote must be an ASCII character",
+		},
+		{
+			name: "csv delimiter equal to the quote",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.tsv", TableName: "a", SyncMode: SyncModeOverwrite,
+				CSV: CSVOptions{Delimiter: "'", Quote: "'"}}},
+			wantErr: "csv: delimiter, quote and comment must differ",
+		},
+		{
+			name: "csv negative skipLines",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				CSV: CSVOptions{SkipLines: -1}}},
+			wantErr: "csv: skipLines must not be negative",
+		},
+		{
+			name: "csv without header or columns",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				CSV: CSVOptions{Header: new(bool)}}},
+			wantErr: "csv: columns are required when header is false",
+		},
+		{
+			name: "csv without header and with constants",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "items", FilePath: "items.csv", SyncMode: SyncModeOverwrite, Columns: []string{"id", "tenant_id"},
+					CSV: CSVOptions{Header: new(bool)}, Constants: map[string]string{"tenant_id": "42"}},
+			}},
+			wantErr: "table[0] (items): csv: header false cannot be combined with constants",
+		},
+		{
+			name: "csv without header and with concat",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				Columns: []string{"id", "label"}, CSV: CSVOptions{Header: new(bool)},
+				Transform: []TransformRule{{Column: "label", Steps: []TransformStep{{Concat: "{id}-x"}}}}}},
+			wantErr: "csv: header false cannot be combined with concat transforms",
+		},
+		{
+			name: "json options for a CSV file",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				JSON: JSONOptions{RecordsPath: "data"}}},
+			wantErr: "json: options only apply to .json, .ndjson and .jsonl files, not '.csv'",
+		},
+		{
+			name: "json recordsPath for an NDJSON file",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "events", FilePath: "events.ndjson", SyncMode: SyncModeOverwrite, JSON: JSONOptions{RecordsPath: "data"}},
+			}},
+			wantErr: "table[0] (events): json: recordsPath only applies to .json files",
+		},
+		{
+			name: "json invalid recordsPath",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.json", TableName: "a", SyncMode: SyncModeOverwrite,
+				JSON: JSONOptions{RecordsPath: "data[*]"}}},
+			wantErr: "json: recordsPath: invalid path 'data[*]'",
+		},
+		{
+			name: "json source for a column that is not synced",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.json", TableName: "a", SyncMode: SyncModeOverwrite,
+				Columns: []string{"id"}, JSON: JSONOptions{Sources: map[string]string{"amount": "price.amount"}}}},
+			wantErr: "json: sources: column 'amount' is not in columns",
+		},
+		{
+			name: "json invalid source",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.json", TableName: "a", SyncMode: SyncModeOverwrite,
+				JSON: JSONOptions{Sources: map[string]string{"amount": ""}}}},
+			wantErr: "json: sources: source of column 'amount': path is empty",
+		},
+		{
+			name: "xlsx options for a CSV file",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				XLSX: XLSXOptions{Sheet: "Data"}}},
+			wantErr: "xlsx: options only apply to .xlsx files, not '.csv'",
+		},
+		{
+			name: "xlsx sheet and sheetIndex",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "products", FilePath: "products.xlsx", SyncMode: SyncModeOverwrite, XLSX: XLSXOptions{Sheet: "Data", SheetIndex: 2}},
+			}},
+			wantErr: "table[0] (products): xlsx: set either sheet or sheetIndex, not both",
+		},
+		{
+			name: "xlsx negative headerRow",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.xlsx", TableName: "a", SyncMode: SyncModeOverwrite,
+				XLSX: XLSXOptions{HeaderRow: -1}}},
+			wantErr: "xlsx: headerRow must not be negative",
+		},
+		{
+			name: "xlsx invalid range",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.xlsx", TableName: "a", SyncMode: SyncModeOverwrite,
+				XLSX: XLSXOptions{Range: "B3-F200"}}},
+			wantErr: "xlsx: range: 'B3-F200' is not a cell range such as A1:D100",
+		},
+		{
+			name: "xlsx headerRow outside the range",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.xlsx", TableName: "a", SyncMode: SyncModeOverwrite,
+				XLSX: XLSXOptions{Range: "B3:F200", HeaderRow: 1}}},
+			wantErr: "xlsx: headerRow 1 must be a row of range 'B3:F200' above its last row",
+		},
+		{
+			name: "date format for a column that is not synced",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				Columns: []string{"id"}, DateFormats: map[string]string{"ordered_at": "2006/01/02"}}},
+			wantErr: "dateFormats: column 'ordered_at' is not in columns",
+		},
+		{
+			name: "empty date format",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Tables: []TableSyncConfig{
+				{Name: "orders", FilePath: "orders.csv", SyncMode: SyncModeOverwrite, DateFormats: map[string]string{"ordered_at": " "}},
+			}},
+			wantErr: "table[0] (orders): dateFormats: format of column 'ordered_at' is empty",
+		},
+		{
+			name: "unknown source timezone",
+			config: Config{DB: DBConfig{DSN: "dsn"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite,
+				Timezone: "Mars/Olympus"}},
+			wantErr: "timezone: unknown time zone 'Mars/Olympus'",
+		},
+		{
+			name:    "unknown database timezone",
+			config:  Config{DB: DBConfig{DSN: "dsn", Timezone: "+25:00"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite}},
+			wantErr: "db timezone: invalid UTC offset '+25:00'",
+		},
+		{
+			name:    "local database timezone",
+			config:  Config{DB: DBConfig{DSN: "dsn", Timezone: "Local"}, Sync: SyncConfig{FilePath: "a.csv", TableName: "a", SyncMode: SyncModeOverwrite}},
+			wantErr: "db timezone: 'Local' is not a MySQL time zone",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// writeConfigFiles writes the given files (name -> content) into dir
+func writeConfigFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfigDefaultsIncludesAndOverlays(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yml": `
+include:
+  - tables/categories.yml
+defaults:
+  syncMode: diff
+  primaryKey: id
+  deleteNotInFile: true
+  timestampColumns: [created_at, updated_at]
+  immutableColumns: [created_at]
+db:
+  dsn: "dev:devpass@tcp(localhost:3306)/dev"
+tables:
+  - name: products
+    filePath: products.csv
+    dependencies: [categories]
+  - name: logs
+    filePath: logs.csv
+    syncMode: overwrite
+    deleteNotInFile: false
+`,
+		"tables/categories.yml": `
+tables:
+  - name: categories
+    filePath: categories.json
+    immutableColumns: []
+`,
+		"config.prod.yml": `
+db:
+  dsn: "prod:prodpass@tcp(db.prod:3306)/prod"
+tables:
+  - name: products
+    filePath: /data/products.csv
+`,
+	})
+	configPath := filepath.Join(dir, "config.yml")
+
+	t.Run("defaults and includes are merged", func(t *testing.T) {
+		cfg, err := LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var names []string
+		for _, table := range cfg.Tables {
+			names = append(names, table.Name)
+		}
+		if !slicesEqual(names, []string{"categories", "products", "logs"}) {
+			t.Fatalf("Expected included tables first, got %v", names)
+		}
+
+		categories := cfg.Tables[0]
+		if categories.SyncMode != SyncModeDiff || categories.PrimaryKey != "id" || !categories.DeleteNotInFile {
+			t.Errorf("Expected categories to inherit defaults, got %+v", categories)
+		}
+		if len(categories.ImmutableColumns) != 0 {
+			t.Errorf("Expected explicit empty list to override defaults, got %v", categories.ImmutableColumns)
+		}
+
+		products := cfg.Tables[1]
+		if !slicesEqual(products.TimestampColumns, []string{"created_at", "updated_at"}) {
+			t.Errorf("Expected products to inherit timestampColumns, got %v", products.TimestampColumns)
+		}
+
+		logs := cfg.Tables[2]
+		if logs.SyncMode != SyncModeOverwrite || logs.DeleteNotInFile {
+			t.Errorf("Expected logs to keep its own settings, got %+v", logs)
+		}
+
+		if err := ValidateConfig(cfg); err != nil {
+			t.Errorf("Expected merged config to be valid, got: %v", err)
+		}
+	})
+
+	t.Run("environment overlay overrides fields", func(t *testing.T) {
+		cfg, err := LoadConfigForEnv(configPath, "prod")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.DB.DSN != "prod:prodpass@tcp(db.prod:3306)/prod" {
+			t.Errorf("Expected DSN from overlay, got %q", cfg.DB.DSN)
+		}
+		products, err := GetTableConfig(cfg.Tables, "products")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if products.FilePath != "/data/products.csv" {
+			t.Errorf("Expected file path from overlay, got %q", products.FilePath)
+		}
+		if !slicesEqual(products.Dependencies, []string{"categories"}) || products.SyncMode != SyncModeDiff {
+			t.Errorf("Expected other product settings to be kept, got %+v", products)
+		}
+		if len(cfg.Tables) != 3 {
+			t.Errorf("Expected overlay to merge tables by name, got %d tables", len(cfg.Tables))
+		}
+	})
+
+	t.Run("missing overlay is an error", func(t *testing.T) {
+		_, err := LoadConfigForEnv(configPath, "staging")
+		if err == nil {
+			t.Fatal("Expected error for missing overlay file")
+		}
+		if !strings.Contains(err.Error(), "config.staging.yml") {
+			t.Errorf("Expected error to name the overlay file, got: %v", err)
+		}
+	})
+
+	t.Run("config print shows merged config with masked secrets", func(t *testing.T) {
+		cfg, err := LoadConfigForEnv(configPath, "prod")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var buf strings.Builder
+		if err := PrintConfig(&buf, cfg); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		out := buf.String()
+		if strings.Contains(out, "prodpass") {
+			t.Errorf("Expected password to be masked, got:\n%s", out)
+		}
+		for _, expected := range []string{"prod:****@tcp(db.prod:3306)/prod", "/data/products.csv", "name: categories"} {
+			if !strings.Contains(out, expected) {
+				t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+			}
+		}
+		if strings.Contains(out, "include:") || strings.Contains(out, "defaults:") || strings.Contains(out, "sync:") {
+			t.Errorf("Expected only the resolved config to be printed, got:\n%s", out)
+		}
+	})
+}
+
+func TestLoadConfigIncludeErrors(t *testing.T) {
+	t.Run("include cycle", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, map[string]string{
+			"a.yml": "include: [b.yml]\n",
+			"b.yml": "include: [a.yml]\n",
+		})
+		_, err := LoadConfig(filepath.Join(dir, "a.yml"))
+		if err == nil || !strings.Contains(err.Error(), "include cycle") {
+			t.Errorf("Expected include cycle error, got: %v", err)
+		}
+	})
+
+	t.Run("unknown key in included file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, map[string]string{
+			"main.yml":  "include: [part.yml]\n",
+			"part.yml":  "tables:\n  - name: products\n    filPath: products.csv\n",
+			"other.yml": "",
+		})
+		_, err := LoadConfig(filepath.Join(dir, "main.yml"))
+		if err == nil {
+			t.Fatal("Expected error for unknown key in included file")
+		}
+		if !strings.Contains(err.Error(), "part.yml") || !strings.Contains(err.Error(), `unknown field "filPath"`) || !strings.Contains(err.Error(), "[3:5]") {
+			t.Errorf("Expected line-numbered error naming the included file, got: %v", err)
+		}
+	})
+
+	t.Run("missing include", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, map[string]string{"main.yml": "include: [missing.yml]\n"})
+		_, err := LoadConfig(filepath.Join(dir, "main.yml"))
+		if err == nil || !strings.Contains(err.Error(), "missing.yml") {
+			t.Errorf("Expected missing include error, got: %v", err)
+		}
+	})
+
+	t.Run("table-specific key in defaults", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, map[string]string{"main.yml": "defaults:\n  filePath: data.csv\n"})
+		_, err := LoadConfig(filepath.Join(dir, "main.yml"))
+		if err == nil || !strings.Contains(err.Error(), "filePath") {
+			t.Errorf("Expected defaults error, got: %v", err)
+		}
+	})
+}
+
+func TestMergeConfigMaps(t *testing.T) {
+	base := map[string]any{
+		"db":     map[string]any{"dsn": "base", "params": map[string]any{"parseTime": "true"}},
+		"dryRun": false,
+		"tables": []any{
+			map[string]any{"name": "a", "syncMode": "diff"},
+			map[string]any{"name": "b", "syncMode": "diff"},
+		},
+	}
+	override := map[string]any{
+		"db":     map[string]any{"dsn": "override"},
+		"dryRun": true,
+		"tables": []any{
+			map[string]any{"name": "b", "syncMode": "overwrite"},
+			map[string]any{"name": "c"},
+		},
+	}
+
+	merged := mergeConfigMaps(base, override)
+
+	db, ok := merged["db"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected db to be a map, got %T", merged["db"])
+	}
+	if db["dsn"] != "override" || db["params"] == nil {
+		t.Errorf("Expected nested maps to be merged, got %v", db)
+	}
+	if merged["dryRun"] != true {
+		t.Errorf("Expected scalar to be overridden, got %v", merged["dryRun"])
+	}
+	tables, ok := merged["tables"].([]any)
+	if !ok || len(tables) != 3 {
+		t.Fatalf("Expected 3 merged tables, got %v", merged["tables"])
+	}
+	if b, ok := tables[1].(map[string]any); !ok || b["syncMode"] != "overwrite" {
+		t.Errorf("Expected table b to be merged by name, got %v", tables[1])
+	}
+	if base["dryRun"] != false {
+		t.Error("Expected base map not to be modified")
+	}
+}
+
+func TestSelectTables(t *testing.T) {
+	tables := []TableSyncConfig{
+		{Name: "categories", SyncMode: SyncModeDiff, DeleteNotInFile: true},
+		{Name: "products", SyncMode: SyncModeDiff, Dependencies: []string{"categories"}},
+		{Name: "users", SyncMode: SyncModeDiff},
+		{Name: "orders", SyncMode: SyncModeDiff, Dependencies: []string{"users"}},
+		{Name: "order_items", SyncMode: SyncModeDiff, Dependencies: []string{"orders", "products"}},
+	}
+
+	tableNames := func(tables []TableSyncConfig) []string {
+		names := make([]string, 0, len(tables))
+		for _, table := range tables {
+			names = append(names, table.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name             string
+		selection        TableSelection
+		expected         []string
+		expectedWarnings int
+		warningContains  string
+		errContains      string
+	}{
+		{
+			name:      "empty selection keeps all tables",
+			selection: TableSelection{},
+			expected:  []string{"categories", "products", "users", "orders", "order_items"},
+		},
+		{
+			name:      "independent tables",
+			selection: TableSelection{Tables: []string{"users"}},
+			expected:  []string{"users"},
+		},
+		{
+			name:             "missing parent is a warning",
+			selection:        TableSelection{Tables: []string{"products"}},
+			expected:         []string{"products"},
+			expectedWarnings: 1,
+			warningContains:  "'products' depends on 'categories'",
+		},
+		{
+			name:      "with dependencies pulls in ancestors",
+			selection: TableSelection{Tables: []string{"order_items"}, WithDependencies: true},
+			expected:  []string{"categories", "products", "users", "orders", "order_items"},
+		},
+		{
+			name:      "with dependents pulls in descendants",
+			selection: TableSelection{Tables: []string{"users"}, WithDependents: true},
+			expected:  []string{"users", "orders", "order_items"},
+			// order_items also depends on products, which is not selected
+			expectedWarnings: 1,
+			warningContains:  "'order_items' depends on 'products'",
+		},
+		{
+			name:             "deleting parent without dependents is a warning",
+			selection:        TableSelection{Tables: []string{"categories"}},
+			expected:         []string{"categories"},
+			expectedWarnings: 1,
+			warningContains:  "dependent table 'products' is not selected",
+		},
+		{
+			name:      "exclude tables",
+			selection: TableSelection{ExcludeTables: []string{"order_items", "orders", "users"}},
+			expected:  []string{"categories", "products"},
+		},
+		{
+			name:      "exclude applies after dependency expansion",
+			selection: TableSelection{Tables: []string{"orders"}, WithDependencies: true, ExcludeTables: []string{"orders"}},
+			expected:  []string{"users"},
+		},
+		{
+			name:        "unknown table",
+			selection:   TableSelection{Tables: []string{"product"}},
+			errContains: "unknown table 'product'",
+		},
+		{
+			name:        "unknown excluded table",
+			selection:   TableSelection{ExcludeTables: []string{"missing"}},
+			errContains: "unknown table 'missing'",
+		},
+		{
+			name:        "nothing left",
+			selection:   TableSelection{Tables: []string{"users"}, ExcludeTables: []string{"users"}},
+			errContains: "table selection is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, warnings, err := SelectTables(tables, tt.selection)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("Expected error containing %q, got: %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := tableNames(selected); !slicesEqual(got, tt.expected) {
+				t.Errorf("Expected tables %v, got %v", tt.expected, got)
+			}
+			if len(warnings) != tt.expectedWarnings {
+				t.Errorf("Expected %d warnings, got %d: %v", tt.expectedWarnings, len(warnings), warnings)
+			}
+			if tt.warningContains != "" && (len(warnings) == 0 || !strings.Contains(warnings[0], tt.warningContains)) {
+				t.Errorf("Expected warning containing %q, got %v", tt.warningContains, warnings)
+			}
+
+			// The selected subset must always be orderable
+			if _, _, err := GetSyncOrder(selected); err != nil {
+				t.Errorf("GetSyncOrder() failed for selection: %v", err)
+			}
+		})
+	}
+
+	t.Run("unknown dependency", func(t *testing.T) {
+		withTypo := append(slices.Clone(tables), TableSyncConfig{Name: "reviews", SyncMode: SyncModeDiff, Dependencies: []string{"product"}})
+		_, _, err := SelectTables(withTypo, TableSelection{Tables: []string{"reviews"}, WithDependencies: true})
+		var depErr *DependencyError
+		if !errors.As(err, &depErr) || depErr.TableName != "reviews" || depErr.MissingDependency != "product" {
+			t.Errorf("Expected DependencyError for reviews -> product, got: %v", err)
+		}
+	})
+}
+
+func TestDependencyGraphAncestorsAndDescendants(t *testing.T) {
+	graph := NewDependencyGraph([]TableSyncConfig{
+		{Name: "categories"},
+		{Name: "products", Dependencies: []string{"categories"}},
+		{Name: "users"},
+		{Name: "orders", Dependencies: []string{"users"}},
+		{Name: "order_items", Dependencies: []string{"orders", "products"}},
+	})
+
+	if got := graph.Ancestors("order_items"); !slicesEqual(got, []string{"categories", "orders", "products", "users"}) {
+		t.Errorf("Ancestors(order_items) = %v", got)
+	}
+	if got := graph.Descendants("categories"); !slicesEqual(got, []string{"order_items", "products"}) {
+		t.Errorf("Descendants(categories) = %v", got)
+	}
+	if got := graph.Ancestors("users"); len(got) != 0 {
+		t.Errorf("Ancestors(users) = %v, want none", got)
+	}
+}
+
+func TestGetSyncOrderIgnoresUnselectedDependencies(t *testing.T) {
+	insertOrder, deleteOrder, err := GetSyncOrder([]TableSyncConfig{
+		{Name: "products", Dependencies: []string{"categories"}},
+		{Name: "order_items", Dependencies: []string{"orders", "products"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !slicesEqual(insertOrder, []string{"products", "order_items"}) {
+		t.Errorf("insertOrder = %v", insertOrder)
+	}
+	if !slicesEqual(deleteOrder, []string{"order_items", "products"}) {
+		t.Errorf("deleteOrder = %v", deleteOrder)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// dbTimeLayout is the text of DATETIME values as MySQL returns them; trailing zero fractions are dropped
+const dbTimeLayout = "2006-01-02 15:04:05.999999"
+
+// Date formats for epoch timestamps, usable in place of a layout in dateFormats
+const (
+	DateFormatUnix   = "unix"   // Seconds since 1970-01-01 UTC, optionally with a fraction
+	DateFormatUnixMs = "unixms" // Milliseconds since 1970-01-01 UTC
+)
+
+// utcOffsetPattern matches fixed time zones written as UTC offsets, e.g. "+09:00"
+var utcOffsetPattern = regexp.MustCompile(`^([+-])(\d{2}):(\d{2})$`)
+
+// loadTimezone returns the location for a timezone option: an IANA name (e.g. Asia/Tokyo), UTC, Local
+// or a fixed UTC offset such as +09:00. An empty name returns nil, the zone of the database session.
+// Local is only valid for file values, as MySQL rejects it as a session time zone.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if m := utcOffsetPattern.FindStringSubmatch(name); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("invalid UTC offset '%s'", name)
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(name, offset), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone '%s'", name)
+	}
+	return loc, nil
+}
+
+// dbLocation returns the time zone of the database session; UTC, the driver default, if none is configured
+func dbLocation(db DBConfig) *time.Location {
+	loc, err := loadTimezone(db.Timezone)
+	if err != nil || loc == nil {
+		return time.UTC // Invalid zones are rejected by ValidateConfig
+	}
+	return loc
+}
+
+// openDB opens the database, set up for the configured database time zone:
+// the driver writes and reads time values in that zone and the session time_zone matches it,
+// so TIMESTAMP columns are converted consistently with DATETIME columns
+func openDB(db DBConfig) (*sql.DB, error) {
+	if db.Timezone == "" {
+		return sql.Open("mysql", db.DSN)
+	}
+	mysqlCfg, err := sessionConfig(db)
+	if err != nil {
+		return nil, err
+	}
+	// A connector instead of a DSN string, since a fixed zone such as +09:00 has no loadable name
+	connector, err := mysql.NewConnector(mysqlCfg)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// sessionConfig returns the driver configuration of the DSN with the database time zone applied
+func sessionConfig(db DBConfig) (*mysql.Config, error) {
+	mysqlCfg, err := mysql.ParseDSN(db.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSN: %w", err)
+	}
+	mysqlCfg.Loc = dbLocation(db)
+	if mysqlCfg.Params == nil {
+		mysqlCfg.Params = map[string]string{}
+	}
+	sessionZone := db.Timezone
+	if strings.EqualFold(sessionZone, "UTC") {
+		sessionZone = "+00:00" // Works without the MySQL time zone tables
+	}
+	mysqlCfg.Params["time_zone"] = "'" + sessionZone + "'"
+	return mysqlCfg, nil
+}
+
+// parseDateColumns parses the values of the dateFormats columns into time.Time
+// Values without a zone are read in source; a nil source reads them in the database zone. Every time value,
+// including RFC3339 values of other columns, is then converted into the database zone, so that it is
+// written, compared and shown as the DATETIME text the database holds. Values of layouts without a time
+// of day are calendar dates, which are kept as the same date in the database zone. Empty values are left unchanged.
+func parseDateColumns(records []DataRecord, formats map[string]string, source, db *time.Location) error {
+	return parseDateColumnsFrom(0, records, formats, source, db)
+}
+
+// parseDateColumnsFrom is parseDateColumns for a batch of records whose first record has index offset in its file
+func parseDateColumnsFrom(offset int, records []DataRecord, formats map[string]string, source, db *time.Location) error {
+	if source == nil {
+		source = db
+	}
+	columns := slices.Sorted(maps.Keys(formats))
+	dateOnly := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		dateOnly[column] = isDateOnlyLayout(formats[column])
+	}
+	for i, record := range records {
+		for _, column := range columns {
+			value, ok := record[column]
+			if !ok || value == nil {
+				continue
+			}
+			if _, isTime := value.(time.Time); isTime {
+				continue
+			}
+			text := strings.TrimSpace(convertValueToString(value))
+			if text == "" {
+				continue
+			}
+			t, err := parseDateValue(text, formats[column], source)
+			if err != nil {
+				return fmt.Errorf("record %d, column '%s': %w", offset+i+1, column, err)
+			}
+			if dateOnly[column] {
+				t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, db)
+			}
+			record[column] = t
+		}
+		for column, value := range record {
+			if t, isTime := value.(time.Time); isTime {
+				record[column] = t.In(db)
+			}
+		}
+	}
+	return nil
+}
+
+// isDateOnlyLayout reports whether a Go layout has no time of day or zone, e.g. "2006/01/02"
+// Such a layout formats two times of the same day in different zones alike.
+func isDateOnlyLayout(format string) bool {
+	if format == DateFormatUnix || format == DateFormatUnixMs {
+		return false
+	}
+	midnight := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+	afternoon := time.Date(2001, 2, 3, 13, 14, 15, 123456789, time.FixedZone("XST", 5*3600+30*60))
+	return midnight.Format(format) == afternoon.Format(format)
+}
+
+// parseDateValue parses a value in a Go layout or an epoch format
+func parseDateValue(text, format string, loc *time.Location) (time.Time, error) {
+	switch format {
+	case DateFormatUnix:
+		seconds, fraction, _ := strings.Cut(text, ".")
+		sec, err := strconv.ParseInt(seconds, 10, 64)
+		if err != nil || (fraction != "" && strings.Trim(fraction, "0123456789") != "") {
+			return time.Time{}, fmt.Errorf("cannot parse '%s' as epoch seconds", text)
+		}
+		fraction = (fraction + "000000000")[:9]
+		nsec, _ := strconv.ParseInt(fraction, 10, 64)
+		if strings.HasPrefix(seconds, "-") {
+			nsec = -nsec
+		}
+		return time.Unix(sec, nsec), nil
+	case DateFormatUnixMs:
+		ms, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse '%s' as epoch milliseconds", text)
+		}
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.ParseInLocation(format, text, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse '%s' as a date in layout '%s'", text, format)
+	}
+	return t, nil
+}
+
+// timeEqual reports whether a time value matches the DATE or DATETIME text read from the database
+// The text is read in the zone of the value, which is the database zone after loading. A DATE column
+// keeps only the date of the values written to it, so it matches every time of that day.
+func timeEqual(t time.Time, dbStr string) bool {
+	if _, err := time.Parse(time.DateOnly, dbStr); err == nil {
+		return t.Format(time.DateOnly) == dbStr
+	}
+	dbTime, err := time.ParseInLocation(dbTimeLayout, dbStr, t.Location())
+	return err == nil && dbTime.Equal(t)
+}
+
+// validateDateFormats checks the date formats and source time zone of a table
+func validateDateFormats(formats map[string]string, timezone string, columns []string) error {
+	for _, column := range slices.Sorted(maps.Keys(formats)) {
+		if len(columns) > 0 && !slices.Contains(columns, column) {
+			return fmt.Errorf("dateFormats: column '%s' is not in columns", column)
+		}
+		if strings.TrimSpace(formats[column]) == "" {
+			return fmt.Errorf("dateFormats: format of column '%s' is empty", column)
+		}
+	}
+	if _, err := loadTimezone(timezone); err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+	return nil
+}
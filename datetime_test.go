@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadTimezone(t *testing.T) {
+	tests := []struct {
+		name       string
+		wantOffset int
+		wantErr    string
+	}{
+		{"UTC", 0, ""},
+		{"Asia/Tokyo", 9 * 3600, ""},
+		{"+09:00", 9 * 3600, ""},
+		{"-05:30", -(5*3600 + 30*60), ""},
+		{"+15:00", 0, "invalid UTC offset '+15:00'"},
+		{"Mars/Olympus", 0, "unknown time zone 'Mars/Olympus'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := loadTimezone(tt.name)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadTimezone() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, loc).Zone(); offset != tt.wantOffset {
+				t.Errorf("offset = %d, want %d", offset, tt.wantOffset)
+			}
+		})
+	}
+
+	if loc, err := loadTimezone(""); loc != nil || err != nil {
+		t.Errorf("loadTimezone(\"\") = %v, %v, want nil, nil", loc, err)
+	}
+}
+
+func TestSessionConfig(t *testing.T) {
+	dsn := "user:pass@tcp(127.0.0.1:3306)/app?parseTime=true"
+	tests := []struct {
+		timezone    string
+		wantSession string
+	}{
+		{"Asia/Tokyo", "'Asia/Tokyo'"},
+		{"+09:00", "'+09:00'"},
+		{"UTC", "'+00:00'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			mysqlCfg, err := sessionConfig(DBConfig{DSN: dsn, Timezone: tt.timezone})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if mysqlCfg.Loc.String() != tt.timezone {
+				t.Errorf("loc = %s, want %s", mysqlCfg.Loc, tt.timezone)
+			}
+			if mysqlCfg.Params["time_zone"] != tt.wantSession {
+				t.Errorf("time_zone = %s, want %s", mysqlCfg.Params["time_zone"], tt.wantSession)
+			}
+			if !mysqlCfg.ParseTime || mysqlCfg.Passwd != "pass" || mysqlCfg.DBName != "app" {
+				t.Errorf("sessionConfig() lost settings of the DSN: %+v", mysqlCfg)
+			}
+		})
+	}
+
+	if _, err := sessionConfig(DBConfig{DSN: "not a dsn", Timezone: "UTC"}); err == nil {
+		t.Error("Expected an error for an invalid DSN")
+	}
+}
+
+func TestParseDateColumns(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	formats := map[string]string{
+		"ordered_at": "2006/01/02",
+		"shipped_at": time.DateTime,
+		"synced_at":  DateFormatUnix,
+		"event_ms":   DateFormatUnixMs,
+	}
+
+	tests := []struct {
+		name   string
+		source *time.Location
+		db     *time.Location
+		record DataRecord
+		want   DataRecord
+	}{
+		{
+			name:   "values without a zone are read in the database zone by default",
+			db:     tokyo,
+			record: DataRecord{"ordered_at": "2024/06/17", "shipped_at": "2024-06-17 10:00:00", "name": "a"},
+			want: DataRecord{"ordered_at": time.Date(2024, 6, 17, 0, 0, 0, 0, tokyo),
+				"shipped_at": time.Date(2024, 6, 17, 10, 0, 0, 0, tokyo), "name": "a"},
+		},
+		{
+			name:   "source zone is converted into the database zone",
+			source: tokyo,
+			db:     time.UTC,
+			record: DataRecord{"shipped_at": "2024-06-17 10:00:00"},
+			want:   DataRecord{"shipped_at": time.Date(2024, 6, 17, 1, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "dates keep their day in a database zone west of the source",
+			source: tokyo,
+			db:     time.UTC,
+			record: DataRecord{"ordered_at": "2024/06/17", "shipped_at": "2024-06-17 08:00:00"},
+			want: DataRecord{"ordered_at": time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC),
+				"shipped_at": time.Date(2024, 6, 16, 23, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "dates keep their day in a database zone east of the source",
+			source: time.UTC,
+			db:     tokyo,
+			record: DataRecord{"ordered_at": "2024/06/17"},
+			want:   DataRecord{"ordered_at": time.Date(2024, 6, 17, 0, 0, 0, 0, tokyo)},
+		},
+		{
+			name:   "epoch seconds and milliseconds",
+			db:     tokyo,
+			record: DataRecord{"synced_at": json.Number("1718586000.25"), "event_ms": "1718586000123"},
+			want: DataRecord{"synced_at": time.Date(2024, 6, 17, 10, 0, 0, 250000000, tokyo),
+				"event_ms": time.Date(2024, 6, 17, 10, 0, 0, 123000000, tokyo)},
+		},
+		{
+			name:   "RFC3339 values of other columns are converted too",
+			db:     tokyo,
+			record: DataRecord{"at": time.Date(2024, 6, 17, 1, 0, 0, 0, time.UTC)},
+			want:   DataRecord{"at": time.Date(2024, 6, 17, 10, 0, 0, 0, tokyo)},
+		},
+		{
+			name:   "empty and NULL values are kept",
+			db:     time.UTC,
+			record: DataRecord{"ordered_at": "", "shipped_at": nil},
+			want:   DataRecord{"ordered_at": "", "shipped_at": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := []DataRecord{tt.record}
+			if err := parseDateColumns(records, formats, tt.source, tt.db); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, records[0]); diff != "" {
+				t.Errorf("parseDateColumns() mismatch (-want +got):\n%s", diff)
+			}
+			for col, val := range records[0] {
+				if v, ok := val.(time.Time); ok && v.Location() != tt.db {
+					t.Errorf("column %s is in %s, want %s", col, v.Location(), tt.db)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDateColumnsErrors(t *testing.T) {
+	tests := []struct {
+		format  string
+		value   any
+		wantErr string
+	}{
+		{"2006/01/02", "2024-06-17", "record 2, column 'd': cannot parse '2024-06-17' as a date in layout '2006/01/02'"},
+		{DateFormatUnix, "1718586000.x", "cannot parse '1718586000.x' as epoch seconds"},
+		{DateFormatUnixMs, "1718586000.5", "cannot parse '1718586000.5' as epoch milliseconds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			records := []DataRecord{{"d": nil}, {"d": tt.value}}
+			err := parseDateColumns(records, map[string]string{"d": tt.format}, nil, time.UTC)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseDateColumns() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsDateOnlyLayout(t *testing.T) {
+	tests := []struct {
+		format string
+		want   bool
+	}{
+		{"2006/01/02", true},
+		{time.DateOnly, true},
+		{"Jan 2, 2006", true},
+		{"Monday 02.01.2006", true},
+		{time.DateTime, false},
+		{"2006-01-02 15:04", false},
+		{"2006-01-02 3PM", false},
+		{"2006-01-02Z07:00", false},
+		{DateFormatUnix, false},
+	}
+	for _, tt := range tests {
+		if got := isDateOnlyLayout(tt.format); got != tt.want {
+			t.Errorf("isDateOnlyLayout(%q) = %v, want %v", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestTimeEqual(t *testing.T) {
+	tokyo := time.FixedZone("+09:00", 9*3600)
+	value := time.Date(2024, 6, 17, 10, 0, 0, 0, tokyo)
+	tests := []struct {
+		dbStr string
+		want  bool
+	}{
+		{"2024-06-17 10:00:00", true},
+		{"2024-06-17 10:00:00.000000", true},
+		{"2024-06-17 01:00:00", false},
+		{"2024-06-17", true},
+		{"2024-06-16", false},
+		{"not a date", false},
+	}
+	for _, tt := range tests {
+		if got := timeEqual(value, tt.dbStr); got != tt.want {
+			t.Errorf("timeEqual(%v, %q) = %v, want %v", value, tt.dbStr, got, tt.want)
+		}
+	}
+}
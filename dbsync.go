@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
+	"math/big"
 	"reflect"
 	"slices"
 	"strconv"
@@ -35,6 +38,12 @@ func NewPrimaryKey(value any) PrimaryKey {
 	if value == nil {
 		return PrimaryKey{Value: nil, Str: ""}
 	}
+	// A whole JSON number such as 1.0 is kept as written, but must match the key 1 in the database
+	if n, ok := value.(json.Number); ok && !strings.ContainsAny(n.String(), "eE") {
+		if r, ok := new(big.Rat).SetString(n.String()); ok && r.IsInt() {
+			return PrimaryKey{Value: value, Str: r.Num().String()}
+		}
+	}
 	return PrimaryKey{
 		Value: value,
 		Str:   convertValueToString(value),
@@ -90,14 +99,16 @@ func convertValueToString(val any) string {
 		if f == float64(int64(f)) {
 			return strconv.FormatInt(int64(f), 10)
 		}
-		return strconv.FormatFloat(f, 'g', -1, 32)
+		return strconv.FormatFloat(f, 'f', -1, 32)
 	case float64:
 		if v == float64(int64(v)) {
 			return strconv.FormatInt(int64(v), 10)
 		}
-		return strconv.FormatFloat(v, 'g', -1, 64)
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
 	case time.Time:
-		return v.Format(time.RFC3339)
+		return v.Format(dbTimeLayout)
 	}
 
 	// Slow path: Use reflection for other types
@@ -122,7 +133,7 @@ func convertValueToString(val any) string {
 		if f == float64(int64(f)) {
 			return strconv.FormatInt(int64(f), 10)
 		}
-		return strconv.FormatFloat(f, 'g', -1, 64)
+		return strconv.FormatFloat(f, 'f', -1, 64)
 	default:
 		return fmt.Sprintf("%v", val)
 	}
@@ -134,26 +145,21 @@ type UpdateOperation struct {
 	After  DataRecord
 }
 
-// DiffOperations represents the operations to be performed during differential synchronization
-type DiffOperations struct {
-	ToInsert []DataRecord
-	ToUpdate []UpdateOperation
-	ToDelete []DataRecord
-}
-
 // ExecutionPlan represents the planned operations for data synchronization
 type ExecutionPlan struct {
-	SyncMode         string
-	TableName        string
-	FileRecordCount  int
-	DbRecordCount    int
-	InsertOperations []DataRecord
-	UpdateOperations []UpdateOperation
-	DeleteOperations []DataRecord
-	AffectedColumns  []string // These will be the columns actually present in both CSV header and DB
-	TimestampColumns []string
-	ImmutableColumns []string
-	PrimaryKey       string // Added to know which column is PK for display
+	SyncMode           string
+	TableName          string
+	FileRecordCount    int
+	DbRecordCount      int
+	InsertOperations   []DataRecord
+	UpdateOperations   []UpdateOperation
+	DeleteOperations   []DataRecord
+	AffectedColumns    []string // These will be the columns actually present in both CSV header and DB
+	TransformedColumns []string // Affected columns whose file values were transformed
+	Scope              string   // Condition that limits the sync to some rows of the table, if any
+	TimestampColumns   []string
+	ImmutableColumns   []string
+	PrimaryKey         string // Added to know which column is PK for display
 }
 
 // String returns a human-readable representation of the execution plan
@@ -168,6 +174,12 @@ func (p *ExecutionPlan) String() string {
 	buf.WriteString(fmt.Sprintf("- Target Table: %s\n", p.TableName))
 	buf.WriteString(fmt.Sprintf("- Records in File: %d\n", p.FileRecordCount))
 	buf.WriteString(fmt.Sprintf("- Records in Database: %d\n", p.DbRecordCount))
+	if p.Scope != "" {
+		buf.WriteString(fmt.Sprintf("- Scope: %s\n", p.Scope))
+	}
+	if len(p.TransformedColumns) > 0 {
+		buf.WriteString(fmt.Sprintf("- Transformed Columns: %v (values shown after transformation)\n", p.TransformedColumns))
+	}
 	buf.WriteString("\nPlanned Operations:\n")
 
 	if len(p.DeleteOperations) > 0 {
@@ -176,7 +188,7 @@ func (p *ExecutionPlan) String() string {
 		for i, record := range p.DeleteOperations {
 			buf.WriteString(fmt.Sprintf("Record %d:\n", i+1))
 			for col, val := range record {
-				buf.WriteString(fmt.Sprintf("   %s: %v\n", col, val))
+				buf.WriteString(fmt.Sprintf("   %s: %s\n", col, planValue(val)))
 			}
 			buf.WriteString("\n")
 		}
@@ -189,7 +201,7 @@ func (p *ExecutionPlan) String() string {
 		for i, record := range p.InsertOperations {
 			buf.WriteString(fmt.Sprintf("Record %d:\n", i+1))
 			for _, col := range p.AffectedColumns {
-				buf.WriteString(fmt.Sprintf("   %s: %v\n", col, record[col]))
+				buf.WriteString(fmt.Sprintf("   %s: %s%s\n", col, planValue(record[col]), p.transformedMarker(col)))
 			}
 			// Show timestamp values that will be set
 			for _, tsCol := range p.TimestampColumns {
@@ -223,16 +235,16 @@ func (p *ExecutionPlan) String() string {
 			for _, col := range updateableColumns {
 				oldVal := update.Before[col]
 				newVal := update.After[col]
-				if oldVal != newVal {
-					buf.WriteString(fmt.Sprintf("   %s: %v -> %v\n", col, oldVal, newVal))
+				if !valuesEqual(newVal, oldVal) {
+					buf.WriteString(fmt.Sprintf("   %s: %s -> %s%s\n", col, planValue(oldVal), planValue(newVal), p.transformedMarker(col)))
 				} else {
-					buf.WriteString(fmt.Sprintf("   %s: %v (unchanged)\n", col, oldVal))
+					buf.WriteString(fmt.Sprintf("   %s: %s (unchanged)\n", col, planValue(oldVal)))
 				}
 			}
 			// Display immutable columns with a note
 			for _, col := range p.ImmutableColumns {
 				if val, exists := update.After[col]; exists {
-					buf.WriteString(fmt.Sprintf("   %s: %v (immutable)\n", col, val))
+					buf.WriteString(fmt.Sprintf("   %s: %s (immutable)\n", col, planValue(val)))
 				}
 			}
 			// Show timestamp values that will be set
@@ -249,6 +261,40 @@ func (p *ExecutionPlan) String() string {
 	return buf.String()
 }
 
+// planValue formats a value for the execution plan; times are shown as the DATETIME text written to the database
+func planValue(val any) string {
+	if t, ok := val.(time.Time); ok {
+		return convertValueToString(t)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// transformedMarker returns the note shown after file values of transformed columns
+func (p *ExecutionPlan) transformedMarker(col string) string {
+	if slices.Contains(p.TransformedColumns, col) {
+		return " (transformed)"
+	}
+	return ""
+}
+
+// statementContext bounds a single SQL statement by config.StatementTimeout
+// Without a statement timeout the parent context is returned unchanged.
+func statementContext(ctx context.Context, config Config) (context.Context, context.CancelFunc) {
+	if config.StatementTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, config.StatementTimeout)
+}
+
+// tableContext bounds the work on one table by its configured timeout, if any
+func tableContext(ctx context.Context, tableConfig *TableSyncConfig) (context.Context, context.CancelFunc) {
+	ctx = withLogTable(ctx, tableConfig.Name)
+	if tableConfig.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, tableConfig.Timeout)
+}
+
 // getTableColumns retrieves the column names of a given table
 func getTableColumns(ctx context.Context, tx *sql.Tx, tableName string) ([]string, error) {
 	// Query to get column names, specific to MySQL. For other DBs, this might need adjustment.
@@ -278,6 +324,13 @@ func getTableColumns(ctx context.Context, tx *sql.Tx, tableName string) ([]strin
 	return columns, nil
 }
 
+// getTableColumnsWithTimeout retrieves the columns of config.Sync.TableName within the statement timeout
+func getTableColumnsWithTimeout(ctx context.Context, tx *sql.Tx, config Config) ([]string, error) {
+	stmtCtx, cancel := statementContext(ctx, config)
+	defer cancel()
+	return getTableColumns(stmtCtx, tx, config.Sync.TableName)
+}
+
 // findCommonColumns returns the intersection of CSV headers and DB table columns
 func findCommonColumns(csvHeaders []string, dbTableColumns []string) []string {
 	var commonColumns []string
@@ -353,6 +406,12 @@ func generateExecutionPlan(ctx context.Context, tx *sql.Tx, config Config, fileR
 		TimestampColumns: config.Sync.TimestampColumns,
 		ImmutableColumns: config.Sync.ImmutableColumns,
 		PrimaryKey:       config.Sync.PrimaryKey,
+		Scope:            describeScope(config),
+	}
+	for _, col := range transformedColumns(config.Sync.Transform) {
+		if slices.Contains(actualSyncCols, col) {
+			plan.TransformedColumns = append(plan.TransformedColumns, col)
+		}
 	}
 
 	switch config.Sync.SyncMode {
@@ -385,7 +444,7 @@ func generateExecutionPlan(ctx context.Context, tx *sql.Tx, config Config, fileR
 		plan.DbRecordCount = len(dbRecords)
 
 		// Use existing diffData function to calculate differences
-		toInsert, toUpdate, toDelete := diffData(config, fileRecords, dbRecords, actualSyncCols) // Pass actualSyncCols
+		toInsert, toUpdate, toDelete := diffData(ctx, config, fileRecords, dbRecords, actualSyncCols) // Pass actualSyncCols
 		plan.InsertOperations = toInsert
 		plan.UpdateOperations = toUpdate
 		if config.Sync.DeleteNotInFile {
@@ -404,38 +463,62 @@ func generateExecutionPlan(ctx context.Context, tx *sql.Tx, config Config, fileR
 // TRANSACTION BOUNDARY: Single-table synchronization uses one dedicated transaction per table.
 // Transaction scope: Load data → Sync operations → Commit/Rollback
 // If any operation fails, only this table's changes are rolled back.
-func syncData(ctx context.Context, db *sql.DB, config Config, fileRecords []DataRecord) error {
+func syncData(ctx context.Context, db *sql.DB, config Config, fileRecords recordSource) error {
+	ctx = withLogTable(ctx, config.Sync.TableName)
+
 	// Early return only for diff mode without deleteNotInFile
-	if len(fileRecords) == 0 {
+	first, err := firstRecord(fileRecords)
+	if err != nil {
+		return err
+	}
+	if first == nil {
 		if config.Sync.SyncMode == SyncModeDiff && !config.Sync.DeleteNotInFile {
-			log.Println("No records loaded from file. Nothing to sync.")
+			slog.InfoContext(ctx, "No records loaded from file, nothing to sync")
 			return nil
 		}
 		// Log the intention for overwrite or diff+deleteNotInFile modes
 		if config.Sync.SyncMode == SyncModeOverwrite {
-			log.Println("File is empty. In overwrite mode, all existing data will be deleted.")
+			slog.WarnContext(ctx, "File is empty; in overwrite mode all existing data will be deleted")
 		} else if config.Sync.SyncMode == SyncModeDiff && config.Sync.DeleteNotInFile {
-			log.Println("File is empty. In diff mode with deleteNotInFile, all existing data may be deleted.")
+			slog.WarnContext(ctx, "File is empty; in diff mode with deleteNotInFile all existing data may be deleted")
 		}
 	}
 
+	return withRetry(ctx, config.Retry, fmt.Sprintf("sync of table '%s'", config.Sync.TableName), func() error {
+		return syncDataTransaction(ctx, db, config, fileRecords)
+	})
+}
+
+// syncDataTransaction synchronizes fileRecords into the table in a single transaction
+// Every attempt reads the records again, so a streamed file is written batch by batch on each of them.
+func syncDataTransaction(ctx context.Context, db *sql.DB, config Config, fileRecords recordSource) error {
+	stats := currentTableStats(ctx)
+	stats.resetAttempt()
+	stats.SyncMode = config.Sync.SyncMode
+
+	setPhase(ctx, fmt.Sprintf("reading table '%s'", config.Sync.TableName))
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("transaction start error: %w", err)
 	}
-	defer tx.Rollback() // Rollback on error or if commit fails
+	setTxState(ctx, txOpen)
+	defer rollbackTx(ctx, tx) // Rollback on error or if commit fails
 
 	// Determine actual columns to sync
+	first, err := firstRecord(fileRecords)
+	if err != nil {
+		return err
+	}
 	var actualSyncColumns []string
-	if len(fileRecords) > 0 {
+	if first != nil {
 		// Normal case: get headers from file records
-		fileHeaders := make([]string, 0, len(fileRecords[0]))
-		for k := range fileRecords[0] {
+		fileHeaders := make([]string, 0, len(first))
+		for k := range first {
 			fileHeaders = append(fileHeaders, k)
 		}
 		slices.Sort(fileHeaders) // Ensure consistent order
 
-		dbTableCols, err := getTableColumns(ctx, tx, config.Sync.TableName)
+		dbTableCols, err := getTableColumnsWithTimeout(ctx, tx, config)
 		if err != nil {
 			return fmt.Errorf("failed to get database table columns: %w", err)
 		}
@@ -446,7 +529,7 @@ func syncData(ctx context.Context, db *sql.DB, config Config, fileRecords []Data
 		}
 	} else {
 		// Empty file case: use all DB columns for overwrite or diff+deleteNotInFile
-		dbTableCols, err := getTableColumns(ctx, tx, config.Sync.TableName)
+		dbTableCols, err := getTableColumnsWithTimeout(ctx, tx, config)
 		if err != nil {
 			return fmt.Errorf("failed to get database table columns: %w", err)
 		}
@@ -458,18 +541,39 @@ func syncData(ctx context.Context, db *sql.DB, config Config, fileRecords []Data
 		}
 	}
 
-	log.Printf("Actual columns to be synced: %v", actualSyncColumns)
+	slog.InfoContext(ctx, "Determined columns to sync", "columns", actualSyncColumns)
 
 	// For dry-run mode, generate and display execution plan
 	if config.DryRun {
-		plan, err := generateExecutionPlan(ctx, tx, config, fileRecords, actualSyncColumns) // Pass actualSyncCols
+		// The plan lists every operation, so a dry run holds all records of the file
+		records, err := collectRecords(fileRecords)
+		if err != nil {
+			return err
+		}
+		diffStart := time.Now()
+		plan, err := generateExecutionPlan(ctx, tx, config, records, actualSyncColumns) // Pass actualSyncCols
 		if err != nil {
 			return fmt.Errorf("error generating execution plan: %w", err)
 		}
-		log.Print(plan.String())
+		stats.DiffDuration += time.Since(diffStart)
+		stats.Inserted = len(plan.InsertOperations)
+		stats.Updated = len(plan.UpdateOperations)
+		stats.Deleted = len(plan.DeleteOperations)
+		if config.Sync.SyncMode == SyncModeDiff {
+			stats.Rejected = countRecordsWithoutPrimaryKey(records, config.Sync.PrimaryKey)
+		}
+		slog.InfoContext(ctx, "Execution plan",
+			"sync_mode", plan.SyncMode,
+			"file_records", plan.FileRecordCount,
+			"db_records", plan.DbRecordCount,
+			"inserts", len(plan.InsertOperations),
+			"updates", len(plan.UpdateOperations),
+			"deletes", len(plan.DeleteOperations))
+		writeReport("%s", plan.String())
 		return nil // Dry run ends here
 	}
 
+	setPhase(ctx, fmt.Sprintf("%s sync of table '%s'", config.Sync.SyncMode, config.Sync.TableName))
 	switch config.Sync.SyncMode {
 	case SyncModeOverwrite:
 		err = syncOverwrite(ctx, tx, config, fileRecords, actualSyncColumns) // Pass actualSyncCols
@@ -483,36 +587,54 @@ func syncData(ctx context.Context, db *sql.DB, config Config, fileRecords []Data
 		return fmt.Errorf("sync process error: %w", err)
 	}
 
+	setPhase(ctx, fmt.Sprintf("commit of table '%s'", config.Sync.TableName))
+	setTxState(ctx, txCommitting)
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("transaction commit error: %w", err)
+		return &commitError{err: err}
 	}
+	setTxState(ctx, txCommitted)
 
 	return nil
 }
 
 // syncOverwrite performs complete overwrite synchronization
-func syncOverwrite(ctx context.Context, tx *sql.Tx, config Config, fileRecords []DataRecord, actualSyncCols []string) error {
+func syncOverwrite(ctx context.Context, tx *sql.Tx, config Config, fileRecords recordSource, actualSyncCols []string) error {
 	// 1. Delete existing data (DELETE)
-	_, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", config.Sync.TableName))
+	stmtCtx, cancel := statementContext(ctx, config)
+	defer cancel()
+	stats := currentTableStats(ctx)
+	writeStart := time.Now()
+	defer func() { stats.WriteDuration += time.Since(writeStart) }()
+
+	where, scopeArgs := whereScope(config)
+	result, err := tx.ExecContext(stmtCtx, fmt.Sprintf("DELETE FROM %s%s", config.Sync.TableName, where), scopeArgs...)
 	if err != nil {
 		return fmt.Errorf("error deleting data from table '%s': %w", config.Sync.TableName, err)
 	}
-	log.Printf("Deleted existing data from table '%s'.", config.Sync.TableName)
+	stats.Deleted += rowsAffected(result)
+	slog.InfoContext(ctx, "Deleted existing data")
 
-	// 2. Insert all file data
-	if len(fileRecords) == 0 {
-		log.Println("No data to insert from file (file was empty or only header).")
+	// 2. Insert all file data, one batch at a time
+	inserted := 0
+	err = fileRecords.each(func(_ int, batch []DataRecord) error {
+		if len(actualSyncCols) == 0 {
+			return fmt.Errorf("no columns determined for sync, cannot insert data")
+		}
+		if err := bulkInsert(ctx, tx, config, batch, actualSyncCols); err != nil {
+			return fmt.Errorf("data insertion error: %w", err)
+		}
+		inserted += len(batch)
+		stats.Inserted += len(batch)
 		return nil
-	}
-	if len(actualSyncCols) == 0 {
-		return fmt.Errorf("no columns determined for sync, cannot insert data")
-	}
-
-	err = bulkInsert(ctx, tx, config, fileRecords, actualSyncCols)
+	})
 	if err != nil {
-		return fmt.Errorf("data insertion error: %w", err)
+		return err
 	}
-	log.Printf("Inserted %d records using columns: %v.", len(fileRecords), actualSyncCols)
+	if inserted == 0 {
+		slog.InfoContext(ctx, "No data to insert from file (file was empty or only header)")
+		return nil
+	}
+	slog.InfoContext(ctx, "Inserted records", "count", inserted, "columns", actualSyncCols)
 
 	return nil
 }
@@ -528,67 +650,96 @@ func validateDiffSyncRequirements(config Config, actualSyncCols []string) error
 	return nil
 }
 
-// executeSyncOperations executes the planned sync operations
-func executeSyncOperations(ctx context.Context, tx *sql.Tx, config Config, operations DiffOperations, actualSyncCols []string) error {
-	// INSERT processing
-	if len(operations.ToInsert) > 0 {
-		err := bulkInsert(ctx, tx, config, operations.ToInsert, actualSyncCols)
-		if err != nil {
-			return fmt.Errorf("INSERT error: %w", err)
-		}
-		log.Printf("Inserted %d records.", len(operations.ToInsert))
+// syncDiff performs differential synchronization
+// The file records are compared and written batch by batch; only their primary keys are kept, to find the deletes.
+func syncDiff(ctx context.Context, tx *sql.Tx, config Config, fileRecords recordSource, actualSyncCols []string) error {
+	// Validate requirements for differential sync
+	if err := validateDiffSyncRequirements(config, actualSyncCols); err != nil {
+		return err
 	}
 
-	// UPDATE processing
-	if len(operations.ToUpdate) > 0 {
-		updateRecords := make([]DataRecord, len(operations.ToUpdate))
-		for i, op := range operations.ToUpdate {
-			updateRecords[i] = op.After
-		}
-		err := bulkUpdate(ctx, tx, config, updateRecords, actualSyncCols)
-		if err != nil {
-			return fmt.Errorf("UPDATE error: %w", err)
-		}
-		log.Printf("Updated %d records.", len(operations.ToUpdate))
+	// Get current data from DB
+	stats := currentTableStats(ctx)
+	diffStart := time.Now()
+	dbRecords, err := getCurrentDBData(ctx, tx, config, actualSyncCols)
+	if err != nil {
+		return fmt.Errorf("DB data retrieval error: %w", err)
+	}
+	stats.DiffDuration += time.Since(diffStart)
+
+	// Compare file data with DB data, executing the inserts and updates
+	fileKeys, err := syncDiffBatches(ctx, tx, config, fileRecords, dbRecords, actualSyncCols)
+	if err != nil {
+		return err
 	}
 
 	// DELETE processing
-	if len(operations.ToDelete) > 0 && config.Sync.DeleteNotInFile {
-		err := bulkDelete(ctx, tx, config, operations.ToDelete)
+	toDelete := findRecordsToDelete(dbRecords, fileKeys, config.Sync.DeleteNotInFile)
+	if len(toDelete) > 0 {
+		writeStart := time.Now()
+		err := bulkDelete(ctx, tx, config, toDelete)
+		stats.WriteDuration += time.Since(writeStart)
 		if err != nil {
 			return fmt.Errorf("DELETE error: %w", err)
 		}
-		log.Printf("Deleted %d records.", len(operations.ToDelete))
+		stats.Deleted += len(toDelete)
+		slog.InfoContext(ctx, "Deleted records", "count", len(toDelete))
 	}
 
 	return nil
 }
 
-// syncDiff performs differential synchronization
-func syncDiff(ctx context.Context, tx *sql.Tx, config Config, fileRecords []DataRecord, actualSyncCols []string) error {
-	// Validate requirements for differential sync
-	if err := validateDiffSyncRequirements(config, actualSyncCols); err != nil {
-		return err
-	}
+// syncDiffBatches compares the file records with dbRecords one batch at a time, inserting and updating the
+// differences of each batch before the next is read
+// It returns the primary keys of the file records, which are all that is kept of them.
+func syncDiffBatches(ctx context.Context, tx *sql.Tx, config Config, fileRecords recordSource, dbRecords map[string]DataRecord, actualSyncCols []string) (map[string]bool, error) {
+	stats := currentTableStats(ctx)
+	fileKeys := make(map[string]bool)
+	inserted, updated := 0, 0
+	err := fileRecords.each(func(_ int, batch []DataRecord) error {
+		diffStart := time.Now()
+		toInsert, toUpdate, batchKeys := processFileRecords(ctx, batch, dbRecords, config, actualSyncCols)
+		maps.Copy(fileKeys, batchKeys)
+		stats.DiffDuration += time.Since(diffStart)
+		stats.Rejected += countRecordsWithoutPrimaryKey(batch, config.Sync.PrimaryKey)
+
+		writeStart := time.Now()
+		defer func() { stats.WriteDuration += time.Since(writeStart) }()
+
+		// INSERT processing
+		if len(toInsert) > 0 {
+			if err := bulkInsert(ctx, tx, config, toInsert, actualSyncCols); err != nil {
+				return fmt.Errorf("INSERT error: %w", err)
+			}
+			inserted += len(toInsert)
+			stats.Inserted += len(toInsert)
+		}
 
-	// Get current data from DB
-	dbRecords, err := getCurrentDBData(ctx, tx, config, actualSyncCols)
+		// UPDATE processing
+		if len(toUpdate) > 0 {
+			updateRecords := make([]DataRecord, len(toUpdate))
+			for i, op := range toUpdate {
+				updateRecords[i] = op.After
+			}
+			if err := bulkUpdate(ctx, tx, config, updateRecords, actualSyncCols); err != nil {
+				return fmt.Errorf("UPDATE error: %w", err)
+			}
+			updated += len(toUpdate)
+			stats.Updated += len(toUpdate)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("DB data retrieval error: %w", err)
+		return nil, err
 	}
 
-	// Compare file data with DB data
-	toInsert, toUpdate, toDelete := diffData(config, fileRecords, dbRecords, actualSyncCols)
-	operations := DiffOperations{
-		ToInsert: toInsert,
-		ToUpdate: toUpdate,
-		ToDelete: toDelete,
+	if inserted > 0 {
+		slog.InfoContext(ctx, "Inserted records", "count", inserted)
 	}
-	log.Printf("Difference detection result: Insert %d, Update %d, Delete %d",
-		len(operations.ToInsert), len(operations.ToUpdate), len(operations.ToDelete))
-
-	// Execute the planned operations
-	return executeSyncOperations(ctx, tx, config, operations, actualSyncCols)
+	if updated > 0 {
+		slog.InfoContext(ctx, "Updated records", "count", updated)
+	}
+	return fileKeys, nil
 }
 
 // getCurrentDBData retrieves current data from database (for differential sync)
@@ -614,11 +765,15 @@ func getCurrentDBData(ctx context.Context, tx *sql.Tx, config Config, actualSync
 		return nil, fmt.Errorf("primary key '%s' is configured but not in actual sync columns %v; cannot fetch DB data correctly for diff", config.Sync.PrimaryKey, actualSyncCols)
 	}
 
-	query := fmt.Sprintf("SELECT %s FROM %s",
+	where, scopeArgs := whereScope(config)
+	query := fmt.Sprintf("SELECT %s FROM %s%s",
 		strings.Join(selectCols, ","), // Use selectCols which is a clone of actualSyncCols
-		config.Sync.TableName)
+		config.Sync.TableName, where)
 
-	rows, err := tx.QueryContext(ctx, query)
+	// The statement timeout covers reading all rows, not just starting the query
+	stmtCtx, cancel := statementContext(ctx, config)
+	defer cancel()
+	rows, err := tx.QueryContext(stmtCtx, query, scopeArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("query execution error (%s): %w", query, err)
 	}
@@ -650,7 +805,7 @@ func getCurrentDBData(ctx context.Context, tx *sql.Tx, config Config, actualSync
 			if b, ok := val.([]byte); ok {
 				strVal = string(b)
 			} else if val != nil {
-				strVal = fmt.Sprintf("%v", val) // Handle other types
+				strVal = convertValueToString(val) // Handle other types, e.g. time.Time with parseTime
 			} // NULL might be handled as empty string or separately
 
 			record[colName] = strVal
@@ -661,12 +816,12 @@ func getCurrentDBData(ctx context.Context, tx *sql.Tx, config Config, actualSync
 		}
 		if pkValue == nil {
 			// Skip or error if primary key can't be obtained
-			log.Printf("Warning: Found record with nil primary key value. Skipping. Record: %v", record)
+			slog.WarnContext(ctx, "Skipping database record with nil primary key", "record", record)
 			continue
 		}
 		pk := NewPrimaryKey(pkValue)
 		if pk.Str == "" {
-			log.Printf("Warning: Found record with empty primary key after string conversion. Skipping. Record: %v", record)
+			slog.WarnContext(ctx, "Skipping database record with empty primary key", "record", record)
 			continue
 		}
 		dbData[pk.Str] = record
@@ -691,6 +846,26 @@ func extractPrimaryKeyValue(record DataRecord, primaryKey string) (PrimaryKey, b
 	return pk, true
 }
 
+// countRecordsWithoutPrimaryKey counts file records that diff mode skips because their primary key is missing
+func countRecordsWithoutPrimaryKey(records []DataRecord, primaryKey string) int {
+	count := 0
+	for _, record := range records {
+		if _, ok := extractPrimaryKeyValue(record, primaryKey); !ok {
+			count++
+		}
+	}
+	return count
+}
+
+// rowsAffected returns the number of affected rows, or 0 if the driver cannot report it
+func rowsAffected(result sql.Result) int {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
 // compareRecords compares two records and returns true if they differ
 func compareRecords(fileRecord, dbRecord DataRecord, actualSyncCols []string, primaryKey string) bool {
 	for _, col := range actualSyncCols {
@@ -705,8 +880,7 @@ func compareRecords(fileRecord, dbRecord DataRecord, actualSyncCols []string, pr
 		} else if fileColExists && !dbColExists {
 			return true
 		} else if fileColExists && dbColExists {
-			fileStr := convertValueToString(fileVal)
-			if fileStr != dbVal {
+			if !valuesEqual(fileVal, dbVal) {
 				return true
 			}
 		}
@@ -714,16 +888,45 @@ func compareRecords(fileRecord, dbRecord DataRecord, actualSyncCols []string, pr
 	return false
 }
 
+// valuesEqual reports whether a file value matches the string value read from the database
+// JSON objects and arrays are compared by content, since the database normalizes their text,
+// JSON numbers by exact value, since DECIMAL columns pad their fraction with zeros, and times by instant,
+// since DATE and DATETIME(n) columns omit or pad the time and fraction.
+func valuesEqual(fileVal, dbVal any) bool {
+	fileStr := convertValueToString(fileVal)
+	if fileStr == dbVal {
+		return true
+	}
+	switch fileVal.(type) {
+	case JSONValue:
+		dbStr, isStr := dbVal.(string)
+		return isStr && jsonEqual(fileStr, dbStr)
+	case json.Number:
+		dbStr, isStr := dbVal.(string)
+		return isStr && decimalEqual(fileStr, dbStr)
+	case time.Time:
+		dbStr, isStr := dbVal.(string)
+		return isStr && timeEqual(fileVal.(time.Time), dbStr)
+	}
+	return false
+}
+
 // processFileRecords processes file records and determines insert/update operations
-func processFileRecords(fileRecords []DataRecord, dbRecords map[string]DataRecord, config Config, actualSyncCols []string) ([]DataRecord, []UpdateOperation, map[string]bool) {
+func processFileRecords(ctx context.Context, fileRecords []DataRecord, dbRecords map[string]DataRecord, config Config, actualSyncCols []string) ([]DataRecord, []UpdateOperation, map[string]bool) {
 	var toInsert []DataRecord
 	var toUpdate []UpdateOperation
 	fileKeys := make(map[string]bool)
 
+	// Run-scoped constants such as a batch ID differ on every run; a change in them alone is no update
+	compareCols := slices.DeleteFunc(slices.Clone(actualSyncCols), func(col string) bool {
+		return slices.Contains(runScopedColumns(config), col)
+	})
+
 	for _, fileRecord := range fileRecords {
 		pk, isValid := extractPrimaryKeyValue(fileRecord, config.Sync.PrimaryKey)
 		if !isValid {
-			log.Printf("Warning: Record in file is missing primary key '%s' value or key itself. Skipping: %v", config.Sync.PrimaryKey, fileRecord)
+			slog.WarnContext(ctx, "Skipping file record without primary key value",
+				"table", config.Sync.TableName, "primary_key", config.Sync.PrimaryKey, "record", fileRecord)
 			continue
 		}
 		fileKeys[pk.Str] = true
@@ -731,7 +934,7 @@ func processFileRecords(fileRecords []DataRecord, dbRecords map[string]DataRecor
 		dbRecord, existsInDB := dbRecords[pk.Str]
 		if !existsInDB {
 			toInsert = append(toInsert, fileRecord)
-		} else if compareRecords(fileRecord, dbRecord, actualSyncCols, config.Sync.PrimaryKey) {
+		} else if compareRecords(fileRecord, dbRecord, compareCols, config.Sync.PrimaryKey) {
 			toUpdate = append(toUpdate, UpdateOperation{
 				Before: dbRecord,
 				After:  fileRecord,
@@ -760,18 +963,19 @@ func findRecordsToDelete(dbRecords map[string]DataRecord, fileKeys map[string]bo
 // diffData compares file data with DB data (for differential sync)
 // It now uses actualSyncCols to determine which columns to compare.
 func diffData(
+	ctx context.Context,
 	config Config,
 	fileRecords []DataRecord,
 	dbRecords map[string]DataRecord,
 	actualSyncCols []string,
 ) (toInsert []DataRecord, toUpdate []UpdateOperation, toDelete []DataRecord) {
 	if config.Sync.PrimaryKey == "" {
-		log.Println("Error: Primary key not configured, cannot perform diff.") // Should be caught earlier
+		slog.ErrorContext(ctx, "Primary key not configured, cannot perform diff", "table", config.Sync.TableName) // Should be caught earlier
 		return
 	}
 
 	// Process file records to determine insert/update operations
-	toInsert, toUpdate, fileKeys := processFileRecords(fileRecords, dbRecords, config, actualSyncCols)
+	toInsert, toUpdate, fileKeys := processFileRecords(ctx, fileRecords, dbRecords, config, actualSyncCols)
 
 	// Identify records to delete
 	toDelete = findRecordsToDelete(dbRecords, fileKeys, config.Sync.DeleteNotInFile)
@@ -822,7 +1026,9 @@ func bulkInsert(ctx context.Context, tx *sql.Tx, config Config, records []DataRe
 		strings.Join(insertStatementCols, ","),
 		strings.Join(valueStrings, ","))
 
-	_, err := tx.ExecContext(ctx, stmt, valueArgs...)
+	stmtCtx, cancel := statementContext(ctx, config)
+	defer cancel()
+	_, err := tx.ExecContext(stmtCtx, stmt, valueArgs...)
 	return err
 }
 
@@ -856,14 +1062,15 @@ func bulkUpdate(ctx context.Context, tx *sql.Tx, config Config, records []DataRe
 	}
 
 	if len(setClauses) == 0 {
-		log.Println("No columns to update after excluding primary key and immutable columns.")
+		slog.InfoContext(ctx, "No columns to update after excluding primary key and immutable columns")
 		return nil
 	}
 
-	stmtSQL := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?",
+	scope, scopeArgs := andScope(config)
+	stmtSQL := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?%s",
 		config.Sync.TableName,
 		strings.Join(setClauses, ", "),
-		config.Sync.PrimaryKey)
+		config.Sync.PrimaryKey, scope)
 
 	stmt, err := tx.PrepareContext(ctx, stmtSQL)
 	if err != nil {
@@ -881,8 +1088,9 @@ func bulkUpdate(ctx context.Context, tx *sql.Tx, config Config, records []DataRe
 			args = append(args, now)
 		}
 		args = append(args, record[config.Sync.PrimaryKey]) // PK for WHERE
+		args = append(args, scopeArgs...)
 
-		_, err = stmt.ExecContext(ctx, args...)
+		err = execWithTimeout(ctx, config, stmt, args)
 		if err != nil {
 			return fmt.Errorf("UPDATE execution error (PK: %s): %w", record[config.Sync.PrimaryKey], err)
 		}
@@ -890,6 +1098,14 @@ func bulkUpdate(ctx context.Context, tx *sql.Tx, config Config, records []DataRe
 	return nil
 }
 
+// execWithTimeout executes a prepared statement within the statement timeout
+func execWithTimeout(ctx context.Context, config Config, stmt *sql.Stmt, args []any) error {
+	stmtCtx, cancel := statementContext(ctx, config)
+	defer cancel()
+	_, err := stmt.ExecContext(stmtCtx, args...)
+	return err
+}
+
 // bulkDelete performs deletion of multiple records
 // This function does not need actualSyncCols as it only uses the Primary Key.
 func bulkDelete(ctx context.Context, tx *sql.Tx, config Config, records []DataRecord) error {
@@ -903,12 +1119,15 @@ func bulkDelete(ctx context.Context, tx *sql.Tx, config Config, records []DataRe
 		placeholders = append(placeholders, "?")
 	}
 
-	stmt := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)",
+	scope, scopeArgs := andScope(config)
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)%s",
 		config.Sync.TableName,
 		config.Sync.PrimaryKey,
-		strings.Join(placeholders, ","))
+		strings.Join(placeholders, ","), scope)
 
-	_, err := tx.ExecContext(ctx, stmt, pkValues...)
+	stmtCtx, cancel := statementContext(ctx, config)
+	defer cancel()
+	_, err := tx.ExecContext(stmtCtx, stmt, append(pkValues, scopeArgs...)...)
 	return err
 }
 
@@ -934,24 +1153,47 @@ func syncMultipleTablesData(ctx context.Context, db *sql.DB, config Config) erro
 	}
 
 	// 1. Load data from all files (OUTSIDE TRANSACTION)
-	// Note: For very large datasets, consider implementing streaming/batching to reduce memory usage
+	// Parquet files are streamed: each of the passes below reads them again, one batch at a time
+	setPhase(ctx, "loading files")
 	multiLoader := NewMultiTableLoader(config.Tables)
+	multiLoader.DBLocation = dbLocation(config.DB)
+	multiLoader.RunID = runStatsFrom(ctx).RunID
 	if err := multiLoader.ValidateFilePaths(); err != nil {
 		return fmt.Errorf("multi-table file validation error: %w", err)
 	}
 
-	allData, err := multiLoader.LoadAll()
+	allData, err := multiLoader.openAll()
 	if err != nil {
 		return fmt.Errorf("multi-table data loading error: %w", err)
 	}
 
-	log.Printf("Loaded data from %d table files", len(allData))
-	for tableName, records := range allData {
-		log.Printf("Table '%s': %d records", tableName, len(records))
+	slog.InfoContext(ctx, "Loaded table files", "files", len(allData))
+	for _, tableConfig := range config.Tables {
+		count, err := countRecords(allData[tableConfig.Name])
+		if err != nil {
+			return fmt.Errorf("multi-table data loading error: %w", err)
+		}
+		tableCtx := withLogTable(ctx, tableConfig.Name)
+		stats := currentTableStats(tableCtx)
+		stats.SyncMode = tableConfig.SyncMode
+		stats.RowsRead = count
+		slog.InfoContext(tableCtx, "Loaded records from file", "records", count)
 	}
 
-	// 🚨 STRICT PRIMARY KEY VALIDATION for all tables - Always enforced for data safety
-	validator := NewPrimaryKeyValidator()
+	// File rows outside the scope of their table are rejected before anything else is checked
+	setPhase(ctx, "checking scope")
+	var outOfScopeTables []string
+	for _, tableConfig := range config.Tables {
+		if err := checkScope(newSingleTableConfig(config, &tableConfig, config.DryRun), allData[tableConfig.Name]); err != nil {
+			outOfScopeTables = append(outOfScopeTables, fmt.Sprintf("%s (%v)", tableConfig.Name, err))
+		}
+	}
+	if len(outOfScopeTables) > 0 {
+		return fmt.Errorf("scope check failed for tables: %s", strings.Join(outOfScopeTables, ", "))
+	}
+
+	// 🚨 PRIMARY KEY VALIDATION for all tables - Strict unless primaryKeyValidation.mode is warn
+	setPhase(ctx, "validating primary keys")
 	for _, tableConfig := range config.Tables {
 		if tableConfig.SyncMode == SyncModeDiff && tableConfig.PrimaryKey != "" {
 			records, exists := allData[tableConfig.Name]
@@ -959,23 +1201,69 @@ func syncMultipleTablesData(ctx context.Context, db *sql.DB, config Config) erro
 				continue // Skip if no data for this table
 			}
 
-			log.Printf("Validating primary keys for table '%s'...", tableConfig.Name)
-			validationResult, err := validator.ValidateAllRecords(records, tableConfig.PrimaryKey)
+			tableCtx := withLogTable(ctx, tableConfig.Name)
+			slog.InfoContext(tableCtx, "Validating primary keys")
+			validRecords, err := validatePrimaryKeys(tableCtx, tableConfig.PrimaryKeyValidation, records, tableConfig.PrimaryKey)
 			if err != nil {
-				log.Printf("Primary key validation failed for table '%s'", tableConfig.Name)
-				validator.ReportValidationFailure(validationResult)
 				return fmt.Errorf("primary key validation failed for table '%s': %w", tableConfig.Name, err)
 			}
+			allData[tableConfig.Name] = validRecords
 		}
 	}
 
+	// Column validation rules; every table is checked so that all errors are reported at once
+	setPhase(ctx, "validating records")
+	var invalidTables []string
+	for _, tableConfig := range config.Tables {
+		records, exists := allData[tableConfig.Name]
+		if !exists || len(tableConfig.Validate) == 0 {
+			continue
+		}
+		if err := validateRecords(withLogTable(ctx, tableConfig.Name), tableConfig.Validate, records); err != nil {
+			invalidTables = append(invalidTables, fmt.Sprintf("%s (%v)", tableConfig.Name, err))
+		}
+	}
+	if len(invalidTables) > 0 {
+		return fmt.Errorf("record validation failed for tables: %s", strings.Join(invalidTables, ", "))
+	}
+
+	// Schema check against the column types, also before the transaction and for all tables at once
+	setPhase(ctx, "checking values against the table schema")
+	for _, tableConfig := range config.Tables {
+		if !tableConfig.SchemaCheck {
+			continue
+		}
+		singleConfig := newSingleTableConfig(config, &tableConfig, config.DryRun)
+		if err := checkTableSchema(withLogTable(ctx, tableConfig.Name), db, singleConfig, allData[tableConfig.Name]); err != nil {
+			invalidTables = append(invalidTables, fmt.Sprintf("%s (%v)", tableConfig.Name, err))
+		}
+	}
+	if len(invalidTables) > 0 {
+		return fmt.Errorf("schema check failed for tables: %s", strings.Join(invalidTables, ", "))
+	}
+
 	// 2. Determine synchronization order based on dependencies (OUTSIDE TRANSACTION)
 	insertOrder, deleteOrder, err := GetSyncOrder(config.Tables)
 	if err != nil {
 		return fmt.Errorf("dependency order calculation error: %w", err)
 	}
 
-	log.Printf("Synchronization order - Insert: %v, Delete: %v", insertOrder, deleteOrder)
+	slog.InfoContext(ctx, "Determined synchronization order", "insert_order", insertOrder, "delete_order", deleteOrder)
+	runStatsFrom(ctx).setSyncOrder(insertOrder, deleteOrder)
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("synchronization cancelled before the transaction started: %w", err)
+	}
+
+	// 3-6. Run the transaction, retrying it as a whole after deadlocks and dropped connections
+	return withRetry(ctx, config.Retry, "multi-table sync transaction", func() error {
+		return multiTableSyncTransaction(ctx, db, config, allData, insertOrder, deleteOrder)
+	})
+}
+
+// multiTableSyncTransaction synchronizes all tables in a single global transaction
+func multiTableSyncTransaction(ctx context.Context, db *sql.DB, config Config, allData map[string]recordSource, insertOrder []string, deleteOrder []string) error {
+	runStatsFrom(ctx).resetAttempt()
 
 	// 3. Start SINGLE GLOBAL TRANSACTION for all table synchronizations
 	// This ensures all-or-nothing semantics across all related tables
@@ -983,8 +1271,9 @@ func syncMultipleTablesData(ctx context.Context, db *sql.DB, config Config) erro
 	if err != nil {
 		return fmt.Errorf("transaction start error: %w", err)
 	}
+	setTxState(ctx, txOpen)
 	// Automatic rollback on ANY error via defer - ensures cleanup if commit fails or panic occurs
-	defer tx.Rollback()
+	defer rollbackTx(ctx, tx)
 
 	// 4. For dry-run mode, generate and display execution plan
 	if config.DryRun {
@@ -1003,27 +1292,26 @@ func syncMultipleTablesData(ctx context.Context, db *sql.DB, config Config) erro
 
 	// 6. Commit transaction - only if ALL table syncs succeeded
 	// If commit fails, defer tx.Rollback() will handle cleanup
+	setPhase(ctx, "commit")
+	setTxState(ctx, txCommitting)
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("transaction commit error: %w", err)
+		return &commitError{err: err}
 	}
+	setTxState(ctx, txCommitted)
 
 	return nil
 }
 
 // generateMultiTableExecutionPlan creates and displays execution plan for multiple tables
-func generateMultiTableExecutionPlan(ctx context.Context, db *sql.DB, _ *sql.Tx, config Config, allData MultiTableData, insertOrder []string, deleteOrder []string) error {
-	log.Println("[DRY-RUN Mode] Multi-Table Execution Plan")
-	log.Println("====================================================")
-
-	// Show sync order
-	log.Printf("Insert/Update Order (parent→child): %v", insertOrder)
-	log.Printf("Delete Order (child→parent): %v", deleteOrder)
-	log.Println()
+func generateMultiTableExecutionPlan(ctx context.Context, db *sql.DB, _ *sql.Tx, config Config, allData map[string]recordSource, insertOrder []string, deleteOrder []string) error {
+	writeReport("[DRY-RUN Mode] Multi-Table Execution Plan\n"+
+		"====================================================\n"+
+		"Insert/Update Order (parent→child): %v\n"+
+		"Delete Order (child→parent): %v\n", insertOrder, deleteOrder)
 
 	// Generate individual execution plans for each table
 	for i, tableName := range insertOrder {
-		log.Printf("[%d] Table: %s", i+1, tableName)
-		log.Println("----------------------------------------------------")
+		writeReport("[%d] Table: %s\n----------------------------------------------------", i+1, tableName)
 
 		tableConfig, err := GetTableConfig(config.Tables, tableName)
 		if err != nil {
@@ -1031,41 +1319,63 @@ func generateMultiTableExecutionPlan(ctx context.Context, db *sql.DB, _ *sql.Tx,
 		}
 
 		// Create single-table config for compatibility with existing syncData function
-		singleConfig := Config{
-			DB:     config.DB,
-			DryRun: true,
-			Sync: SyncConfig{
-				FilePath:         tableConfig.FilePath,
-				TableName:        tableConfig.Name,
-				Columns:          tableConfig.Columns,
-				TimestampColumns: tableConfig.TimestampColumns,
-				ImmutableColumns: tableConfig.ImmutableColumns,
-				PrimaryKey:       tableConfig.PrimaryKey,
-				SyncMode:         tableConfig.SyncMode,
-				DeleteNotInFile:  tableConfig.DeleteNotInFile,
-			},
-		}
+		singleConfig := newSingleTableConfig(config, tableConfig, true)
 
 		// Get table data
 		tableData, exists := allData[tableName]
 		if !exists {
-			log.Printf("No data loaded for table '%s'\n", tableName)
+			slog.WarnContext(withLogTable(ctx, tableName), "No data loaded for table")
 			continue
 		}
 
 		// Use existing syncData function for planning (in dry-run mode)
-		err = syncData(ctx, db, singleConfig, tableData)
+		tableCtx, cancel := tableContext(ctx, tableConfig)
+		// The surrounding transaction is retried as a whole, so plan each table only once here
+		err = syncDataTransaction(tableCtx, db, singleConfig, tableData)
+		cancel()
 		if err != nil {
 			return fmt.Errorf("execution plan generation error for table '%s': %w", tableName, err)
 		}
-		log.Println()
 	}
 
 	return nil
 }
 
+// newSingleTableConfig converts one table of a multi-table config into a legacy single-table config,
+// so that the single-table sync functions can be reused for each table
+func newSingleTableConfig(config Config, tableConfig *TableSyncConfig, dryRun bool) Config {
+	return Config{
+		DB:               config.DB,
+		DryRun:           dryRun,
+		StatementTimeout: config.StatementTimeout,
+		Sync: SyncConfig{
+			FilePath:         tableConfig.FilePath,
+			TableName:        tableConfig.Name,
+			Columns:          tableConfig.Columns,
+			TimestampColumns: tableConfig.TimestampColumns,
+			ImmutableColumns: tableConfig.ImmutableColumns,
+			PrimaryKey:       tableConfig.PrimaryKey,
+			SyncMode:         tableConfig.SyncMode,
+			DeleteNotInFile:  tableConfig.DeleteNotInFile,
+			Validate:         tableConfig.Validate,
+			SchemaCheck:      tableConfig.SchemaCheck,
+			Transform:        tableConfig.Transform,
+			Constants:        tableConfig.Constants,
+			Scope:            tableConfig.Scope,
+			Encoding:         tableConfig.Encoding,
+			CSV:              tableConfig.CSV,
+			JSON:             tableConfig.JSON,
+			XLSX:             tableConfig.XLSX,
+			DateFormats:      tableConfig.DateFormats,
+			Timezone:         tableConfig.Timezone,
+
+			PrimaryKeyValidation: tableConfig.PrimaryKeyValidation,
+		},
+	}
+}
+
 // executeMultiTableSync executes synchronization for multiple tables in dependency order
-func executeMultiTableSync(ctx context.Context, tx *sql.Tx, config Config, allData MultiTableData, insertOrder []string, deleteOrder []string) error {
+func executeMultiTableSync(ctx context.Context, tx *sql.Tx, config Config, allData map[string]recordSource, insertOrder []string, deleteOrder []string) error {
 	// Phase 1: Delete operations in reverse dependency order (child→parent)
 	for _, tableName := range deleteOrder {
 		tableConfig, err := GetTableConfig(config.Tables, tableName)
@@ -1078,8 +1388,10 @@ func executeMultiTableSync(ctx context.Context, tx *sql.Tx, config Config, allDa
 			continue
 		}
 
-		log.Printf("Processing deletes for table '%s'", tableName)
-		err = executeSingleTableSync(ctx, tx, config, tableName, allData[tableName], "delete")
+		setPhase(ctx, fmt.Sprintf("delete phase of table '%s'", tableName))
+		tableCtx, cancel := tableContext(ctx, tableConfig)
+		err = executeSingleTableSync(tableCtx, tx, config, tableName, allData[tableName], "delete")
+		cancel()
 		if err != nil {
 			return fmt.Errorf("delete phase error for table '%s': %w", tableName, err)
 		}
@@ -1087,8 +1399,15 @@ func executeMultiTableSync(ctx context.Context, tx *sql.Tx, config Config, allDa
 
 	// Phase 2: Insert/Update operations in dependency order (parent→child)
 	for _, tableName := range insertOrder {
-		log.Printf("Processing inserts/updates for table '%s'", tableName)
-		err := executeSingleTableSync(ctx, tx, config, tableName, allData[tableName], "insert_update")
+		tableConfig, err := GetTableConfig(config.Tables, tableName)
+		if err != nil {
+			return fmt.Errorf("table config not found for '%s': %w", tableName, err)
+		}
+
+		setPhase(ctx, fmt.Sprintf("insert/update phase of table '%s'", tableName))
+		tableCtx, cancel := tableContext(ctx, tableConfig)
+		err = executeSingleTableSync(tableCtx, tx, config, tableName, allData[tableName], "insert_update")
+		cancel()
 		if err != nil {
 			return fmt.Errorf("insert/update phase error for table '%s': %w", tableName, err)
 		}
@@ -1098,7 +1417,7 @@ func executeMultiTableSync(ctx context.Context, tx *sql.Tx, config Config, allDa
 }
 
 // executeSingleTableSync executes synchronization for a single table within the transaction
-func executeSingleTableSync(ctx context.Context, tx *sql.Tx, config Config, tableName string, tableData []DataRecord, phase string) error {
+func executeSingleTableSync(ctx context.Context, tx *sql.Tx, config Config, tableName string, tableData recordSource, phase string) error {
 	if tx == nil {
 		return fmt.Errorf("transaction is nil")
 	}
@@ -1112,32 +1431,23 @@ func executeSingleTableSync(ctx context.Context, tx *sql.Tx, config Config, tabl
 	}
 
 	// Create single-table config for compatibility with existing functions
-	singleConfig := Config{
-		DB:     config.DB,
-		DryRun: false, // We're in execution mode
-		Sync: SyncConfig{
-			FilePath:         tableConfig.FilePath,
-			TableName:        tableConfig.Name,
-			Columns:          tableConfig.Columns,
-			TimestampColumns: tableConfig.TimestampColumns,
-			ImmutableColumns: tableConfig.ImmutableColumns,
-			PrimaryKey:       tableConfig.PrimaryKey,
-			SyncMode:         tableConfig.SyncMode,
-			DeleteNotInFile:  tableConfig.DeleteNotInFile,
-		},
-	}
+	singleConfig := newSingleTableConfig(config, tableConfig, false) // We're in execution mode
 
 	// Determine actual columns to sync
+	first, err := firstRecord(tableData)
+	if err != nil {
+		return err
+	}
 	var actualSyncColumns []string
-	if len(tableData) > 0 {
+	if first != nil {
 		// Normal case: get headers from file records
-		fileHeaders := make([]string, 0, len(tableData[0]))
-		for k := range tableData[0] {
+		fileHeaders := make([]string, 0, len(first))
+		for k := range first {
 			fileHeaders = append(fileHeaders, k)
 		}
 		slices.Sort(fileHeaders) // Ensure consistent order
 
-		dbTableCols, err := getTableColumns(ctx, tx, singleConfig.Sync.TableName)
+		dbTableCols, err := getTableColumnsWithTimeout(ctx, tx, singleConfig)
 		if err != nil {
 			return fmt.Errorf("failed to get database table columns for '%s': %w", tableName, err)
 		}
@@ -1148,7 +1458,7 @@ func executeSingleTableSync(ctx context.Context, tx *sql.Tx, config Config, tabl
 		}
 	} else {
 		// Empty data case: use DB columns
-		dbTableCols, err := getTableColumns(ctx, tx, singleConfig.Sync.TableName)
+		dbTableCols, err := getTableColumnsWithTimeout(ctx, tx, singleConfig)
 		if err != nil {
 			return fmt.Errorf("failed to get database table columns for '%s': %w", tableName, err)
 		}
@@ -1171,7 +1481,7 @@ func executeSingleTableSync(ctx context.Context, tx *sql.Tx, config Config, tabl
 }
 
 // executeDeletePhase handles delete operations for a single table in multi-table sync
-func executeDeletePhase(ctx context.Context, tx *sql.Tx, config Config, tableData []DataRecord, actualSyncColumns []string) error {
+func executeDeletePhase(ctx context.Context, tx *sql.Tx, config Config, tableData recordSource, actualSyncColumns []string) error {
 	if config.Sync.SyncMode != SyncModeDiff {
 		return nil // Only diff mode supports delete operations
 	}
@@ -1182,18 +1492,26 @@ func executeDeletePhase(ctx context.Context, tx *sql.Tx, config Config, tableDat
 	}
 
 	// Get current data from DB
+	stats := currentTableStats(ctx)
+	diffStart := time.Now()
 	dbRecords, err := getCurrentDBData(ctx, tx, config, actualSyncColumns)
 	if err != nil {
 		return fmt.Errorf("DB data retrieval error: %w", err)
 	}
 
-	// Find records to delete (records in DB but not in file)
+	// Find records to delete (records in DB but not in file); only the keys of the file are kept
 	fileKeys := make(map[string]bool)
-	for _, fileRecord := range tableData {
-		pk, isValid := extractPrimaryKeyValue(fileRecord, config.Sync.PrimaryKey)
-		if isValid {
-			fileKeys[pk.Str] = true
+	err = tableData.each(func(_ int, batch []DataRecord) error {
+		for _, fileRecord := range batch {
+			pk, isValid := extractPrimaryKeyValue(fileRecord, config.Sync.PrimaryKey)
+			if isValid {
+				fileKeys[pk.Str] = true
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	var toDelete []DataRecord
@@ -1202,21 +1520,25 @@ func executeDeletePhase(ctx context.Context, tx *sql.Tx, config Config, tableDat
 			toDelete = append(toDelete, dbRecord)
 		}
 	}
+	stats.DiffDuration += time.Since(diffStart)
 
 	// Execute delete operations
 	if len(toDelete) > 0 {
+		writeStart := time.Now()
 		err = bulkDelete(ctx, tx, config, toDelete)
+		stats.WriteDuration += time.Since(writeStart)
 		if err != nil {
 			return fmt.Errorf("delete execution error: %w", err)
 		}
-		log.Printf("Table '%s': Deleted %d records", config.Sync.TableName, len(toDelete))
+		stats.Deleted += len(toDelete)
+		slog.InfoContext(ctx, "Deleted records", "count", len(toDelete))
 	}
 
 	return nil
 }
 
 // executeInsertUpdatePhase handles insert and update operations for a single table in multi-table sync
-func executeInsertUpdatePhase(ctx context.Context, tx *sql.Tx, config Config, tableData []DataRecord, actualSyncColumns []string) error {
+func executeInsertUpdatePhase(ctx context.Context, tx *sql.Tx, config Config, tableData recordSource, actualSyncColumns []string) error {
 	switch config.Sync.SyncMode {
 	case SyncModeOverwrite:
 		return executeOverwritePhase(ctx, tx, config, tableData, actualSyncColumns)
@@ -1228,64 +1550,60 @@ func executeInsertUpdatePhase(ctx context.Context, tx *sql.Tx, config Config, ta
 }
 
 // executeOverwritePhase handles overwrite mode operations (delete existing + insert all)
-func executeOverwritePhase(ctx context.Context, tx *sql.Tx, config Config, tableData []DataRecord, actualSyncColumns []string) error {
+func executeOverwritePhase(ctx context.Context, tx *sql.Tx, config Config, tableData recordSource, actualSyncColumns []string) error {
 	// In overwrite mode for multi-table sync, we delete ALL existing data first
 	// This ensures a complete refresh of the table data
-	_, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", config.Sync.TableName))
+	stmtCtx, cancel := statementContext(ctx, config)
+	defer cancel()
+	stats := currentTableStats(ctx)
+	writeStart := time.Now()
+	defer func() { stats.WriteDuration += time.Since(writeStart) }()
+
+	where, scopeArgs := whereScope(config)
+	result, err := tx.ExecContext(stmtCtx, fmt.Sprintf("DELETE FROM %s%s", config.Sync.TableName, where), scopeArgs...)
 	if err != nil {
 		return fmt.Errorf("error deleting all data from table '%s': %w", config.Sync.TableName, err)
 	}
-	log.Printf("Table '%s': Deleted all existing data for overwrite", config.Sync.TableName)
+	stats.Deleted += rowsAffected(result)
+	slog.InfoContext(ctx, "Deleted all existing data for overwrite")
 
-	// Insert all file records
-	if len(tableData) > 0 {
-		err := bulkInsert(ctx, tx, config, tableData, actualSyncColumns)
-		if err != nil {
+	// Insert all file records, one batch at a time
+	inserted := 0
+	err = tableData.each(func(_ int, batch []DataRecord) error {
+		if err := bulkInsert(ctx, tx, config, batch, actualSyncColumns); err != nil {
 			return fmt.Errorf("insert execution error: %w", err)
 		}
-		log.Printf("Table '%s': Inserted %d records", config.Sync.TableName, len(tableData))
+		inserted += len(batch)
+		stats.Inserted += len(batch)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if inserted > 0 {
+		slog.InfoContext(ctx, "Inserted records", "count", inserted)
 	}
 
 	return nil
 }
 
 // executeDiffInsertUpdatePhase handles differential insert and update operations
-func executeDiffInsertUpdatePhase(ctx context.Context, tx *sql.Tx, config Config, tableData []DataRecord, actualSyncColumns []string) error {
+func executeDiffInsertUpdatePhase(ctx context.Context, tx *sql.Tx, config Config, tableData recordSource, actualSyncColumns []string) error {
 	// Validate requirements for differential sync
 	if err := validateDiffSyncRequirements(config, actualSyncColumns); err != nil {
 		return err
 	}
 
 	// Get current data from DB
+	stats := currentTableStats(ctx)
+	diffStart := time.Now()
 	dbRecords, err := getCurrentDBData(ctx, tx, config, actualSyncColumns)
 	if err != nil {
 		return fmt.Errorf("DB data retrieval error: %w", err)
 	}
+	stats.DiffDuration += time.Since(diffStart)
 
-	// Compare file data with DB data to find insert/update operations
-	toInsert, toUpdate, _ := diffData(config, tableData, dbRecords, actualSyncColumns)
-
-	// Execute insert operations
-	if len(toInsert) > 0 {
-		err = bulkInsert(ctx, tx, config, toInsert, actualSyncColumns)
-		if err != nil {
-			return fmt.Errorf("insert execution error: %w", err)
-		}
-		log.Printf("Table '%s': Inserted %d records", config.Sync.TableName, len(toInsert))
-	}
-
-	// Execute update operations
-	if len(toUpdate) > 0 {
-		updateRecords := make([]DataRecord, len(toUpdate))
-		for i, op := range toUpdate {
-			updateRecords[i] = op.After
-		}
-		err = bulkUpdate(ctx, tx, config, updateRecords, actualSyncColumns)
-		if err != nil {
-			return fmt.Errorf("update execution error: %w", err)
-		}
-		log.Printf("Table '%s': Updated %d records", config.Sync.TableName, len(toUpdate))
-	}
-
-	return nil
+	// Compare file data with DB data, executing the inserts and updates
+	_, err = syncDiffBatches(ctx, tx, config, tableData, dbRecords, actualSyncColumns)
+	return err
 }
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
 	"reflect"
@@ -118,7 +120,7 @@ func TestSyncOverwrite(t *testing.T) {
 			{"id": "2", "name": "test2", "value": "value2"},
 		}
 
-		err := syncData(t.Context(), db, config, fileRecords)
+		err := syncData(t.Context(), db, config, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("Failed to sync data: %v", err)
 		}
@@ -159,7 +161,7 @@ func TestSyncOverwrite(t *testing.T) {
 			{"id": "2", "name": "new2", "value": "new_value2"},
 		}
 
-		err = syncData(t.Context(), db, config, fileRecords)
+		err = syncData(t.Context(), db, config, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("Failed to sync data: %v", err)
 		}
@@ -206,7 +208,7 @@ func TestSyncOverwrite(t *testing.T) {
 			{"id": "2", "name": "file_name2", "value": "file_value2", "extra_csv_col": "ignore_this_too"},
 		}
 
-		err = syncData(t.Context(), db, localConfig, fileRecords)
+		err = syncData(t.Context(), db, localConfig, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("Failed to sync data: %v", err)
 		}
@@ -259,7 +261,7 @@ func TestSyncOverwrite(t *testing.T) {
 			{"id": "2", "name": "file_name2", "value": "file_value2_ignored"},
 		}
 
-		err = syncData(t.Context(), db, localConfig, fileRecords)
+		err = syncData(t.Context(), db, localConfig, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("Failed to sync data: %v", err)
 		}
@@ -317,7 +319,7 @@ func TestSyncDiff(t *testing.T) {
 			{"id": "2", "name": "test2", "value": "value2"},
 		}
 
-		err := syncData(t.Context(), db, config, fileRecords)
+		err := syncData(t.Context(), db, config, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("Failed to sync data: %v", err)
 		}
@@ -362,7 +364,7 @@ func TestSyncDiff(t *testing.T) {
 			{"id": "4", "name": "test4", "value": "value4"},
 		}
 
-		err = syncData(t.Context(), db, config, fileRecords)
+		err = syncData(t.Context(), db, config, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("Failed to sync data: %v", err)
 		}
@@ -410,7 +412,7 @@ func TestSyncDiff(t *testing.T) {
 			{"id": "1", "name": "new_name", "value": "new_value"},
 		}
 
-		err = syncData(t.Context(), db, localConfig, fileRecords)
+		err = syncData(t.Context(), db, localConfig, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("Failed to sync data: %v", err)
 		}
@@ -447,7 +449,7 @@ func TestSyncDiff(t *testing.T) {
 			{"id": "2", "name": "file_name2", "value": "file_value2", "extra_csv_col": "ignore_this_too"},
 		}
 
-		err = syncData(t.Context(), db, localConfig, fileRecords)
+		err = syncData(t.Context(), db, localConfig, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("Failed to sync data: %v", err)
 		}
@@ -497,7 +499,7 @@ func TestSyncDiff(t *testing.T) {
 			{"id": "2", "name": "file_name2", "value": "file_value2"},
 		}
 
-		err = syncData(t.Context(), db, localConfig, fileRecords)
+		err = syncData(t.Context(), db, localConfig, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("Failed to sync data: %v", err)
 		}
@@ -554,7 +556,7 @@ func TestDiffData(t *testing.T) {
 	}
 
 	actualSyncCols := config.Sync.Columns
-	toInsert, toUpdate, toDelete := diffData(config, fileRecords, dbRecords, actualSyncCols)
+	toInsert, toUpdate, toDelete := diffData(context.Background(), config, fileRecords, dbRecords, actualSyncCols)
 
 	expectedInsert := []DataRecord{{"id": "4", "name": "test4", "value": "value4"}}
 	expectedUpdate := []UpdateOperation{
@@ -576,6 +578,20 @@ func TestDiffData(t *testing.T) {
 	}
 }
 
+func TestDiffDataWholeJSONNumberKeys(t *testing.T) {
+	config := createTestConfig()
+	config.Sync.DeleteNotInFile = true
+
+	// JSON keys written as 1.0 match the database key 1 instead of being inserted again and deleted
+	fileRecords := []DataRecord{{"id": json.Number("1.0"), "name": "test1", "value": "value1"}}
+	dbRecords := map[string]DataRecord{"1": {"id": "1", "name": "test1", "value": "value1"}}
+
+	toInsert, toUpdate, toDelete := diffData(context.Background(), config, fileRecords, dbRecords, config.Sync.Columns)
+	if len(toInsert) != 0 || len(toUpdate) != 0 || len(toDelete) != 0 {
+		t.Errorf("Expected no changes, got %d inserts, %d updates and %d deletes", len(toInsert), len(toUpdate), len(toDelete))
+	}
+}
+
 func TestDiffDataErrorCases(t *testing.T) {
 	t.Run("empty primary key returns empty results", func(t *testing.T) {
 		config := createTestConfig()
@@ -590,7 +606,7 @@ func TestDiffDataErrorCases(t *testing.T) {
 		}
 
 		actualSyncCols := config.Sync.Columns
-		toInsert, toUpdate, toDelete := diffData(config, fileRecords, dbRecords, actualSyncCols)
+		toInsert, toUpdate, toDelete := diffData(context.Background(), config, fileRecords, dbRecords, actualSyncCols)
 
 		// Should return empty slices when primary key is empty
 		if len(toInsert) != 0 {
@@ -624,7 +640,7 @@ func TestSyncDataEmptyFile(t *testing.T) {
 		// Empty file records
 		fileRecords := []DataRecord{}
 
-		err = syncData(t.Context(), db, config, fileRecords)
+		err = syncData(t.Context(), db, config, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("syncData failed: %v", err)
 		}
@@ -666,7 +682,7 @@ func TestSyncDataEmptyFile(t *testing.T) {
 		// Empty file records
 		fileRecords := []DataRecord{}
 
-		err = syncData(t.Context(), db, config, fileRecords)
+		err = syncData(t.Context(), db, config, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("syncData failed: %v", err)
 		}
@@ -707,7 +723,7 @@ func TestSyncDataEmptyFile(t *testing.T) {
 		// Empty file records
 		fileRecords := []DataRecord{}
 
-		err = syncData(t.Context(), db, config, fileRecords)
+		err = syncData(t.Context(), db, config, memoryRecords(fileRecords))
 		if err != nil {
 			t.Fatalf("syncData failed: %v", err)
 		}
@@ -757,7 +773,7 @@ func TestDryRunOverwriteMode(t *testing.T) {
 		{"id": "4", "name": "new4", "value": "new_value4"},
 	}
 
-	err = syncData(t.Context(), db, config, fileRecords)
+	err = syncData(t.Context(), db, config, memoryRecords(fileRecords))
 	if err != nil {
 		t.Fatalf("Failed to execute dry run: %v", err)
 	}
@@ -814,7 +830,7 @@ func TestDryRunDiffMode(t *testing.T) {
 		{"id": "2", "name": "old2", "value": "old_value2"},
 	}
 
-	err = syncData(t.Context(), db, config, fileRecords)
+	err = syncData(t.Context(), db, config, memoryRecords(fileRecords))
 	if err != nil {
 		t.Fatalf("Failed to execute dry run: %v", err)
 	}
@@ -909,6 +925,98 @@ func TestExecutionPlanString(t *testing.T) {
 	}
 }
 
+func TestExecutionPlanStringTimes(t *testing.T) {
+	tokyo := time.FixedZone("+09:00", 9*3600)
+	plan := &ExecutionPlan{
+		SyncMode:         SyncModeDiff,
+		TableName:        "orders",
+		InsertOperations: []DataRecord{{"id": "1", "ordered_at": time.Date(2024, 6, 17, 10, 0, 0, 0, tokyo)}},
+		UpdateOperations: []UpdateOperation{
+			{Before: DataRecord{"id": "2", "ordered_at": "2024-06-17 09:00:00", "shipped_at": "2024-06-18 00:00:00.500"},
+				After: DataRecord{"id": "2", "ordered_at": time.Date(2024, 6, 17, 9, 30, 0, 0, tokyo), "shipped_at": time.Date(2024, 6, 18, 0, 0, 0, 500000000, tokyo)}},
+		},
+		AffectedColumns: []string{"id", "ordered_at", "shipped_at"},
+	}
+
+	output := plan.String()
+	for _, want := range []string{
+		"   ordered_at: 2024-06-17 10:00:00\n",
+		"   ordered_at: 2024-06-17 09:00:00 -> 2024-06-17 09:30:00\n",
+		"   shipped_at: 2024-06-18 00:00:00.500 (unchanged)\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("ExecutionPlan.String() output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestExecutionPlanStringTransformedColumns(t *testing.T) {
+	plan := &ExecutionPlan{
+		SyncMode:           SyncModeDiff,
+		TableName:          "products",
+		InsertOperations:   []DataRecord{{"id": "1", "code": "AB-1"}},
+		UpdateOperations:   []UpdateOperation{{Before: DataRecord{"id": "2", "code": "ab-2"}, After: DataRecord{"id": "2", "code": "AB-2"}}},
+		AffectedColumns:    []string{"id", "code"},
+		TransformedColumns: []string{"code"},
+	}
+
+	output := plan.String()
+	for _, want := range []string{
+		"- Transformed Columns: [code] (values shown after transformation)",
+		"   id: 1\n",
+		"   code: AB-1 (transformed)\n",
+		"   code: ab-2 -> AB-2 (transformed)\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("ExecutionPlan.String() output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestValuesEqual(t *testing.T) {
+	tests := []struct {
+		name    string
+		fileVal any
+		dbVal   any
+		want    bool
+	}{
+		{"same string", "a", "a", true},
+		{"number and string", 1.0, "1", true},
+		{"different string", "a", "b", false},
+		{"JSON normalized by the database", JSONValue(`{"a":1,"bb":[1,2]}`), `{"a": 1, "bb": [1, 2]}`, true},
+		{"JSON with another value", JSONValue(`{"a":1}`), `{"a": 2}`, false},
+		{"JSON-looking text is compared as text", `{"a":1}`, `{"a": 1}`, false},
+		{"JSON and NULL", JSONValue(`{}`), nil, false},
+		{"19-digit ID", json.Number("9223372036854775807"), "9223372036854775807", true},
+		{"19-digit ID differing in the last digit", json.Number("9223372036854775807"), "9223372036854775806", false},
+		{"decimal padded by the database", json.Number("19.99"), "19.9900", true},
+		{"high-precision decimal", json.Number("0.123456789012345678"), "0.123456789012345679", false},
+		{"number and empty string", json.Number("0"), "", false},
+		{"datetime", time.Date(2024, 6, 17, 10, 0, 0, 0, time.UTC), "2024-06-17 10:00:00", true},
+		{"datetime with another time", time.Date(2024, 6, 17, 10, 0, 0, 0, time.UTC), "2024-06-17 10:00:01", false},
+		{"DATETIME(3) pads the fraction", time.Date(2024, 6, 17, 10, 0, 0, 500000000, time.UTC), "2024-06-17 10:00:00.500", true},
+		{"DATE column", time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC), "2024-06-17", true},
+		{"datetime and NULL", time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := valuesEqual(tt.fileVal, tt.dbVal); got != tt.want {
+				t.Errorf("valuesEqual(%v, %v) = %v, want %v", tt.fileVal, tt.dbVal, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutionPlanStringScope(t *testing.T) {
+	plan := &ExecutionPlan{SyncMode: SyncModeOverwrite, TableName: "sales", Scope: "region = 'JP'"}
+	if output := plan.String(); !strings.Contains(output, "- Scope: region = 'JP'\n") {
+		t.Errorf("ExecutionPlan.String() output missing scope:\n%s", output)
+	}
+	if output := (&ExecutionPlan{SyncMode: SyncModeOverwrite, TableName: "sales"}).String(); strings.Contains(output, "- Scope:") {
+		t.Errorf("ExecutionPlan.String() shows a scope for an unscoped sync:\n%s", output)
+	}
+}
+
 // TestDetermineActualSyncColumns should be a top-level function
 func TestPrimaryKey(t *testing.T) {
 	t.Run("NewPrimaryKey with different types", func(t *testing.T) {
@@ -923,6 +1031,10 @@ func TestPrimaryKey(t *testing.T) {
 			{"float value", 3.14, "3.14"},
 			{"bool true", true, "true"},
 			{"bool false", false, "false"},
+			{"whole JSON number", json.Number("1.0"), "1"},
+			{"negative whole JSON number", json.Number("-42.000"), "-42"},
+			{"fractional JSON number", json.Number("1.50"), "1.50"},
+			{"large JSON number", json.Number("12345678901234567890"), "12345678901234567890"},
 		}
 
 		for _, tt := range tests {
@@ -1024,6 +1136,8 @@ func TestConvertValueToString(t *testing.T) {
 			{"float32", float32(3.14), "3.14"},
 			{"float64", 3.14159, "3.14159"},
 			{"float64 whole number", 100.0, "100"},
+			{"float64 without exponent", 0.000001, "0.000001"},
+			{"json.Number", json.Number("12345678901234567890.1234"), "12345678901234567890.1234"},
 		}
 
 		for _, tt := range tests {
@@ -1039,7 +1153,7 @@ func TestConvertValueToString(t *testing.T) {
 	t.Run("time type", func(t *testing.T) {
 		testTime := time.Date(2023, 12, 25, 15, 30, 45, 0, time.UTC)
 		result := convertValueToString(testTime)
-		expected := "2023-12-25T15:30:45Z"
+		expected := "2023-12-25 15:30:45"
 		if result != expected {
 			t.Errorf("Expected %q, got %q", expected, result)
 		}
@@ -1579,3 +1693,99 @@ func TestCompareRecords(t *testing.T) {
 		}
 	})
 }
+
+func TestStatementAndTableContext(t *testing.T) {
+	t.Run("no statement timeout keeps the parent context", func(t *testing.T) {
+		parent := context.Background()
+		ctx, cancel := statementContext(parent, Config{})
+		defer cancel()
+		if ctx != parent {
+			t.Error("Expected the parent context to be returned")
+		}
+	})
+
+	t.Run("statement timeout sets a deadline", func(t *testing.T) {
+		ctx, cancel := statementContext(context.Background(), Config{StatementTimeout: time.Minute})
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > time.Minute {
+			t.Errorf("Expected a deadline within one minute, got %v (set: %v)", deadline, ok)
+		}
+	})
+
+	t.Run("table timeout sets a deadline", func(t *testing.T) {
+		ctx, cancel := tableContext(context.Background(), &TableSyncConfig{Timeout: time.Minute})
+		defer cancel()
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Expected a deadline")
+		}
+	})
+
+	t.Run("no table timeout sets no deadline but names the table", func(t *testing.T) {
+		ctx, cancel := tableContext(context.Background(), &TableSyncConfig{Name: "orders"})
+		defer cancel()
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("Expected no deadline")
+		}
+		if table, _ := ctx.Value(logTableKey{}).(string); table != "orders" {
+			t.Errorf("Expected log table 'orders', got %q", table)
+		}
+	})
+}
+
+func TestNewSingleTableConfig(t *testing.T) {
+	config := Config{
+		DB:               DBConfig{DSN: "user:pass@tcp(localhost:3306)/db"},
+		StatementTimeout: 30 * time.Second,
+	}
+	table := &TableSyncConfig{
+		Name:             "orders",
+		FilePath:         "orders.csv",
+		Columns:          []string{"id", "total"},
+		TimestampColumns: []string{"updated_at"},
+		ImmutableColumns: []string{"created_at"},
+		PrimaryKey:       "id",
+		SyncMode:         SyncModeDiff,
+		DeleteNotInFile:  true,
+		Validate:         []ValidationRule{{Column: "total", NotNull: true}},
+		SchemaCheck:      true,
+		Transform:        []TransformRule{{Column: "total", Steps: []TransformStep{{Trim: true}}}},
+		Constants:        map[string]string{"tenant_id": "42"},
+		Scope:            map[string]string{"region": "JP"},
+		Encoding:         "cp932",
+		CSV:              CSVOptions{Delimiter: ";"},
+		JSON:             JSONOptions{RecordsPath: "data.items"},
+
+		PrimaryKeyValidation: PrimaryKeyValidationConfig{Mode: PrimaryKeyModeWarn},
+	}
+
+	got := newSingleTableConfig(config, table, true)
+	want := Config{
+		DB:               config.DB,
+		DryRun:           true,
+		StatementTimeout: 30 * time.Second,
+		Sync: SyncConfig{
+			FilePath:         "orders.csv",
+			TableName:        "orders",
+			Columns:          []string{"id", "total"},
+			TimestampColumns: []string{"updated_at"},
+			ImmutableColumns: []string{"created_at"},
+			PrimaryKey:       "id",
+			SyncMode:         SyncModeDiff,
+			DeleteNotInFile:  true,
+			Validate:         []ValidationRule{{Column: "total", NotNull: true}},
+			SchemaCheck:      true,
+			Transform:        []TransformRule{{Column: "total", Steps: []TransformStep{{Trim: true}}}},
+			Constants:        map[string]string{"tenant_id": "42"},
+			Scope:            map[string]string{"region": "JP"},
+			Encoding:         "cp932",
+			CSV:              CSVOptions{Delimiter: ";"},
+			JSON:             JSONOptions{RecordsPath: "data.items"},
+
+			PrimaryKeyValidation: PrimaryKeyValidationConfig{Mode: PrimaryKeyModeWarn},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("newSingleTableConfig() mismatch (-want +got):\n%s", diff)
+	}
+}
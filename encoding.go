@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// fileEncodings maps the supported values of the encoding option to their decoders
+// An empty encoding detects a byte order mark: a UTF-8 BOM is stripped and UTF-16 is decoded.
+var fileEncodings = map[string]encoding.Encoding{
+	"utf-8":     unicode.UTF8,
+	"utf-8-bom": unicode.UTF8BOM,
+	"shift_jis": japanese.ShiftJIS,
+	"cp932":     japanese.ShiftJIS, // The ShiftJIS decoder includes the CP932 (Windows-31J) extensions
+	"euc-jp":    japanese.EUCJP,
+	"utf-16":    unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+}
+
+// validateEncoding checks that an encoding option names a supported encoding
+func validateEncoding(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := fileEncodings[strings.ToLower(name)]; !ok {
+		return fmt.Errorf("unsupported encoding '%s' (supported: %s)", name, strings.Join(slices.Sorted(maps.Keys(fileEncodings)), ", "))
+	}
+	return nil
+}
+
+// validateFileEncoding checks an encoding option for a file; Excel and Parquet files are binary and have none
+func validateFileEncoding(name, filePath string) error {
+	if err := validateEncoding(name); err != nil {
+		return err
+	}
+	if ext := strings.ToLower(filepath.Ext(filePath)); name != "" && (ext == ".xlsx" || ext == ".parquet") {
+		return fmt.Errorf("only applies to text files, not '%s'", ext)
+	}
+	return nil
+}
+
+// newDecoder returns the decoder for an encoding option, detecting a BOM if it is empty
+// The decoder fails on input that is invalid in the encoding instead of replacing it with U+FFFD.
+func newDecoder(name string) (transform.Transformer, error) {
+	if err := validateEncoding(name); err != nil {
+		return nil, err
+	}
+	decoder := &strictDecoder{encoding: name, line: 1}
+	switch strings.ToLower(name) {
+	case "":
+		decoder.Transformer = unicode.BOMOverride(unicode.UTF8.NewDecoder())
+		decoder.encoding = "UTF-8"
+		decoder.utf8, decoder.utf16 = true, true
+	case "utf-8", "utf-8-bom":
+		decoder.Transformer = fileEncodings[strings.ToLower(name)].NewDecoder()
+		decoder.utf8 = true
+	case "utf-16":
+		decoder.Transformer = fileEncodings["utf-16"].NewDecoder()
+		decoder.utf16 = true
+	default:
+		// Shift_JIS and EUC-JP cannot encode U+FFFD, so every one in the output replaces invalid input
+		decoder.Transformer = fileEncodings[strings.ToLower(name)].NewDecoder()
+	}
+	return decoder, nil
+}
+
+// replacementChar is U+FFFD in UTF-8, which decoders write for invalid input
+var replacementChar = []byte("\uFFFD")
+
+// DecodeError reports input that is invalid in the encoding of a file
+type DecodeError struct {
+	Encoding string
+	Line     int // Line of the file, counting from 1
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("line %d: invalid %s byte sequence", e.Line, e.Encoding)
+}
+
+// strictDecoder wraps a decoder and fails when it replaces invalid input with U+FFFD
+// A U+FFFD written in the file itself is decoded as usual: the decoded text may hold as many as
+// the consumed input encodes, which is never more than zero for Shift_JIS and EUC-JP.
+type strictDecoder struct {
+	transform.Transformer
+	encoding string
+	utf8     bool // The input may encode U+FFFD in UTF-8
+	utf16    bool // The input may encode U+FFFD in UTF-16
+	line     int  // Line of the decoded text reached so far
+}
+
+// Transform decodes src into dst, stopping at the first replacement for invalid input
+func (d *strictDecoder) Transform(dst, src []byte, atEOF bool) (int, int, error) {
+	nDst, nSrc, err := d.Transformer.Transform(dst, src, atEOF)
+	decoded := dst[:nDst]
+	if bytes.Count(decoded, replacementChar) > d.encodedReplacements(src[:nSrc]) {
+		// Only the text before the replacement is passed on, so its lines still load
+		i := bytes.Index(decoded, replacementChar)
+		d.line += bytes.Count(decoded[:i], []byte{'\n'})
+		return i, nSrc, &DecodeError{Encoding: d.encoding, Line: d.line}
+	}
+	d.line += bytes.Count(decoded, []byte{'\n'})
+	return nDst, nSrc, err
+}
+
+// Reset resets the decoder to decode a new input
+func (d *strictDecoder) Reset() {
+	d.Transformer.Reset()
+	d.line = 1
+}
+
+// encodedReplacements counts the U+FFFD characters encoded in input
+// Decoders only consume whole characters, so UTF-16 code units start at even offsets.
+func (d *strictDecoder) encodedReplacements(input []byte) int {
+	var n int
+	if d.utf8 {
+		n += bytes.Count(input, []byte("\xEF\xBF\xBD"))
+	}
+	if d.utf16 {
+		for i := 0; i+1 < len(input); i += 2 {
+			if unit := string(input[i : i+2]); unit == "\xFD\xFF" || unit == "\xFF\xFD" {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// decodedFile is an open file whose contents are decoded to UTF-8 while reading
+type decodedFile struct {
+	io.Reader
+	file *os.File
+}
+
+// Close closes the underlying file
+func (f *decodedFile) Close() error {
+	return f.file.Close()
+}
+
+// openDecoded opens a file for reading with its contents decoded from the given encoding to UTF-8
+func openDecoded(filePath, encodingName string) (io.ReadCloser, error) {
+	decoder, err := newDecoder(encodingName)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &decodedFile{Reader: transform.NewReader(file, decoder), file: file}, nil
+}
+
+// readDecoded reads a whole file decoded from the given encoding to UTF-8
+func readDecoded(filePath, encodingName string) ([]byte, error) {
+	file, err := openDecoded(filePath, encodingName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
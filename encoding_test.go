@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// encodeText encodes UTF-8 text in the given encoding
+func encodeText(t *testing.T, enc encoding.Encoding, text string) []byte {
+	t.Helper()
+	encoded, err := enc.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatalf("Failed to encode test data: %v", err)
+	}
+	return encoded
+}
+
+func TestCSVLoaderEncodings(t *testing.T) {
+	const content = "id,名前\n1,山田太郎\n2,髙橋①\n"
+	want := []DataRecord{{"id": "1", "名前": "山田太郎"}, {"id": "2", "名前": "髙橋①"}}
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+
+	tests := []struct {
+		name     string
+		encoding string
+		data     []byte
+	}{
+		{"plain UTF-8 without option", "", []byte(content)},
+		{"UTF-8 BOM detected without option", "", append([]byte("\xef\xbb\xbf"), content...)},
+		{"UTF-16 BOM detected without option", "", encodeText(t, utf16, content)},
+		{"utf-8", "utf-8", []byte(content)},
+		{"utf-8-bom", "utf-8-bom", append([]byte("\xef\xbb\xbf"), content...)},
+		{"cp932 with NEC and IBM extensions", "cp932", encodeText(t, japanese.ShiftJIS, content)},
+		{"shift_jis is case insensitive", "Shift_JIS", encodeText(t, japanese.ShiftJIS, content)},
+		{"euc-jp", "euc-jp", encodeText(t, japanese.EUCJP, "id,名前\n1,山田太郎\n2,髙橋①\n")},
+		{"utf-16", "utf-16", encodeText(t, utf16, content)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "users.csv")
+			if err := os.WriteFile(filePath, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			loader, err := GetLoaderWithOptions(filePath, LoaderOptions{Encoding: tt.encoding})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			records, err := loader.Load([]string{"id", "名前"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(records, want) {
+				t.Errorf("Load() = %v, want %v", records, want)
+			}
+		})
+	}
+}
+
+func TestJSONLoaderEncodings(t *testing.T) {
+	const content = `[{"id": 1, "name": "山田"}]`
+	want := []DataRecord{{"id": json.Number("1"), "name": "山田"}}
+
+	tests := []struct {
+		name     string
+		encoding string
+		data     []byte
+	}{
+		{"UTF-8 BOM detected without option", "", append([]byte("\xef\xbb\xbf"), content...)},
+		{"cp932", "cp932", encodeText(t, japanese.ShiftJIS, content)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "users.json")
+			if err := os.WriteFile(filePath, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			loader, err := GetLoaderWithOptions(filePath, LoaderOptions{Encoding: tt.encoding})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			records, err := loader.Load(nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(records, want) {
+				t.Errorf("Load() = %v, want %v", records, want)
+			}
+		})
+	}
+}
+
+func TestLoaderInvalidEncodedInput(t *testing.T) {
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	sjis := encodeText(t, japanese.ShiftJIS, "id,名前\n1,山田\n")
+
+	tests := []struct {
+		name          string
+		fileName      string
+		encoding      string
+		data          []byte
+		expectedError string
+	}{
+		{"invalid UTF-8 in CSV", "users.csv", "", []byte("id,name\n1,a\n2,\xff\n"), "line 3: invalid UTF-8 byte sequence"},
+		{"truncated UTF-8 at the end of the file", "users.csv", "utf-8", []byte("id,name\n1,\xe5\xb1"), "line 2: invalid utf-8 byte sequence"},
+		{"invalid Shift_JIS in CSV", "users.csv", "cp932", append(sjis, "2,\x81\n"...), "line 3: invalid cp932 byte sequence"},
+		{"invalid UTF-8 in NDJSON", "users.ndjson", "", []byte("{\"id\": 1}\n{\"id\": \"\xc3\"}\n"), "error reading NDJSON file '"},
+		{"invalid UTF-8 in JSON", "users.json", "", []byte("[\n{\"id\": \"\xc3\"}]"), "line 2: invalid UTF-8 byte sequence"},
+		{"unpaired surrogate in UTF-16", "users.csv", "utf-16", append(encodeText(t, utf16, "id,name\n1,"), 0x00, 0xd8), "line 2: invalid utf-16 byte sequence"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(filePath, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			loader, err := GetLoaderWithOptions(filePath, LoaderOptions{Encoding: tt.encoding})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			_, err = loader.Load(nil)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Load() error = %v, want error containing %q", err, tt.expectedError)
+			}
+		})
+	}
+
+	t.Run("U+FFFD in the file is loaded", func(t *testing.T) {
+		for _, data := range [][]byte{[]byte("id,name\n1,a\uFFFDb\n"), encodeText(t, utf16, "id,name\n1,a\uFFFDb\n")} {
+			filePath := filepath.Join(t.TempDir(), "users.csv")
+			if err := os.WriteFile(filePath, data, 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			records, err := NewCSVLoader(filePath).Load(nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if want := []DataRecord{{"id": "1", "name": "a\uFFFDb"}}; !reflect.DeepEqual(records, want) {
+				t.Errorf("Load() = %v, want %v", records, want)
+			}
+		}
+	})
+}
+
+func TestValidateEncoding(t *testing.T) {
+	for _, name := range []string{"", "utf-8", "utf-8-bom", "shift_jis", "cp932", "euc-jp", "utf-16", "CP932"} {
+		if err := validateEncoding(name); err != nil {
+			t.Errorf("validateEncoding(%q) returned error: %v", name, err)
+		}
+	}
+
+	err := validateEncoding("latin1")
+	want := "unsupported encoding 'latin1' (supported: cp932, euc-jp, shift_jis, utf-16, utf-8, utf-8-bom)"
+	if err == nil || err.Error() != want {
+		t.Errorf("validateEncoding(latin1) = %v, want %q", err, want)
+	}
+
+	loader := &CSVLoader{Delimiter: ',', FilePath: "users.csv", Encoding: "latin1"}
+	if _, err := loader.Load(nil); err == nil || !strings.Contains(err.Error(), "unsupported encoding") {
+		t.Errorf("Expected unsupported encoding error, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// interruptContext returns a context that is cancelled when the process receives SIGINT or SIGTERM
+// The cancellation cause names the signal. After the first signal the default handling is restored,
+// so a second Ctrl-C terminates the process immediately.
+func interruptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			cancel(fmt.Errorf("received signal %s", sig))
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel(context.Canceled)
+	}
+}
+
+// resolveTimeout returns the overall run timeout: the command line wins over the config file,
+// which wins over DefaultTimeout
+func resolveTimeout(flagTimeout time.Duration, config Config) time.Duration {
+	if flagTimeout > 0 {
+		return flagTimeout
+	}
+	if config.Timeout > 0 {
+		return config.Timeout
+	}
+	return DefaultTimeout
+}
+
+// interruptedError describes a run stopped by a signal or timeout while in the tracked phase,
+// and what became of the transaction: it only claims a rollback the tracker has seen happen
+// It returns err unchanged if ctx was not cancelled.
+func interruptedError(ctx context.Context, tracker *phaseTracker, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+	var outcome string
+	switch tracker.TxState() {
+	case txNone:
+		outcome = "no changes were made"
+	case txOpen:
+		outcome = "the transaction was not committed"
+	case txCommitting:
+		outcome = "the commit may or may not have been applied; check the target tables"
+	case txCommitted:
+		outcome = "the changes were committed"
+	case txRolledBack:
+		outcome = "all changes were rolled back"
+	}
+	return fmt.Errorf("synchronization interrupted during %s (%v); %s: %w",
+		tracker.Current(), context.Cause(ctx), outcome, err)
+}
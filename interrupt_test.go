@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInterruptContext(t *testing.T) {
+	t.Run("SIGTERM cancels the context with the signal as cause", func(t *testing.T) {
+		ctx, stop := interruptContext(context.Background())
+		defer stop()
+
+		if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+			t.Fatalf("Failed to send SIGTERM: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("Context was not cancelled after SIGTERM")
+		}
+		if cause := context.Cause(ctx); cause == nil || !strings.Contains(cause.Error(), "received signal terminated") {
+			t.Errorf("Expected signal cause, got %v", cause)
+		}
+	})
+
+	t.Run("stop cancels without a signal", func(t *testing.T) {
+		ctx, stop := interruptContext(context.Background())
+		stop()
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", ctx.Err())
+		}
+	})
+}
+
+func TestResolveTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		flagTimeout   time.Duration
+		configTimeout time.Duration
+		want          time.Duration
+	}{
+		{name: "default", want: DefaultTimeout},
+		{name: "config", configTimeout: time.Hour, want: time.Hour},
+		{name: "flag overrides config", flagTimeout: 2 * time.Hour, configTimeout: time.Hour, want: 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveTimeout(tt.flagTimeout, Config{Timeout: tt.configTimeout})
+			if got != tt.want {
+				t.Errorf("resolveTimeout() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterruptedError(t *testing.T) {
+	syncErr := errors.New("sync process error")
+
+	t.Run("active context returns the error unchanged", func(t *testing.T) {
+		ctx, tracker := withPhaseTracker(context.Background())
+		if got := interruptedError(ctx, tracker, syncErr); got != syncErr {
+			t.Errorf("Expected the original error, got %v", got)
+		}
+	})
+
+	t.Run("cancelled context reports phase and cause", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		ctx, tracker := withPhaseTracker(ctx)
+		setPhase(ctx, "delete phase of table 'orders'")
+		setTxState(ctx, txRolledBack)
+		cancel(errors.New("received signal interrupt"))
+
+		err := interruptedError(ctx, tracker, syncErr)
+		want := "synchronization interrupted during delete phase of table 'orders' (received signal interrupt); all changes were rolled back"
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+		if !errors.Is(err, syncErr) {
+			t.Error("Expected the original error to be wrapped")
+		}
+	})
+
+	t.Run("timeout reports the timeout cause", func(t *testing.T) {
+		ctx, cancel := context.WithTimeoutCause(context.Background(), time.Nanosecond, errors.New("timeout of 1ns exceeded"))
+		defer cancel()
+		ctx, tracker := withPhaseTracker(ctx)
+		<-ctx.Done()
+
+		err := interruptedError(ctx, tracker, syncErr)
+		if !strings.Contains(err.Error(), "during starting (timeout of 1ns exceeded); no changes were made") {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("outcome depends on the transaction state", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			phase string
+			state txState
+			want  string
+		}{
+			{"before any transaction", "loading files", txNone, "during loading files (interrupted); no changes were made"},
+			{"rollback not seen", "delete phase of table 'orders'", txOpen, "; the transaction was not committed"},
+			{"commit in progress", "commit", txCommitting,
+				"during commit (interrupted); the commit may or may not have been applied; check the target tables"},
+			{"committed", "commit of table 'orders'", txCommitted, "; the changes were committed"},
+			{"rolled back", "commit", txRolledBack, "; all changes were rolled back"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctx, cancel := context.WithCancelCause(context.Background())
+				ctx, tracker := withPhaseTracker(ctx)
+				setPhase(ctx, tt.phase)
+				setTxState(ctx, tt.state)
+				cancel(errors.New("interrupted"))
+
+				err := interruptedError(ctx, tracker, syncErr)
+				if !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("Expected error containing %q, got %v", tt.want, err)
+				}
+				if tt.state != txRolledBack && strings.Contains(err.Error(), "rolled back") {
+					t.Errorf("Unexpected rollback claim: %v", err)
+				}
+			})
+		}
+	})
+}
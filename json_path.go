@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONValue is a JSON object or array loaded from a file, serialized for a JSON column
+// It is compared with the database by content, so key order and spacing do not cause updates.
+type JSONValue string
+
+// jsonPathSegment is one step of a JSON path: an object key or an array index
+type jsonPathSegment struct {
+	key   string
+	index int // Used when key is empty
+}
+
+// jsonPath locates a value in nested JSON, e.g. "data.items", "$.price.amount" or "$['a.b'].tags[0]"
+type jsonPath []jsonPathSegment
+
+// parseJSONPath parses a dotted path or a simple JSONPath with keys, quoted keys and array indexes
+// Wildcards, filters and recursive descent are not supported.
+func parseJSONPath(path string) (jsonPath, error) {
+	s := path
+	if s == "$" || strings.HasPrefix(s, "$.") || strings.HasPrefix(s, "$[") {
+		s = s[1:]
+	} else if s == "" {
+		return nil, fmt.Errorf("path is empty")
+	}
+
+	var segments jsonPath
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path '%s': missing ']'", path)
+			}
+			inner := s[i+1 : i+end]
+			i += end + 1
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, jsonPathSegment{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path '%s': '[%s]' is neither an array index nor a quoted key", path, inner)
+			}
+			segments = append(segments, jsonPathSegment{index: index})
+		default:
+			if s[i] == '.' {
+				if i == 0 && path[0] != '$' {
+					return nil, fmt.Errorf("invalid path '%s': empty key", path)
+				}
+				i++
+			}
+			end := strings.IndexAny(s[i:], ".[")
+			if end < 0 {
+				end = len(s) - i
+			}
+			key := s[i : i+end]
+			if key == "" || key == "*" {
+				return nil, fmt.Errorf("invalid path '%s': empty keys and wildcards are not supported", path)
+			}
+			segments = append(segments, jsonPathSegment{key: key})
+			i += end
+		}
+	}
+	return segments, nil
+}
+
+// lookup returns the value at the path and whether it exists
+func (p jsonPath) lookup(value any) (any, bool) {
+	for _, segment := range p {
+		switch v := value.(type) {
+		case map[string]any:
+			if segment.key == "" {
+				return nil, false
+			}
+			if value = v[segment.key]; value == nil {
+				if _, exists := v[segment.key]; !exists {
+					return nil, false
+				}
+			}
+		case []any:
+			if segment.key != "" || segment.index >= len(v) {
+				return nil, false
+			}
+			value = v[segment.index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// String formats the path in dotted notation, e.g. data.items[0].name
+func (p jsonPath) String() string {
+	var b strings.Builder
+	for _, segment := range p {
+		switch {
+		case segment.key == "":
+			fmt.Fprintf(&b, "[%d]", segment.index)
+		case strings.ContainsAny(segment.key, ".[]"):
+			fmt.Fprintf(&b, "['%s']", segment.key)
+		default:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(segment.key)
+		}
+	}
+	if b.Len() == 0 {
+		return "$"
+	}
+	return b.String()
+}
+
+// parseJSONSources parses the source path of each column
+func parseJSONSources(sources map[string]string) (map[string]jsonPath, error) {
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	paths := make(map[string]jsonPath, len(sources))
+	for column, source := range sources {
+		path, err := parseJSONPath(source)
+		if err != nil {
+			return nil, fmt.Errorf("source of column '%s': %w", column, err)
+		}
+		paths[column] = path
+	}
+	return paths, nil
+}
+
+// jsonFileValue converts a value loaded from a JSON file; objects and arrays become JSONValue
+func jsonFileValue(val any) (any, error) {
+	switch val.(type) {
+	case map[string]any, []any:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(val); err != nil {
+			return nil, err
+		}
+		return JSONValue(strings.TrimSuffix(buf.String(), "\n")), nil
+	}
+	return convertValue(val), nil
+}
+
+// decodeJSON unmarshals a JSON document like json.Unmarshal, but decodes numbers as json.Number
+// so that large IDs and DECIMAL amounts keep every digit
+func decodeJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid data after top-level JSON value at offset %d", decoder.InputOffset())
+	}
+	return nil
+}
+
+// maxJSONExponent bounds the exponents that jsonNumberValue expands; larger ones exceed any MySQL numeric type
+const maxJSONExponent = 400
+
+// jsonNumberValue returns a JSON number as plain decimal text, expanding an exponent such as 1.5e3 into 1500
+// Numbers without an exponent are returned unchanged, with all their digits.
+func jsonNumberValue(number json.Number) json.Number {
+	text := number.String()
+	e := strings.IndexAny(text, "eE")
+	if e < 0 {
+		return number
+	}
+	exponent, err := strconv.Atoi(text[e+1:])
+	if err != nil || exponent < -maxJSONExponent || exponent > maxJSONExponent {
+		return number
+	}
+	value, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return number
+	}
+	// Digits after the decimal point that the exact value needs
+	scale := 0
+	if dot := strings.IndexByte(text[:e], '.'); dot >= 0 {
+		scale = e - dot - 1
+	}
+	return json.Number(value.FloatString(max(scale-exponent, 0)))
+}
+
+// decimalEqual reports whether two decimal texts hold the same number, e.g. "19.99" and "19.9900"
+func decimalEqual(a, b string) bool {
+	ra, okA := new(big.Rat).SetString(strings.TrimSpace(a))
+	rb, okB := new(big.Rat).SetString(strings.TrimSpace(b))
+	return okA && okB && ra.Cmp(rb) == 0
+}
+
+// jsonEqual reports whether two JSON texts hold the same value
+func jsonEqual(a, b string) bool {
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
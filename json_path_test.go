@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	document := map[string]any{
+		"data": map[string]any{
+			"items": []any{map[string]any{"name": "a", "tags": []any{"x", "y"}}},
+			"a.b":   "dotted",
+			"empty": nil,
+		},
+	}
+
+	tests := []struct {
+		path      string
+		want      any
+		wantFound bool
+		formatted string
+	}{
+		{"data.items[0].name", "a", true, "data.items[0].name"},
+		{"$.data.items[0].tags[1]", "y", true, "data.items[0].tags[1]"},
+		{"$['data']['a.b']", "dotted", true, "data['a.b']"},
+		{`$["data"].empty`, nil, true, "data.empty"},
+		{"$", document, true, "$"},
+		{"data.missing", nil, false, "data.missing"},
+		{"data.items[1]", nil, false, "data.items[1]"},
+		{"data.items.name", nil, false, "data.items.name"},
+		{"data[0]", nil, false, "data[0]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := parseJSONPath(tt.path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, found := path.lookup(document)
+			if found != tt.wantFound {
+				t.Fatalf("lookup() found = %v, want %v", found, tt.wantFound)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("lookup() mismatch (-want +got):\n%s", diff)
+			}
+			if path.String() != tt.formatted {
+				t.Errorf("String() = %q, want %q", path.String(), tt.formatted)
+			}
+		})
+	}
+}
+
+func TestParseJSONPathErrors(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"", "path is empty"},
+		{".data", "empty key"},
+		{"data..items", "empty keys and wildcards are not supported"},
+		{"data.*", "empty keys and wildcards are not supported"},
+		{"data[0", "missing ']'"},
+		{"data[-1]", "is neither an array index nor a quoted key"},
+		{"data[?(@.x)]", "is neither an array index nor a quoted key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := parseJSONPath(tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseJSONPath(%q) error = %v, want error containing %q", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJSONFileValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{"object is serialized with sorted keys", map[string]any{"b": 1.0, "a": "<x>"}, JSONValue(`{"a":"<x>","b":1}`)},
+		{"array", []any{"x", 2.5, nil}, JSONValue(`["x",2.5,null]`)},
+		{"empty array", []any{}, JSONValue(`[]`)},
+		{"scalar", "text", "text"},
+		{"null", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonFileValue(tt.value)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("jsonFileValue() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJSONEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`{"a":1,"bb":[1,2]}`, `{"bb": [1, 2], "a": 1}`, true},
+		{`[1,2]`, `[2,1]`, false},
+		{`{"a":1}`, `{"a":2}`, false},
+		{`{"a":1}`, `not json`, false},
+	}
+	for _, tt := range tests {
+		if got := jsonEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("jsonEqual(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var got []map[string]any
+	if err := decodeJSON([]byte(`[{"id": 1234567890123456789, "price": 12345678901234.5678}]`), &got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []map[string]any{{"id": json.Number("1234567890123456789"), "price": json.Number("12345678901234.5678")}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("decodeJSON() mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := decodeJSON([]byte(`[] []`), &got); err == nil || !strings.Contains(err.Error(), "invalid data after top-level JSON value") {
+		t.Errorf("decodeJSON() error = %v, want error about trailing data", err)
+	}
+}
+
+func TestJSONNumberValue(t *testing.T) {
+	tests := []struct {
+		number json.Number
+		want   json.Number
+	}{
+		{"1234567890123456789", "1234567890123456789"},
+		{"19.9900", "19.9900"},
+		{"-42.5", "-42.5"},
+		{"1.5e3", "1500"},
+		{"1E+2", "100"},
+		{"12.345e-2", "0.12345"},
+		{"1e-7", "0.0000001"},
+		{"1e999", "1e999"},
+	}
+	for _, tt := range tests {
+		if got := jsonNumberValue(tt.number); got != tt.want {
+			t.Errorf("jsonNumberValue(%s) = %s, want %s", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"19.99", "19.9900", true},
+		{"100", "100.00", true},
+		{"9223372036854775807", "9223372036854775806", false},
+		{"0.1", "0.10000000000000001", false},
+		{"1", "", false},
+		{"1", "abc", false},
+	}
+	for _, tt := range tests {
+		if got := decimalEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("decimalEqual(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -1,17 +1,22 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // DataRecord represents one record of data loaded from file
@@ -38,6 +43,11 @@ func convertValue(val any) any {
 		return str
 	}
 
+	// JSON numbers keep their exact decimal text instead of being rounded to float64
+	if number, ok := val.(json.Number); ok {
+		return jsonNumberValue(number)
+	}
+
 	// Return other types as-is
 	return val
 }
@@ -49,19 +59,33 @@ type Loader interface {
 	Load(columns []string) ([]DataRecord, error)
 }
 
+// BatchLoader is a Loader that can also read its file in batches, without holding every record in memory
+type BatchLoader interface {
+	Loader
+	// LoadBatches calls fn with the records of the file in order, one batch at a time
+	// An error returned by fn stops the loading and is returned as is.
+	LoadBatches(columns []string, fn func(batch []DataRecord) error) error
+}
+
 // CSVLoader loads data from CSV files
 type CSVLoader struct {
-	Delimiter rune // CSV delimiter character
-	// HasHeader bool   // Whether file has a header row - For CSV, we now always assume a header
-	FilePath string // Path to file to be loaded
+	Delimiter        rune   // CSV delimiter character
+	Quote            rune   // Quote character; '"' unless set to another ASCII character
+	LazyQuotes       bool   // Allow quotes in unquoted fields and unescaped quotes in quoted fields
+	TrimLeadingSpace bool   // Ignore leading white space in fields
+	Comment          rune   // Lines starting with this character are skipped; 0 disables comments
+	SkipLines        int    // Number of lines skipped at the start of the file, before the header
+	NoHeader         bool   // The file has no header row; its fields map to the columns by position
+	FilePath         string // Path to file to be loaded
+	Encoding         string // Character encoding of the file; empty detects a byte order mark
 }
 
 // NewCSVLoader creates a new CSV loader instance
 func NewCSVLoader(filePath string) *CSVLoader {
 	return &CSVLoader{
 		Delimiter: ',', // Default is comma delimiter
-		// HasHeader: true, // Default is no header - Now always true for CSV
-		FilePath: filePath,
+		Quote:     '"',
+		FilePath:  filePath,
 	}
 }
 
@@ -70,34 +94,77 @@ func (l *CSVLoader) WithDelimiter(delimiter rune) {
 	l.Delimiter = delimiter
 }
 
+// WithOptions applies the configured dialect to the CSV loader; unset options keep their defaults
+func (l *CSVLoader) WithOptions(opts CSVOptions) {
+	if opts.Delimiter != "" {
+		l.Delimiter, _ = utf8.DecodeRuneInString(opts.Delimiter)
+	}
+	if opts.Quote != "" {
+		l.Quote = rune(opts.Quote[0])
+	}
+	if opts.Comment != "" {
+		l.Comment, _ = utf8.DecodeRuneInString(opts.Comment)
+	}
+	l.LazyQuotes = opts.LazyQuotes
+	l.TrimLeadingSpace = opts.TrimLeadingSpace
+	l.SkipLines = opts.SkipLines
+	if opts.Header != nil {
+		l.NoHeader = !*opts.Header
+	}
+}
+
 // Load loads data from CSV file.
 // If 'columns' is specified, only those columns will be included in the result.
 // If 'columns' is empty, all columns from the CSV header will be included.
+// Files without a header row map their fields to 'columns' by position.
 func (l *CSVLoader) Load(columns []string) ([]DataRecord, error) {
-	file, err := os.Open(l.FilePath)
+	if l.NoHeader && len(columns) == 0 {
+		return nil, fmt.Errorf("CSV file '%s' has no header row, so columns must be specified", l.FilePath)
+	}
+
+	file, err := openDecoded(l.FilePath, l.Encoding)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open file '%s': %w", l.FilePath, err)
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	reader.Comma = l.Delimiter
-
-	// Read header row
-	headerNames, err := reader.Read()
-	if err != nil {
-		if errors.Is(err, csv.ErrFieldCount) || errors.Is(err, io.EOF) { // Check for empty file or just header
-			return nil, fmt.Errorf("CSV file '%s' must contain a header row and at least one data row: %w", l.FilePath, err)
+	input := bufio.NewReader(file)
+	for range l.SkipLines {
+		if _, err := input.ReadString('\n'); err != nil {
+			break // Fewer lines than skipLines; the header check below reports the empty file
 		}
-		return nil, fmt.Errorf("error reading header row from CSV file '%s': %w", l.FilePath, err)
 	}
-	if len(headerNames) == 0 {
-		return nil, fmt.Errorf("CSV file '%s' header row is empty", l.FilePath)
+
+	// encoding/csv only quotes with '"', so another quote character is swapped with it while parsing
+	quotes := quoteSwap{quote: l.Quote}
+	reader := csv.NewReader(quotes.reader(input))
+	reader.Comma = quotes.swap(l.Delimiter)
+	reader.Comment = quotes.swap(l.Comment)
+	reader.LazyQuotes = l.LazyQuotes
+	reader.TrimLeadingSpace = l.TrimLeadingSpace
+
+	var headerNames []string
+	if !l.NoHeader {
+		// Read header row
+		headerNames, err = reader.Read()
+		if err != nil {
+			if errors.Is(err, csv.ErrFieldCount) || errors.Is(err, io.EOF) { // Check for empty file or just header
+				return nil, fmt.Errorf("CSV file '%s' must contain a header row and at least one data row: %w", l.FilePath, err)
+			}
+			return nil, fmt.Errorf("error reading header row from CSV file '%s': %w", l.FilePath, l.fileLineError(err))
+		}
+		if len(headerNames) == 0 {
+			return nil, fmt.Errorf("CSV file '%s' header row is empty", l.FilePath)
+		}
+		quotes.restore(headerNames)
+	} else {
+		headerNames = columns
+		reader.FieldsPerRecord = len(columns)
 	}
 
 	csvRows, err := reader.ReadAll()
 	if err != nil {
-		return nil, fmt.Errorf("error reading CSV data rows from '%s': %w", l.FilePath, err)
+		return nil, fmt.Errorf("error reading CSV data rows from '%s': %w", l.FilePath, l.fileLineError(err))
 	}
 
 	// Determine which columns to include in the result
@@ -120,6 +187,7 @@ func (l *CSVLoader) Load(columns []string) ([]DataRecord, error) {
 		if len(row) != len(headerNames) {
 			return nil, fmt.Errorf("CSV file '%s', line %d: column count (%d) does not match header column count (%d)", l.FilePath, i+2, len(row), len(headerNames))
 		}
+		quotes.restore(row)
 		record := make(DataRecord)
 		// Create a map of all data from the row
 		allData := make(map[string]any)
@@ -135,9 +203,84 @@ func (l *CSVLoader) Load(columns []string) ([]DataRecord, error) {
 	return records, nil
 }
 
+// fileLineError corrects the line numbers of a CSV parse error for the skipped leading lines
+func (l *CSVLoader) fileLineError(err error) error {
+	var parseErr *csv.ParseError
+	if l.SkipLines > 0 && errors.As(err, &parseErr) {
+		parseErr.StartLine += l.SkipLines
+		parseErr.Line += l.SkipLines
+	}
+	return err
+}
+
+// quoteSwap exchanges a custom ASCII quote character with '"' so that encoding/csv can parse the file
+// The swap is applied to the input and undone on the parsed fields, so a literal '"' is kept as-is.
+type quoteSwap struct {
+	quote rune
+}
+
+// active reports whether the quote character differs from '"'
+func (q quoteSwap) active() bool {
+	return q.quote != 0 && q.quote != '"'
+}
+
+// swap exchanges the quote character and '"' in a single character
+func (q quoteSwap) swap(r rune) rune {
+	switch {
+	case !q.active():
+		return r
+	case r == q.quote:
+		return '"'
+	case r == '"':
+		return q.quote
+	}
+	return r
+}
+
+// reader returns the input with the quote character and '"' exchanged
+func (q quoteSwap) reader(input io.Reader) io.Reader {
+	if !q.active() {
+		return input
+	}
+	return &quoteSwapReader{Reader: input, a: byte(q.quote), b: '"'}
+}
+
+// restore undoes the swap in parsed fields
+func (q quoteSwap) restore(fields []string) {
+	if !q.active() {
+		return
+	}
+	for i, field := range fields {
+		fields[i] = strings.Map(q.swap, field)
+	}
+}
+
+// quoteSwapReader exchanges two ASCII bytes while reading; they never occur inside multi-byte UTF-8 characters
+type quoteSwapReader struct {
+	io.Reader
+	a, b byte
+}
+
+// Read reads from the underlying reader and exchanges the two bytes
+func (r *quoteSwapReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	for i, c := range p[:n] {
+		switch c {
+		case r.a:
+			p[i] = r.b
+		case r.b:
+			p[i] = r.a
+		}
+	}
+	return n, err
+}
+
 // JSONLoader loads data from JSON files
 type JSONLoader struct {
-	FilePath string // Path to file to be loaded
+	FilePath    string            // Path to file to be loaded
+	Encoding    string            // Character encoding of the file; empty detects a byte order mark
+	RecordsPath string            // Path of the array of records in a wrapped payload; empty for a top-level array
+	Sources     map[string]string // Path of each column's value within a record, for nested values
 }
 
 // NewJSONLoader creates a new JSON loader instance
@@ -148,10 +291,15 @@ func NewJSONLoader(filePath string) *JSONLoader {
 }
 
 // Load loads data from JSON file.
-// It expects an array of objects. If 'columns' is empty, it auto-detects all keys from the first object.
-// If 'columns' is specified, it filters to only those columns.
+// It expects an array of objects, at the top level or at RecordsPath. If 'columns' is empty, it auto-detects
+// all keys from the first object. If 'columns' is specified, it filters to only those columns.
 func (l *JSONLoader) Load(columns []string) ([]DataRecord, error) {
-	fileData, err := os.ReadFile(l.FilePath)
+	sources, err := parseJSONSources(l.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("JSON file '%s': %w", l.FilePath, err)
+	}
+
+	fileData, err := readDecoded(l.FilePath, l.Encoding)
 	if err != nil {
 		return nil, fmt.Errorf("cannot read JSON file '%s': %w", l.FilePath, err)
 	}
@@ -161,7 +309,11 @@ func (l *JSONLoader) Load(columns []string) ([]DataRecord, error) {
 	}
 
 	var jsonData []map[string]any
-	err = json.Unmarshal(fileData, &jsonData)
+	if l.RecordsPath == "" {
+		err = decodeJSON(fileData, &jsonData)
+	} else {
+		jsonData, err = l.wrappedRecords(fileData)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshalling JSON data from '%s': %w", l.FilePath, err)
 	}
@@ -171,45 +323,242 @@ func (l *JSONLoader) Load(columns []string) ([]DataRecord, error) {
 	}
 
 	// Auto-detect columns from the first JSON object if not specified
-	var actualColumns []string
-	if len(columns) == 0 {
-		// Get all keys from the first object
-		for key := range jsonData[0] {
-			actualColumns = append(actualColumns, key)
-		}
-		// Sort for consistent ordering
-		sort.Strings(actualColumns)
-	} else {
-		actualColumns = columns
-	}
+	actualColumns := jsonColumns(columns, jsonData[0], sources)
 
 	records := make([]DataRecord, 0, len(jsonData))
 	for i, jsonObj := range jsonData {
-		record := make(DataRecord)
-		for _, colName := range actualColumns {
-			val, ok := jsonObj[colName]
-			if !ok {
-				return nil, fmt.Errorf("JSON file '%s', record %d: missing required key '%s'", l.FilePath, i, colName)
-			}
-			record[colName] = convertValue(val)
+		record, err := jsonRecord(jsonObj, actualColumns, sources)
+		if err != nil {
+			return nil, fmt.Errorf("JSON file '%s', record %d: %w", l.FilePath, i, err)
 		}
 		records = append(records, record)
 	}
 	return records, nil
 }
 
+// wrappedRecords returns the array of records at RecordsPath, e.g. data.items of {"data": {"items": [...]}}
+func (l *JSONLoader) wrappedRecords(fileData []byte) ([]map[string]any, error) {
+	path, err := parseJSONPath(l.RecordsPath)
+	if err != nil {
+		return nil, fmt.Errorf("recordsPath: %w", err)
+	}
+	var document any
+	if err := decodeJSON(fileData, &document); err != nil {
+		return nil, err
+	}
+	value, ok := path.lookup(document)
+	if !ok {
+		return nil, fmt.Errorf("recordsPath '%s' not found", l.RecordsPath)
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("recordsPath '%s' is not an array", l.RecordsPath)
+	}
+	records := make([]map[string]any, 0, len(items))
+	for i, item := range items {
+		record, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("record %d at recordsPath '%s' is not an object", i, l.RecordsPath)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// jsonColumns returns the columns to load from JSON objects: the specified columns, or all keys
+// of the first object and all columns with a source path in sorted order if none are specified
+func jsonColumns(columns []string, first map[string]any, sources map[string]jsonPath) []string {
+	if len(columns) > 0 {
+		return columns
+	}
+	actualColumns := make([]string, 0, len(first)+len(sources))
+	for key := range first {
+		actualColumns = append(actualColumns, key)
+	}
+	for column := range sources {
+		if _, exists := first[column]; !exists {
+			actualColumns = append(actualColumns, column)
+		}
+	}
+	// Sort for consistent ordering
+	sort.Strings(actualColumns)
+	return actualColumns
+}
+
+// jsonRecord converts a JSON object into a record with the given columns, all of which are required
+// Columns with a source path take their value from that path instead of the key of the same name.
+func jsonRecord(jsonObj map[string]any, columns []string, sources map[string]jsonPath) (DataRecord, error) {
+	record := make(DataRecord, len(columns))
+	for _, colName := range columns {
+		var val any
+		if path, ok := sources[colName]; ok {
+			if val, ok = path.lookup(jsonObj); !ok {
+				return nil, fmt.Errorf("missing value at '%s' for column '%s'", path, colName)
+			}
+		} else {
+			var ok bool
+			if val, ok = jsonObj[colName]; !ok {
+				return nil, fmt.Errorf("missing required key '%s'", colName)
+			}
+		}
+		value, err := jsonFileValue(val)
+		if err != nil {
+			return nil, fmt.Errorf("column '%s': %w", colName, err)
+		}
+		record[colName] = value
+	}
+	return record, nil
+}
+
+// NDJSONLoader loads data from newline-delimited JSON (JSON Lines) files
+type NDJSONLoader struct {
+	FilePath string            // Path to file to be loaded
+	Encoding string            // Character encoding of the file; empty detects a byte order mark
+	Sources  map[string]string // Path of each column's value within a record, for nested values
+}
+
+// NewNDJSONLoader creates a new NDJSON loader instance
+func NewNDJSONLoader(filePath string) *NDJSONLoader {
+	return &NDJSONLoader{
+		FilePath: filePath,
+	}
+}
+
+// Load loads data from an NDJSON file, reading one JSON object per line; blank lines are skipped.
+// Like an empty JSON array, a file with only blank lines loads no records, but an empty file is an error.
+// If 'columns' is empty, it auto-detects all keys from the first object.
+// If 'columns' is specified, it filters to only those columns.
+func (l *NDJSONLoader) Load(columns []string) ([]DataRecord, error) {
+	sources, err := parseJSONSources(l.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("NDJSON file '%s': %w", l.FilePath, err)
+	}
+
+	file, err := openDecoded(l.FilePath, l.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file '%s': %w", l.FilePath, err)
+	}
+	defer file.Close()
+
+	// bufio.Reader instead of bufio.Scanner, so lines are not limited in length
+	reader := bufio.NewReader(file)
+	var records []DataRecord
+	var actualColumns []string
+	for lineNumber := 1; ; lineNumber++ {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			var decodeErr *DecodeError
+			if errors.As(readErr, &decodeErr) { // Already names the line
+				return nil, fmt.Errorf("error reading NDJSON file '%s': %w", l.FilePath, readErr)
+			}
+			return nil, fmt.Errorf("error reading NDJSON file '%s', line %d: %w", l.FilePath, lineNumber, readErr)
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var jsonObj map[string]any
+			if err := decodeJSON(trimmed, &jsonObj); err != nil {
+				return nil, fmt.Errorf("NDJSON file '%s', line %d: %w", l.FilePath, lineNumber, err)
+			}
+			if jsonObj == nil {
+				return nil, fmt.Errorf("NDJSON file '%s', line %d: expected a JSON object, got null", l.FilePath, lineNumber)
+			}
+			if actualColumns == nil {
+				actualColumns = jsonColumns(columns, jsonObj, sources)
+			}
+			record, err := jsonRecord(jsonObj, actualColumns, sources)
+			if err != nil {
+				return nil, fmt.Errorf("NDJSON file '%s', line %d: %w", l.FilePath, lineNumber, err)
+			}
+			records = append(records, record)
+		}
+
+		if readErr != nil { // io.EOF after the last line
+			// Only a file without any bytes is empty; blank lines are skipped like in the rest of the file
+			if lineNumber == 1 && len(line) == 0 {
+				return nil, fmt.Errorf("NDJSON file '%s' is empty", l.FilePath)
+			}
+			return records, nil
+		}
+	}
+}
+
+// LoaderOptions holds the per-file settings of a loader
+type LoaderOptions struct {
+	Encoding string         // Character encoding of the file; empty detects a byte order mark
+	CSV      CSVOptions     // Dialect of CSV and TSV files
+	JSON     JSONOptions    // Record and value paths of JSON and NDJSON files
+	XLSX     XLSXOptions    // Sheet, header row and cell range of Excel files
+	Location *time.Location // Time zone of Excel and Parquet dates that hold no offset (default UTC)
+}
+
 // GetLoader creates a loader instance for the specified file path
 // Returns appropriate loader based on file extension
 func GetLoader(filePath string) (Loader, error) {
+	return GetLoaderWithOptions(filePath, LoaderOptions{})
+}
+
+// GetLoaderWithOptions creates a loader instance for the specified file path with the given options
+func GetLoaderWithOptions(filePath string, options LoaderOptions) (Loader, error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
 	switch ext {
-	case ".csv":
-		return NewCSVLoader(filePath), nil
+	case ".csv", ".tsv":
+		loader := NewCSVLoader(filePath)
+		if ext == ".tsv" {
+			loader.WithDelimiter('\t')
+		}
+		loader.WithOptions(options.CSV)
+		loader.Encoding = options.Encoding
+		return loader, nil
 	case ".json":
-		return NewJSONLoader(filePath), nil
+		loader := NewJSONLoader(filePath)
+		loader.Encoding = options.Encoding
+		loader.RecordsPath = options.JSON.RecordsPath
+		loader.Sources = options.JSON.Sources
+		return loader, nil
+	case ".ndjson", ".jsonl":
+		loader := NewNDJSONLoader(filePath)
+		loader.Encoding = options.Encoding
+		loader.Sources = options.JSON.Sources
+		return loader, nil
+	case ".xlsx":
+		loader := NewXLSXLoader(filePath)
+		loader.WithOptions(options.XLSX)
+		loader.Location = options.Location
+		return loader, nil
+	case ".parquet":
+		loader := NewParquetLoader(filePath)
+		loader.Location = options.Location
+		return loader, nil
 	default:
-		return nil, fmt.Errorf("unsupported file type: '%s'. Only .csv and .json are supported", ext)
+		return nil, fmt.Errorf("unsupported file type: '%s'. Only .csv, .tsv, .json, .ndjson, .jsonl, .xlsx and .parquet are supported", ext)
+	}
+}
+
+// fileLoadColumns returns the columns to load from the file for the given sync columns
+// Constant columns and columns computed by a concat step are not read from the file;
+// the columns that concat templates use are.
+func fileLoadColumns(columns []string, rules []TransformRule, constants map[string]string) []string {
+	if len(columns) == 0 || (len(rules) == 0 && len(constants) == 0) {
+		return columns
+	}
+	computed := slices.Collect(maps.Keys(constants))
+	for _, rule := range rules {
+		if len(rule.Steps) > 0 && rule.Steps[0].Concat != "" {
+			computed = append(computed, rule.Column)
+		}
+	}
+
+	load := slices.DeleteFunc(slices.Clone(columns), func(c string) bool { return slices.Contains(computed, c) })
+	for _, rule := range rules {
+		for _, step := range rule.Steps {
+			for _, ref := range concatPlaceholder.FindAllStringSubmatch(step.Concat, -1) {
+				if !slices.Contains(load, ref[1]) && !slices.Contains(computed, ref[1]) {
+					load = append(load, ref[1])
+				}
+			}
+		}
 	}
+	return load
 }
 
 // MultiTableData represents data loaded from multiple files, keyed by table name
@@ -218,6 +567,8 @@ type MultiTableData map[string][]DataRecord
 // MultiTableLoader handles loading data from multiple files for multi-table synchronization
 type MultiTableLoader struct {
 	TableConfigs []TableSyncConfig
+	DBLocation   *time.Location // Time zone of the database session that loaded dates are converted into (default UTC)
+	RunID        string         // Replaces ${RUN_ID} in constant values
 }
 
 // NewMultiTableLoader creates a new multi-table loader instance
@@ -230,47 +581,101 @@ func NewMultiTableLoader(tableConfigs []TableSyncConfig) *MultiTableLoader {
 // LoadAll loads data from all configured table files
 // Returns a map where keys are table names and values are the loaded records
 func (ml *MultiTableLoader) LoadAll() (MultiTableData, error) {
-	if len(ml.TableConfigs) == 0 {
-		return nil, fmt.Errorf("no table configurations provided")
+	sources, err := ml.openAll()
+	if err != nil {
+		return nil, err
 	}
 
 	result := make(MultiTableData)
-
 	for _, tableConfig := range ml.TableConfigs {
-		// Create appropriate loader for each file
-		loader, err := GetLoader(tableConfig.FilePath)
+		records, err := collectRecords(sources[tableConfig.Name])
 		if err != nil {
-			return nil, fmt.Errorf("error creating loader for table '%s' file '%s': %w", tableConfig.Name, tableConfig.FilePath, err)
+			return nil, err
 		}
+		// Store the loaded records mapped by table name
+		result[tableConfig.Name] = records
+	}
+
+	return result, nil
+}
+
+// openAll opens the files of all configured tables as record sources, keyed by table name
+func (ml *MultiTableLoader) openAll() (map[string]recordSource, error) {
+	if len(ml.TableConfigs) == 0 {
+		return nil, fmt.Errorf("no table configurations provided")
+	}
 
-		// Load data from the file
-		records, err := loader.Load(tableConfig.Columns)
+	result := make(map[string]recordSource)
+	for _, tableConfig := range ml.TableConfigs {
+		records, err := openTableRecords(tableConfig, ml.dbLocation(), ml.RunID)
 		if err != nil {
-			return nil, fmt.Errorf("error loading data for table '%s' from file '%s': %w", tableConfig.Name, tableConfig.FilePath, err)
+			return nil, err
 		}
-
-		// Store the loaded records mapped by table name
 		result[tableConfig.Name] = records
 	}
-
 	return result, nil
 }
 
+// dbLocation returns the time zone of the database session, UTC if none is set
+func (ml *MultiTableLoader) dbLocation() *time.Location {
+	if ml.DBLocation == nil {
+		return time.UTC
+	}
+	return ml.DBLocation
+}
+
+// loadTableRecords loads the file of a table, sets its constants, applies its transform rules and parses its dates
+// Dates are converted into dbLoc, the time zone of the database session; runID replaces ${RUN_ID} in constants.
+func loadTableRecords(tableConfig TableSyncConfig, dbLoc *time.Location, runID string) ([]DataRecord, error) {
+	records, err := openTableRecords(tableConfig, dbLoc, runID)
+	if err != nil {
+		return nil, err
+	}
+	return collectRecords(records)
+}
+
+// openTableRecords is loadTableRecords returning a record source, which streams Parquet files instead of loading them
+func openTableRecords(tableConfig TableSyncConfig, dbLoc *time.Location, runID string) (recordSource, error) {
+	sourceLoc, err := loadTimezone(tableConfig.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone for table '%s': %w", tableConfig.Name, err)
+	}
+
+	// Create appropriate loader for the file
+	loader, err := GetLoaderWithOptions(tableConfig.FilePath, LoaderOptions{
+		Encoding: tableConfig.Encoding,
+		CSV:      tableConfig.CSV,
+		JSON:     tableConfig.JSON,
+		XLSX:     tableConfig.XLSX,
+		Location: cmp.Or(sourceLoc, dbLoc),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating loader for table '%s' file '%s': %w", tableConfig.Name, tableConfig.FilePath, err)
+	}
+
+	file := fmt.Sprintf("for table '%s' from file '%s'", tableConfig.Name, tableConfig.FilePath)
+	transformer, err := NewTransformer(tableConfig.Transform)
+	if err != nil {
+		return nil, fmt.Errorf("error transforming data %s: %w", file, err)
+	}
+
+	// Load data from the file, including the source columns of computed columns but not the constant columns
+	return openRecords(loader, fileLoadColumns(tableConfig.Columns, tableConfig.Transform, tableConfig.Constants), &recordPreparer{
+		constants:   tableConfig.Constants,
+		runID:       runID,
+		transformer: transformer,
+		dateFormats: tableConfig.DateFormats,
+		source:      sourceLoc,
+		db:          dbLoc,
+		file:        file,
+	})
+}
+
 // LoadForTable loads data for a specific table by name
 func (ml *MultiTableLoader) LoadForTable(tableName string) ([]DataRecord, error) {
 	for _, tableConfig := range ml.TableConfigs {
 		if tableConfig.Name == tableName {
-			loader, err := GetLoader(tableConfig.FilePath)
-			if err != nil {
-				return nil, fmt.Errorf("error creating loader for table '%s' file '%s': %w", tableConfig.Name, tableConfig.FilePath, err)
-			}
-
-			records, err := loader.Load(tableConfig.Columns)
-			if err != nil {
-				return nil, fmt.Errorf("error loading data for table '%s' from file '%s': %w", tableConfig.Name, tableConfig.FilePath, err)
-			}
-
-			return records, nil
+			return loadTableRecords(tableConfig, ml.dbLocation(), ml.RunID)
 		}
 	}
 
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 // Helper function to create a temporary CSV file for testing
@@ -145,8 +149,8 @@ func TestJSONLoader_Load_Success(t *testing.T) {
 ]`,
 			columns: []string{"id", "name", "stock"},
 			expected: []DataRecord{
-				{"id": "1", "name": "Product A", "stock": float64(10)},
-				{"id": "2", "name": "Product B", "stock": float64(5)},
+				{"id": "1", "name": "Product A", "stock": json.Number("10")},
+				{"id": "2", "name": "Product B", "stock": json.Number("5")},
 			},
 		},
 		{
@@ -162,7 +166,7 @@ func TestJSONLoader_Load_Success(t *testing.T) {
 ]`,
 			columns: []string{"id", "name", "available", "rating"},
 			expected: []DataRecord{
-				{"id": float64(1), "name": "Test", "available": true, "rating": 4.5},
+				{"id": json.Number("1"), "name": "Test", "available": true, "rating": json.Number("4.5")},
 			},
 		},
 		{
@@ -184,7 +188,7 @@ func TestJSONLoader_Load_Success(t *testing.T) {
 ]`,
 			columns: []string{}, // Empty - should auto-detect and sort keys
 			expected: []DataRecord{
-				{"active": true, "id": float64(1), "name": "Test"},
+				{"active": true, "id": json.Number("1"), "name": "Test"},
 			},
 		},
 		{
@@ -195,14 +199,14 @@ func TestJSONLoader_Load_Success(t *testing.T) {
 			columns: []string{"int_val", "float_val", "bool_true", "bool_false", "null_val", "string_val", "zero_int", "zero_float"},
 			expected: []DataRecord{
 				{
-					"int_val":    float64(42),
-					"float_val":  3.14159,
+					"int_val":    json.Number("42"),
+					"float_val":  json.Number("3.14159"),
 					"bool_true":  true,  // boolean true
 					"bool_false": false, // boolean false
 					"null_val":   nil,
 					"string_val": "text",
-					"zero_int":   float64(0),
-					"zero_float": 0.0,
+					"zero_int":   json.Number("0"),
+					"zero_float": json.Number("0.0"),
 				},
 			},
 		},
@@ -214,10 +218,10 @@ func TestJSONLoader_Load_Success(t *testing.T) {
 			columns: []string{"large_int", "small_float", "large_float", "negative_val"},
 			expected: []DataRecord{
 				{
-					"large_int":    9.007199254740991e+15,
-					"small_float":  1e-06,
-					"large_float":  1.2345678998765433e+08,
-					"negative_val": -42.5,
+					"large_int":    json.Number("9007199254740991"),
+					"small_float":  json.Number("0.000001"),
+					"large_float":  json.Number("123456789.987654321"),
+					"negative_val": json.Number("-42.5"),
 				},
 			},
 		},
@@ -309,6 +313,212 @@ func TestJSONLoader_Load_Error(t *testing.T) {
 	}
 }
 
+func TestJSONLoaderRecordsPathAndSources(t *testing.T) {
+	content := `{"meta": {"count": 2}, "data": {"items": [
+		{"id": 1, "price": {"amount": 9.5, "currency": "JPY"}, "tags": ["a", "b"], "attrs": {"color": "red"}},
+		{"id": 2, "price": {"amount": 12, "currency": "USD"}, "tags": [], "attrs": {}}
+	]}}`
+
+	tests := []struct {
+		name     string
+		options  JSONOptions
+		columns  []string
+		expected []DataRecord
+	}{
+		{
+			name:    "dotted and JSONPath sources with specified columns",
+			options: JSONOptions{RecordsPath: "data.items", Sources: map[string]string{"amount": "price.amount", "currency": "$.price.currency", "first_tag": "tags[0]"}},
+			columns: []string{"id", "amount", "currency", "tags"},
+			expected: []DataRecord{
+				{"id": json.Number("1"), "amount": json.Number("9.5"), "currency": "JPY", "tags": JSONValue(`["a","b"]`)},
+				{"id": json.Number("2"), "amount": json.Number("12"), "currency": "USD", "tags": JSONValue(`[]`)},
+			},
+		},
+		{
+			name:    "auto-detected columns include sourced columns and serialize objects",
+			options: JSONOptions{RecordsPath: "$.data.items", Sources: map[string]string{"amount": "price.amount"}},
+			expected: []DataRecord{
+				{"id": json.Number("1"), "amount": json.Number("9.5"), "price": JSONValue(`{"amount":9.5,"currency":"JPY"}`), "tags": JSONValue(`["a","b"]`), "attrs": JSONValue(`{"color":"red"}`)},
+				{"id": json.Number("2"), "amount": json.Number("12"), "price": JSONValue(`{"amount":12,"currency":"USD"}`), "tags": JSONValue(`[]`), "attrs": JSONValue(`{}`)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := GetLoaderWithOptions(createTempJSON(t, "items.json", content), LoaderOptions{JSON: tt.options})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			records, err := loader.Load(tt.columns)
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(records, tt.expected) {
+				t.Errorf("Load() = %v, want %v", records, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJSONLoaderRecordsPathErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		options       JSONOptions
+		columns       []string
+		expectedError string
+	}{
+		{"records path not found", `{"data": {}}`, JSONOptions{RecordsPath: "data.items"}, nil, "recordsPath 'data.items' not found"},
+		{"records path is not an array", `{"data": {"items": {}}}`, JSONOptions{RecordsPath: "data.items"}, nil, "recordsPath 'data.items' is not an array"},
+		{"record is not an object", `{"items": [{"id": 1}, 2]}`, JSONOptions{RecordsPath: "items"}, nil, "record 1 at recordsPath 'items' is not an object"},
+		{"invalid records path", `{"items": []}`, JSONOptions{RecordsPath: "items[x]"}, nil, "recordsPath: invalid path"},
+		{"missing nested value", `[{"id": 1, "price": {}}]`, JSONOptions{Sources: map[string]string{"amount": "price.amount"}}, []string{"id", "amount"},
+			"record 0: missing value at 'price.amount' for column 'amount'"},
+		{"invalid source", `[]`, JSONOptions{Sources: map[string]string{"amount": "price..amount"}}, nil, "source of column 'amount': invalid path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := GetLoaderWithOptions(createTempJSON(t, "items.json", tt.content), LoaderOptions{JSON: tt.options})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			_, err = loader.Load(tt.columns)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Load() error = %v, want error containing %q", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestJSONLoadersExactNumbers(t *testing.T) {
+	expected := []DataRecord{
+		{"id": json.Number("1234567890123456789"), "amount": json.Number("12345678901234.5678"), "rate": json.Number("0.000000123456789012")},
+		{"id": json.Number("9223372036854775807"), "amount": json.Number("0.0001"), "rate": json.Number("1500")},
+	}
+	rows := []string{
+		`{"id": 1234567890123456789, "amount": 12345678901234.5678, "rate": 0.000000123456789012}`,
+		`{"id": 9223372036854775807, "amount": 0.0001, "rate": 1.5e3}`,
+	}
+	files := map[string]string{
+		"amounts.json":  "[" + strings.Join(rows, ",\n") + "]",
+		"amounts.jsonl": strings.Join(rows, "\n"),
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			loader, err := GetLoader(createTempJSON(t, name, content))
+			if err != nil {
+				t.Fatalf("GetLoader() returned error: %v", err)
+			}
+			records, err := loader.Load([]string{"id", "amount", "rate"})
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(records, expected) {
+				t.Errorf("Load() = %v, want %v", records, expected)
+			}
+			if got := convertValueToString(records[0]["id"]); got != "1234567890123456789" {
+				t.Errorf("convertValueToString(id) = %q, want exact digits", got)
+			}
+		})
+	}
+}
+
+func TestNDJSONLoaderSources(t *testing.T) {
+	content := "{\"id\": 1, \"user\": {\"name\": \"a\"}, \"payload\": {\"k\": [1]}}\n"
+	loader, err := GetLoaderWithOptions(createTempCSV(t, "events.ndjson", content), LoaderOptions{JSON: JSONOptions{Sources: map[string]string{"user_name": "user.name"}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	records, err := loader.Load([]string{"id", "user_name", "payload"})
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	expected := []DataRecord{{"id": json.Number("1"), "user_name": "a", "payload": JSONValue(`{"k":[1]}`)}}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Load() = %v, want %v", records, expected)
+	}
+}
+
+func TestNDJSONLoader_Load_Success(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		columns  []string
+		expected []DataRecord
+	}{
+		{
+			name:    "auto-detect columns from the first line",
+			content: "{\"id\": 1, \"name\": \"a\", \"at\": \"2024-01-02T03:04:05Z\"}\n{\"id\": 2, \"name\": null, \"at\": \"x\"}\n",
+			expected: []DataRecord{
+				{"id": json.Number("1"), "name": "a", "at": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+				{"id": json.Number("2"), "name": nil, "at": "x"},
+			},
+		},
+		{
+			name:     "filter columns, skip blank lines and CRLF endings",
+			content:  "{\"id\": 1, \"name\": \"a\", \"extra\": true}\r\n\r\n   \n{\"id\": 2, \"name\": \"b\"}",
+			columns:  []string{"id", "name"},
+			expected: []DataRecord{{"id": json.Number("1"), "name": "a"}, {"id": json.Number("2"), "name": "b"}},
+		},
+		{
+			name:     "only blank lines",
+			content:  "\n\n",
+			expected: nil,
+		},
+		{
+			name:     "whitespace-only line without a newline",
+			content:  "  \t",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := NewNDJSONLoader(createTempCSV(t, "events.ndjson", tt.content))
+			records, err := loader.Load(tt.columns)
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(records, tt.expected) {
+				t.Errorf("Load() = %v, want %v", records, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNDJSONLoader_Load_Error(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		columns       []string
+		expectedError string
+	}{
+		{"empty file", "", nil, "' is empty"},
+		{"malformed line", "{\"id\": 1}\n\n{\"id\": 2,\n", nil, "line 3: unexpected EOF"},
+		{"array instead of object", "{\"id\": 1}\n[1, 2]\n", nil, "line 2: json: cannot unmarshal array"},
+		{"null line", "null\n", nil, "line 1: expected a JSON object, got null"},
+		{"missing key", "{\"id\": 1, \"name\": \"a\"}\n{\"id\": 2}\n", nil, "line 2: missing required key 'name'"},
+		{"missing specified column", "{\"id\": 1}\n", []string{"id", "name"}, "line 1: missing required key 'name'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := NewNDJSONLoader(createTempCSV(t, "events.jsonl", tt.content))
+			_, err := loader.Load(tt.columns)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Load() error = %v, want error containing %q", err, tt.expectedError)
+			}
+		})
+	}
+
+	t.Run("file not found", func(t *testing.T) {
+		loader := NewNDJSONLoader(filepath.Join(t.TempDir(), "missing.ndjson"))
+		if _, err := loader.Load(nil); err == nil || !strings.Contains(err.Error(), "no such file or directory") {
+			t.Errorf("Load() error = %v, want file not found", err)
+		}
+	})
+}
+
 func TestGetLoader(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -334,6 +544,36 @@ func TestGetLoader(t *testing.T) {
 			expectedType: &CSVLoader{},
 			expectError:  false,
 		},
+		{
+			name:         "tsv file",
+			filePath:     "testdata.tsv",
+			expectedType: &CSVLoader{},
+			expectError:  false,
+		},
+		{
+			name:         "ndjson file",
+			filePath:     "testdata.ndjson",
+			expectedType: &NDJSONLoader{},
+			expectError:  false,
+		},
+		{
+			name:         "jsonl file",
+			filePath:     "testdata.jsonl",
+			expectedType: &NDJSONLoader{},
+			expectError:  false,
+		},
+		{
+			name:         "xlsx file",
+			filePath:     "testdata.xlsx",
+			expectedType: &XLSXLoader{},
+			expectError:  false,
+		},
+		{
+			name:         "parquet file",
+			filePath:     "testdata.parquet",
+			expectedType: &ParquetLoader{},
+			expectError:  false,
+		},
 		{
 			name:         "uppercase JSON extension",
 			filePath:     "testdata.JSON",
@@ -596,6 +836,129 @@ func TestCSVLoader_AdditionalErrorCases(t *testing.T) {
 	})
 }
 
+func TestCSVLoaderDialect(t *testing.T) {
+	noHeader := false
+	tests := []struct {
+		name     string
+		fileName string
+		content  string
+		opts     CSVOptions
+		columns  []string
+		expected []DataRecord
+	}{
+		{
+			name:     "tsv extension uses tabs",
+			fileName: "data.tsv",
+			content:  "id\tname\n1\tproduct, A\n",
+			expected: []DataRecord{{"id": "1", "name": "product, A"}},
+		},
+		{
+			name:     "semicolon delimiter",
+			fileName: "data.csv",
+			content:  "id;name\n1;\"a;b\"\n",
+			opts:     CSVOptions{Delimiter: ";"},
+			expected: []DataRecord{{"id": "1", "name": "a;b"}},
+		},
+		{
+			name:     "single quote character keeps double quotes literal",
+			fileName: "data.csv",
+			content:  "id,name\n1,'say \"hi\", it''s'\n",
+			opts:     CSVOptions{Quote: "'"},
+			expected: []DataRecord{{"id": "1", "name": `say "hi", it's`}},
+		},
+		{
+			name:     "lazy quotes",
+			fileName: "data.csv",
+			content:  "id,name\n1,5\" disk\n",
+			opts:     CSVOptions{LazyQuotes: true},
+			expected: []DataRecord{{"id": "1", "name": `5" disk`}},
+		},
+		{
+			name:     "trim leading space",
+			fileName: "data.csv",
+			content:  "id, name\n1,   Alice\n",
+			opts:     CSVOptions{TrimLeadingSpace: true},
+			expected: []DataRecord{{"id": "1", "name": "Alice"}},
+		},
+		{
+			name:     "comment lines and skipped preamble",
+			fileName: "data.csv",
+			content:  "Exported by ERP\nas of 2024-01-01\nid,name\n# disabled\n1,Alice\n",
+			opts:     CSVOptions{Comment: "#", SkipLines: 2},
+			expected: []DataRecord{{"id": "1", "name": "Alice"}},
+		},
+		{
+			name:     "no header maps fields to columns by position",
+			fileName: "data.csv",
+			content:  "1,Alice,100\n2,Bob,200\n",
+			opts:     CSVOptions{Header: &noHeader},
+			columns:  []string{"id", "name", "value"},
+			expected: []DataRecord{{"id": "1", "name": "Alice", "value": "100"}, {"id": "2", "name": "Bob", "value": "200"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := createTempCSV(t, tt.fileName, tt.content)
+			loader, err := GetLoaderWithOptions(filePath, LoaderOptions{CSV: tt.opts})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			records, err := loader.Load(tt.columns)
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(records, tt.expected) {
+				t.Errorf("Load() = %v, want %v", records, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCSVLoaderDialectErrors(t *testing.T) {
+	noHeader := false
+	tests := []struct {
+		name          string
+		content       string
+		opts          CSVOptions
+		columns       []string
+		expectedError string
+	}{
+		{
+			name:          "no header without columns",
+			content:       "1,Alice\n",
+			opts:          CSVOptions{Header: &noHeader},
+			expectedError: "has no header row, so columns must be specified",
+		},
+		{
+			name:          "no header with a different field count",
+			content:       "1,Alice\n2,Bob,200\n",
+			opts:          CSVOptions{Header: &noHeader},
+			columns:       []string{"id", "name"},
+			expectedError: "record on line 2: wrong number of fields",
+		},
+		{
+			name:          "line numbers count skipped lines",
+			content:       "preamble\nid,name\n1,\"unclosed\n",
+			opts:          CSVOptions{SkipLines: 1},
+			expectedError: "parse error on line 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := GetLoaderWithOptions(createTempCSV(t, "data.csv", tt.content), LoaderOptions{CSV: tt.opts})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			_, err = loader.Load(tt.columns)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Load() error = %v, want error containing %q", err, tt.expectedError)
+			}
+		})
+	}
+}
+
 // Multi-table loader tests
 func TestMultiTableLoader_LoadAll(t *testing.T) {
 	tests := []struct {
@@ -640,12 +1003,12 @@ func TestMultiTableLoader_LoadAll(t *testing.T) {
 			},
 			expected: MultiTableData{
 				"categories": []DataRecord{
-					{"id": float64(1), "name": "Electronics"},
-					{"id": float64(2), "name": "Books"},
+					{"id": json.Number("1"), "name": "Electronics"},
+					{"id": json.Number("2"), "name": "Books"},
 				},
 				"products": []DataRecord{
-					{"id": float64(1), "name": "Laptop", "category_id": float64(1)},
-					{"id": float64(2), "name": "Novel", "category_id": float64(2)},
+					{"id": json.Number("1"), "name": "Laptop", "category_id": json.Number("1")},
+					{"id": json.Number("2"), "name": "Novel", "category_id": json.Number("2")},
 				},
 			},
 			wantErr: false,
@@ -666,8 +1029,8 @@ func TestMultiTableLoader_LoadAll(t *testing.T) {
 					{"id": "2", "name": "Bob"},
 				},
 				"profiles": []DataRecord{
-					{"user_id": float64(1), "bio": "Software Developer"},
-					{"user_id": float64(2), "bio": "Designer"},
+					{"user_id": json.Number("1"), "bio": "Software Developer"},
+					{"user_id": json.Number("2"), "bio": "Designer"},
 				},
 			},
 			wantErr: false,
@@ -846,3 +1209,67 @@ func TestMultiTableLoader_ValidateFilePaths(t *testing.T) {
 		})
 	}
 }
+
+func TestFileLoadColumns(t *testing.T) {
+	rules := []TransformRule{
+		{Column: "code", Steps: []TransformStep{{Trim: true}}},
+		{Column: "full_name", Steps: []TransformStep{{Concat: "{first_name} {last_name}"}}},
+		{Column: "label", Steps: []TransformStep{{Concat: "{code}: {full_name}"}}},
+	}
+	tests := []struct {
+		name      string
+		columns   []string
+		rules     []TransformRule
+		constants map[string]string
+		want      []string
+	}{
+		{"all columns", nil, rules, nil, nil},
+		{"no rules", []string{"id", "code"}, nil, nil, []string{"id", "code"}},
+		{"computed columns are replaced by their sources", []string{"id", "code", "full_name", "label"}, rules, nil,
+			[]string{"id", "code", "first_name", "last_name"}},
+		{"constant columns are not loaded", []string{"id", "tenant_id", "code"}, nil, map[string]string{"tenant_id": "42"},
+			[]string{"id", "code"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, fileLoadColumns(tt.columns, tt.rules, tt.constants)); diff != "" {
+				t.Errorf("Columns mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMultiTableLoaderDateFormats(t *testing.T) {
+	tokyo := time.FixedZone("+09:00", 9*3600)
+	filePath := createTempCSV(t, "orders.csv", "id,ordered_on,shipped_at,paid_at\n1,2024/06/17,2024-06-17 10:00:00,2024-06-17T01:00:00Z\n2,,2024-06-18 23:30:00,\n")
+	loader := NewMultiTableLoader([]TableSyncConfig{{
+		Name:        "orders",
+		FilePath:    filePath,
+		Timezone:    "UTC",
+		DateFormats: map[string]string{"ordered_on": "2006/01/02", "shipped_at": time.DateTime},
+	}})
+	loader.DBLocation = tokyo
+
+	records, err := loader.LoadForTable("orders")
+	if err != nil {
+		t.Fatalf("LoadForTable() returned error: %v", err)
+	}
+	want := []string{
+		"1|2024-06-17 00:00:00|2024-06-17 19:00:00|2024-06-17 10:00:00",
+		"2||2024-06-19 08:30:00|",
+	}
+	for i, record := range records {
+		got := strings.Join([]string{
+			convertValueToString(record["id"]), convertValueToString(record["ordered_on"]),
+			convertValueToString(record["shipped_at"]), convertValueToString(record["paid_at"]),
+		}, "|")
+		if got != want[i] {
+			t.Errorf("record %d = %s, want %s", i+1, got, want[i])
+		}
+	}
+
+	loader.TableConfigs[0].DateFormats["ordered_on"] = "02.01.2006"
+	if _, err := loader.LoadForTable("orders"); err == nil || !strings.Contains(err.Error(), "record 1, column 'ordered_on'") {
+		t.Errorf("LoadForTable() error = %v, want a date parse error for record 1", err)
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// lockNamePrefix is prepended to every lock name so that our locks do not collide with other applications
+const lockNamePrefix = "mydatasyncer:"
+
+// maxLockNameLength is the longest name MySQL accepts for GET_LOCK
+const maxLockNameLength = 64
+
+// lockPollInterval is how often the lock table is polled while waiting for another run
+const lockPollInterval = time.Second
+
+// SyncLocker acquires the locks that keep concurrent runs from syncing the same tables
+type SyncLocker interface {
+	// Acquire locks all names or none of them; the returned function releases the locks
+	Acquire(ctx context.Context, names []string) (release func(), err error)
+}
+
+// LockHeldError reports a lock that is held by another run
+type LockHeldError struct {
+	Name  string    // Lock name
+	Since time.Time // When the other run acquired the lock (zero if unknown)
+	Host  string    // Host of the other run (empty if unknown)
+	Hint  string    // Additional information on how to resolve the conflict
+}
+
+func (e *LockHeldError) Error() string {
+	since := "an unknown time"
+	if !e.Since.IsZero() {
+		since = e.Since.Local().Format(time.RFC3339)
+	}
+	host := e.Host
+	if host == "" {
+		host = "an unknown host"
+	}
+	msg := fmt.Sprintf("another sync is running since %s on host %s (lock '%s')", since, host, e.Name)
+	if e.Hint != "" {
+		msg += "; " + e.Hint
+	}
+	return msg
+}
+
+// acquireSyncLocks locks every table the run will touch before anything is read
+// The returned function releases the locks and must be called once the run is over.
+func acquireSyncLocks(ctx context.Context, db *sql.DB, config Config) (func(), error) {
+	noop := func() {}
+	if config.Lock.Mode == LockModeNone {
+		return noop, nil
+	}
+	if config.DryRun {
+		slog.InfoContext(ctx, "Dry-run: skipping sync locks")
+		return noop, nil
+	}
+
+	var tables []string
+	if IsMultiTableConfig(config) {
+		for _, table := range config.Tables {
+			tables = append(tables, table.Name)
+		}
+	} else {
+		tables = []string{config.Sync.TableName}
+	}
+
+	database := ""
+	if dsn, err := mysql.ParseDSN(config.DB.DSN); err == nil {
+		database = dsn.DBName
+	}
+
+	names := syncLockNames(database, tables)
+	release, err := newSyncLocker(db, config.Lock).Acquire(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Acquired sync locks", "tables", tables)
+	return release, nil
+}
+
+// newSyncLocker returns the locker for the configured lock mode
+func newSyncLocker(db *sql.DB, lock LockConfig) SyncLocker {
+	if lock.Mode == LockModeTable {
+		table := lock.Table
+		if table == "" {
+			table = DefaultLockTable
+		}
+		return &tableLocker{db: db, table: table, waitTimeout: lock.WaitTimeout}
+	}
+	return &getLockLocker{db: db, waitTimeout: lock.WaitTimeout}
+}
+
+// syncLockNames returns the lock names of the tables, sorted so that concurrent runs lock in the same order
+func syncLockNames(database string, tables []string) []string {
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, lockName(database, table))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lockName returns the lock name for a table, hashed if it would exceed maxLockNameLength
+func lockName(database, table string) string {
+	name := lockNamePrefix + table
+	if database != "" {
+		name = lockNamePrefix + database + "." + table
+	}
+	if len(name) <= maxLockNameLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	return lockNamePrefix + hex.EncodeToString(sum[:])[:maxLockNameLength-len(lockNamePrefix)]
+}
+
+// getLockLocker locks tables with MySQL GET_LOCK on a dedicated connection
+// Named locks belong to the connection, so they are released automatically if the process dies.
+type getLockLocker struct {
+	db          *sql.DB
+	waitTimeout time.Duration
+}
+
+func (l *getLockLocker) Acquire(ctx context.Context, names []string) (func(), error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock connection: %w", err)
+	}
+
+	var acquired []string
+	release := func() {
+		for _, name := range acquired {
+			var released sql.NullInt64
+			if err := conn.QueryRowContext(context.Background(), "SELECT RELEASE_LOCK(?)", name).Scan(&released); err != nil {
+				slog.WarnContext(ctx, "Failed to release lock", "lock", name, "error", err)
+			}
+		}
+		conn.Close() //nolint:errcheck
+	}
+
+	deadline := time.Now().Add(l.waitTimeout)
+	for _, name := range names {
+		// GET_LOCK takes whole seconds
+		wait := int(math.Ceil(time.Until(deadline).Seconds()))
+		if wait < 0 {
+			wait = 0
+		}
+
+		var result sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, wait).Scan(&result); err != nil {
+			release()
+			return nil, fmt.Errorf("failed to acquire lock '%s': %w", name, err)
+		}
+		if !result.Valid || result.Int64 != 1 {
+			heldErr := l.holder(ctx, conn, name)
+			release()
+			return nil, heldErr
+		}
+		acquired = append(acquired, name)
+	}
+	return release, nil
+}
+
+// holder describes the connection holding the named lock
+// The holder's lock connection is idle after acquiring its locks, so its PROCESSLIST time
+// is the time since the locks were taken.
+func (l *getLockLocker) holder(ctx context.Context, conn *sql.Conn, name string) error {
+	heldErr := &LockHeldError{Name: name}
+
+	var connID sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?)", name).Scan(&connID); err != nil || !connID.Valid {
+		return heldErr
+	}
+	heldErr.Hint = fmt.Sprintf("held by connection %d", connID.Int64)
+
+	var host string
+	var seconds int64
+	err := conn.QueryRowContext(ctx, "SELECT HOST, TIME FROM information_schema.PROCESSLIST WHERE ID = ?", connID.Int64).Scan(&host, &seconds)
+	if err != nil {
+		return heldErr
+	}
+	if h, _, splitErr := net.SplitHostPort(host); splitErr == nil {
+		host = h
+	}
+	heldErr.Host = host
+	heldErr.Since = time.Now().Add(-time.Duration(seconds) * time.Second).Truncate(time.Second)
+	return heldErr
+}
+
+// tableLocker locks tables by inserting rows into a lock table, for databases without named locks
+// Rows left behind by a crashed run must be deleted by hand; the error message says which one.
+type tableLocker struct {
+	db          *sql.DB
+	table       string
+	waitTimeout time.Duration
+}
+
+func (l *tableLocker) Acquire(ctx context.Context, names []string) (func(), error) {
+	_, err := l.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+lock_name VARCHAR(64) NOT NULL PRIMARY KEY,
+holder_host VARCHAR(255) NOT NULL,
+holder_token VARCHAR(64) NOT NULL,
+acquired_at VARCHAR(64) NOT NULL
+)`, l.table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock table %s: %w", l.table, err)
+	}
+
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	holderHost := fmt.Sprintf("%s (pid %d)", hostname, os.Getpid())
+
+	var acquired []string
+	release := func() {
+		for _, name := range acquired {
+			_, err := l.db.ExecContext(context.Background(),
+				fmt.Sprintf("DELETE FROM %s WHERE lock_name = ? AND holder_token = ?", l.table), name, token)
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to release lock", "lock", name, "error", err)
+			}
+		}
+	}
+
+	deadline := time.Now().Add(l.waitTimeout)
+	for _, name := range names {
+		for {
+			_, insertErr := l.db.ExecContext(ctx,
+				fmt.Sprintf("INSERT INTO %s (lock_name, holder_host, holder_token, acquired_at) VALUES (?, ?, ?, ?)", l.table),
+				name, holderHost, token, time.Now().UTC().Format(time.RFC3339))
+			if insertErr == nil {
+				acquired = append(acquired, name)
+				break
+			}
+
+			heldErr, err := l.holder(ctx, name)
+			if err != nil {
+				release()
+				return nil, fmt.Errorf("failed to acquire lock '%s': %w", name, insertErr)
+			}
+			if heldErr == nil {
+				continue // Released in the meantime, try again
+			}
+
+			wait := time.Until(deadline)
+			if wait <= 0 {
+				release()
+				return nil, heldErr
+			}
+			select {
+			case <-ctx.Done():
+				release()
+				return nil, fmt.Errorf("waiting for lock '%s': %w", name, ctx.Err())
+			case <-time.After(min(wait, lockPollInterval)):
+			}
+		}
+	}
+	return release, nil
+}
+
+// holder describes the run holding the named lock, or returns nil if the lock is free
+func (l *tableLocker) holder(ctx context.Context, name string) (*LockHeldError, error) {
+	var host, acquiredAt string
+	err := l.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT holder_host, acquired_at FROM %s WHERE lock_name = ?", l.table), name).Scan(&host, &acquiredAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	heldErr := &LockHeldError{
+		Name: name,
+		Host: host,
+		Hint: fmt.Sprintf("if that run is no longer active, delete the row with lock_name '%s' from %s", name, l.table),
+	}
+	if since, err := time.Parse(time.RFC3339, acquiredAt); err == nil {
+		heldErr.Since = since
+	}
+	return heldErr, nil
+}
+
+// newLockToken returns a random token identifying the locks taken by this run
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
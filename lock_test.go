@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLockName(t *testing.T) {
+	t.Run("short names are readable", func(t *testing.T) {
+		if got := lockName("shop", "orders"); got != "mydatasyncer:shop.orders" {
+			t.Errorf("Unexpected lock name %q", got)
+		}
+		if got := lockName("", "orders"); got != "mydatasyncer:orders" {
+			t.Errorf("Unexpected lock name without database %q", got)
+		}
+	})
+
+	t.Run("long names are hashed to the MySQL limit", func(t *testing.T) {
+		table := strings.Repeat("t", 80)
+		got := lockName("shop", table)
+		if len(got) != maxLockNameLength {
+			t.Errorf("Expected length %d, got %d (%q)", maxLockNameLength, len(got), got)
+		}
+		if !strings.HasPrefix(got, lockNamePrefix) {
+			t.Errorf("Expected prefix %q, got %q", lockNamePrefix, got)
+		}
+		if got != lockName("shop", table) {
+			t.Error("Expected hashed names to be stable")
+		}
+		if got == lockName("shop", table+"x") {
+			t.Error("Expected different tables to get different lock names")
+		}
+	})
+}
+
+func TestSyncLockNames(t *testing.T) {
+	got := syncLockNames("shop", []string{"orders", "customers", "products"})
+	want := []string{"mydatasyncer:shop.customers", "mydatasyncer:shop.orders", "mydatasyncer:shop.products"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("syncLockNames() = %v, want %v", got, want)
+	}
+}
+
+func TestLockHeldError(t *testing.T) {
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("known holder", func(t *testing.T) {
+		err := &LockHeldError{Name: "mydatasyncer:shop.orders", Since: since, Host: "batch-01", Hint: "held by connection 42"}
+		want := "another sync is running since " + since.Local().Format(time.RFC3339) +
+			" on host batch-01 (lock 'mydatasyncer:shop.orders'); held by connection 42"
+		if err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+	})
+
+	t.Run("unknown holder", func(t *testing.T) {
+		err := &LockHeldError{Name: "mydatasyncer:orders"}
+		want := "another sync is running since an unknown time on host an unknown host (lock 'mydatasyncer:orders')"
+		if err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+	})
+}
+
+func TestAcquireSyncLocksSkipped(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "lock mode none", config: Config{Lock: LockConfig{Mode: LockModeNone}}},
+		{name: "dry-run", config: Config{DryRun: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No database is needed because no lock is taken
+			release, err := acquireSyncLocks(context.Background(), nil, tt.config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			release()
+		})
+	}
+}
+
+func TestSyncLockers(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+	names := syncLockNames("test_db", []string{"test_table", "other_table"})
+
+	lockers := map[string]func() SyncLocker{
+		LockModeGetLock: func() SyncLocker { return newSyncLocker(db, LockConfig{Mode: LockModeGetLock}) },
+		LockModeTable:   func() SyncLocker { return newSyncLocker(db, LockConfig{Mode: LockModeTable}) },
+	}
+
+	for mode, newLocker := range lockers {
+		t.Run(mode, func(t *testing.T) {
+			release, err := newLocker().Acquire(ctx, names)
+			if err != nil {
+				t.Fatalf("First acquire failed: %v", err)
+			}
+
+			_, err = newLocker().Acquire(ctx, names[1:])
+			var heldErr *LockHeldError
+			if !errors.As(err, &heldErr) {
+				t.Fatalf("Expected LockHeldError while the locks are held, got %v", err)
+			}
+			if heldErr.Host == "" || heldErr.Since.IsZero() {
+				t.Errorf("Expected holder host and time, got %+v", heldErr)
+			}
+
+			release()
+			releaseAgain, err := newLocker().Acquire(ctx, names)
+			if err != nil {
+				t.Fatalf("Acquire after release failed: %v", err)
+			}
+			releaseAgain()
+		})
+	}
+
+	t.Run("wait timeout waits for the holder", func(t *testing.T) {
+		release, err := newSyncLocker(db, LockConfig{Mode: LockModeTable}).Acquire(ctx, names)
+		if err != nil {
+			t.Fatalf("First acquire failed: %v", err)
+		}
+		time.AfterFunc(500*time.Millisecond, release)
+
+		waiting := newSyncLocker(db, LockConfig{Mode: LockModeTable, WaitTimeout: 5 * time.Second})
+		releaseWaiting, err := waiting.Acquire(ctx, names)
+		if err != nil {
+			t.Fatalf("Expected the lock after the holder released it, got %v", err)
+		}
+		releaseWaiting()
+	})
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Log formats accepted by -log-format
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LevelFatal is the level of the error that stopped the run; it is logged as ERROR but, unlike the
+// per-record errors of validation reports, it is still shown with -quiet
+const LevelFatal = slog.LevelError + 2
+
+// LevelSummary is the level of the final run summary; it is above every other level so that -quiet still shows it
+const LevelSummary = slog.LevelError + 4
+
+// reportOutput receives human-readable reports such as dry-run execution plans.
+// They are kept out of the log stream so that structured logs stay parseable.
+var reportOutput io.Writer = os.Stdout
+
+// LogOptions holds the logging settings from the command line
+type LogOptions struct {
+	Format string // "text" (default) or "json"
+	Level  string // "debug", "info" (default), "warn" or "error"
+	Quiet  bool   // Only log the final summary and the error that stopped the run
+}
+
+// newLogger creates the logger for a run; every record carries the run ID,
+// and the table and phase from the context when they are known
+func newLogger(w io.Writer, opts LogOptions, runID string) (*slog.Logger, error) {
+	level, err := parseLogLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Quiet {
+		level = LevelFatal
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level, ReplaceAttr: replaceLogAttr}
+	var handler slog.Handler
+	switch opts.Format {
+	case "", LogFormatText:
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case LogFormatJSON:
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("unknown log format '%s' (expected '%s' or '%s')", opts.Format, LogFormatText, LogFormatJSON)
+	}
+
+	return slog.New(&contextHandler{Handler: handler}).With("run_id", runID), nil
+}
+
+// parseLogLevel converts a -log-level value to a slog level
+func parseLogLevel(value string) (slog.Level, error) {
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("unknown log level '%s' (expected debug, info, warn or error)", value)
+	}
+	return level, nil
+}
+
+// replaceLogAttr names the fatal and summary levels and masks secrets in every message and attribute
+func replaceLogAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			switch level {
+			case LevelFatal:
+				return slog.String(slog.LevelKey, "ERROR")
+			case LevelSummary:
+				return slog.String(slog.LevelKey, "SUMMARY")
+			}
+		}
+		return a
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, maskSecrets(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, maskSecrets(err.Error()))
+		}
+	}
+	return a
+}
+
+// newRunID returns a random ID that correlates all log records of one run
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// logTableKey is the context key for the table currently being processed
+type logTableKey struct{}
+
+// withLogTable returns a context whose log records carry the given table name
+func withLogTable(ctx context.Context, table string) context.Context {
+	return context.WithValue(ctx, logTableKey{}, table)
+}
+
+// contextHandler adds the table and phase stored in the context to each record,
+// and records warnings in the run statistics for the run report (even when -quiet hides them)
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if isWarning(level) && ctx.Value(runStatsKey{}) != nil {
+		return true
+	}
+	return h.Handler.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	table, hasTable := ctx.Value(logTableKey{}).(string)
+	if isWarning(r.Level) {
+		if stats, ok := ctx.Value(runStatsKey{}).(*RunStats); ok {
+			stats.addWarning(table, maskSecrets(r.Message))
+		}
+		if !h.Handler.Enabled(ctx, r.Level) {
+			return nil
+		}
+	}
+
+	if hasTable {
+		r.AddAttrs(slog.String("table", table))
+	}
+	if tracker, ok := ctx.Value(phaseTrackerKey{}).(*phaseTracker); ok {
+		r.AddAttrs(slog.String("phase", tracker.Current()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// isWarning reports whether level is a warning rather than an error
+func isWarning(level slog.Level) bool {
+	return level >= slog.LevelWarn && level < slog.LevelError
+}
+
+// writeReport writes a human-readable report, such as an execution plan, to reportOutput
+func writeReport(format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	fmt.Fprint(reportOutput, maskSecrets(text)) //nolint:errcheck
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// decodeLogLines parses JSON log output into one map per record
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Log line is not valid JSON: %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNewLogger(t *testing.T) {
+	t.Run("json records carry run ID, table and phase", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, LogOptions{Format: LogFormatJSON}, "run-123")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		ctx, _ := withPhaseTracker(context.Background())
+		setPhase(ctx, "loading files")
+		logger.InfoContext(withLogTable(ctx, "orders"), "Inserted records", "count", 3)
+
+		records := decodeLogLines(t, &buf)
+		if len(records) != 1 {
+			t.Fatalf("Expected 1 record, got %d", len(records))
+		}
+		want := map[string]any{"msg": "Inserted records", "run_id": "run-123", "table": "orders", "phase": "loading files", "count": float64(3)}
+		for key, value := range want {
+			if records[0][key] != value {
+				t.Errorf("Expected %s=%v, got %v", key, value, records[0][key])
+			}
+		}
+	})
+
+	t.Run("log level filters records", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, LogOptions{Format: LogFormatJSON, Level: "warn"}, "run")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		logger.Info("hidden")
+		logger.Warn("shown")
+
+		records := decodeLogLines(t, &buf)
+		if len(records) != 1 || records[0]["msg"] != "shown" {
+			t.Errorf("Expected only the warning, got %v", records)
+		}
+	})
+
+	t.Run("quiet keeps only the fatal error and the summary", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, LogOptions{Format: LogFormatJSON, Quiet: true}, "run")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		logger.Info("hidden")
+		logger.Warn("hidden too")
+		logger.Error("Column 'price' failed decimal check in 3 records")
+		logger.Log(context.Background(), LevelFatal, "Application error")
+		logger.Log(context.Background(), LevelSummary, "summary")
+
+		records := decodeLogLines(t, &buf)
+		if len(records) != 2 {
+			t.Fatalf("Expected 2 records, got %v", records)
+		}
+		if records[0]["msg"] != "Application error" || records[0]["level"] != "ERROR" {
+			t.Errorf("Expected the fatal error logged as ERROR, got %v", records[0])
+		}
+		if records[1]["level"] != "SUMMARY" {
+			t.Errorf("Expected level SUMMARY, got %v", records[1]["level"])
+		}
+	})
+
+	t.Run("warnings are recorded in the run statistics even when quiet", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, LogOptions{Format: LogFormatJSON, Quiet: true}, "run")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		stats := NewRunStats(false)
+		ctx := withRunStats(context.Background(), stats)
+		logger.WarnContext(withLogTable(ctx, "orders"), "Skipping record")
+		logger.WarnContext(withLogTable(ctx, "orders"), "Skipping record")
+		logger.WarnContext(ctx, "Unknown table in selection")
+		logger.InfoContext(ctx, "not a warning")
+		logger.ErrorContext(ctx, "not a warning either")
+
+		want := []RunWarning{
+			{Table: "orders", Message: "Skipping record", Count: 2},
+			{Message: "Unknown table in selection", Count: 1},
+		}
+		if diff := cmp.Diff(want, stats.Warnings()); diff != "" {
+			t.Errorf("Warnings mismatch (-want +got):\n%s", diff)
+		}
+		if records := decodeLogLines(t, &buf); len(records) != 0 {
+			t.Errorf("Expected nothing to be logged, got %v", records)
+		}
+	})
+
+	t.Run("text format", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, LogOptions{}, "run-9")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		logger.Info("hello")
+		if !strings.Contains(buf.String(), "msg=hello run_id=run-9") {
+			t.Errorf("Unexpected text output: %q", buf.String())
+		}
+	})
+
+	t.Run("secrets are masked in messages and attributes", func(t *testing.T) {
+		registerSecret("s3cr3t-logging-value")
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, LogOptions{Format: LogFormatJSON}, "run")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		logger.Error("connect with s3cr3t-logging-value", "dsn", "app:s3cr3t-logging-value@tcp(db)/x",
+			"error", errors.New("denied for s3cr3t-logging-value"))
+		if strings.Contains(buf.String(), "s3cr3t-logging-value") {
+			t.Errorf("Secret leaked into log output: %s", buf.String())
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		if _, err := newLogger(&bytes.Buffer{}, LogOptions{Format: "xml"}, "run"); err == nil {
+			t.Error("Expected error for unknown log format")
+		}
+		if _, err := newLogger(&bytes.Buffer{}, LogOptions{Level: "verbose"}, "run"); err == nil {
+			t.Error("Expected error for unknown log level")
+		}
+	})
+}
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		value string
+		want  slog.Level
+	}{
+		{value: "", want: slog.LevelInfo},
+		{value: "debug", want: slog.LevelDebug},
+		{value: "INFO", want: slog.LevelInfo},
+		{value: "warn", want: slog.LevelWarn},
+		{value: "error", want: slog.LevelError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseLogLevel(tt.value)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseLogLevel(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	var buf bytes.Buffer
+	original := reportOutput
+	reportOutput = &buf
+	defer func() { reportOutput = original }()
+
+	writeReport("[%d] Table: %s", 1, "orders")
+	if buf.String() != "[1] Table: orders\n" {
+		t.Errorf("Unexpected report output: %q", buf.String())
+	}
+}
+
+func TestNewRunID(t *testing.T) {
+	first, second := newRunID(), newRunID()
+	if len(first) != 16 || first == second {
+		t.Errorf("Expected distinct 16-character run IDs, got %q and %q", first, second)
+	}
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
+	"cmp"
 	"context"
-	"database/sql"
 	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -20,6 +22,10 @@ func CustomUsage() {
 
 Usage:
   mydatasyncer [options]
+  mydatasyncer config print [-config path] [-env name]
+
+Commands:
+  config print    Print the fully merged configuration (includes, defaults, environment overlay) with secrets masked
 
 Options:
 `)
@@ -32,10 +38,33 @@ Examples:
 
   Preview changes:
     $ mydatasyncer -config ./config.yml -dry-run
+
+  Use the production overlay (config.prod.yml):
+    $ mydatasyncer -config ./config.yml -env prod
+
+  Allow a long-running sync up to two hours:
+    $ mydatasyncer -config ./config.yml -timeout 2h
+
+  JSON logs for a log pipeline, only the summary on success:
+    $ mydatasyncer -config ./config.yml -log-format json -quiet
+
+  Write a report to attach to a ticket:
+    $ mydatasyncer -config ./config.yml -report-html ./sync-report.html
 `)
 }
 
 func main() {
+	// Mask database passwords in every log line
+	stderr := newMaskingWriter(os.Stderr)
+
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfigCommand(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(stderr, "Config command error: %v\n", maskError(err)) //nolint:errcheck
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Set custom usage function
 	flag.Usage = CustomUsage
 
@@ -48,45 +77,247 @@ func main() {
 	Preview changes that would be made without applying them to the database
 	Use this to verify changes before actual synchronization`)
 
+	env := flag.String("env", "", `Environment overlay to apply on top of the configuration file
+	Example: -env prod with -config config.yml merges config.prod.yml`)
+
+	tables := flag.String("tables", "", `Comma-separated list of tables to synchronize (multi-table configs only)
+	Default: all configured tables`)
+
+	excludeTables := flag.String("exclude-tables", "", `Comma-separated list of tables to skip (multi-table configs only)`)
+
+	withDependencies := flag.Bool("with-dependencies", false, `Also synchronize the tables that the tables selected by -tables depend on`)
+
+	withDependents := flag.Bool("with-dependents", false, `Also synchronize the tables that depend on the tables selected by -tables`)
+
+	allowDefaultConfig := flag.Bool("allow-default-config", false, `Use the built-in default configuration when the config file does not exist
+	By default a missing, unreadable or invalid config file is a fatal error`)
+
+	timeout := flag.Duration("timeout", 0, `Overall time limit for the synchronization (e.g. 30m, 2h)
+	Overrides "timeout" in the configuration file; default 5m`)
+
+	logFormat := flag.String("log-format", LogFormatText, `Log format: text or json`)
+
+	logLevel := flag.String("log-level", "info", `Minimum log level: debug, info, warn or error`)
+
+	reportJSON := flag.String("report-json", "", `Write the end-of-run summary as JSON to this file
+	Overrides "report.json" in the configuration file`)
+
+	reportHTML := flag.String("report-html", "", `Write the end-of-run summary as a self-contained HTML report to this file
+	Overrides "report.html" in the configuration file`)
+
+	quiet := flag.Bool("quiet", false, `Only print the final summary (and the error that stopped the run)
+	Dry-run execution plans are not printed either`)
+
 	flag.Parse()
 
-	if err := RunApp(*configPath, *dryRun); err != nil {
-		log.Fatalf("Application error: %v", err)
+	runID := newRunID()
+	logger, err := newLogger(stderr, LogOptions{Format: *logFormat, Level: *logLevel, Quiet: *quiet}, runID)
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid logging options: %v\n", err) //nolint:errcheck
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+	if *quiet {
+		reportOutput = io.Discard
+	}
+
+	opts := AppOptions{
+		ConfigPath: *configPath,
+		DryRun:     *dryRun,
+		Env:        *env,
+		TableSelection: TableSelection{
+			Tables:           splitList(*tables),
+			ExcludeTables:    splitList(*excludeTables),
+			WithDependencies: *withDependencies,
+			WithDependents:   *withDependents,
+		},
+		AllowDefaultConfig: *allowDefaultConfig,
+		Timeout:            *timeout,
+		RunID:              runID,
+		ReportJSON:         *reportJSON,
+		ReportHTML:         *reportHTML,
 	}
+	if err := RunAppWithOptions(opts); err != nil {
+		slog.Log(context.Background(), LevelFatal, "Application error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// AppOptions holds the command-line options for a synchronization run
+type AppOptions struct {
+	ConfigPath         string         // Path to the configuration file (default: mydatasyncer.yml)
+	DryRun             bool           // Preview changes without applying them
+	Env                string         // Environment overlay to apply (e.g. "prod" loads <config>.prod.yml)
+	TableSelection     TableSelection // Subset of tables to synchronize (multi-table configs only)
+	AllowDefaultConfig bool           // Fall back to NewDefaultConfig when the config file does not exist
+	Timeout            time.Duration  // Overall run timeout; overrides the config file when positive
+	RunID              string         // ID of the run in logs and reports (default: a new random ID)
+	ReportJSON         string         // Path of the JSON run summary; overrides the config file
+	ReportHTML         string         // Path of the HTML run report; overrides the config file
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // RunApp is the main entry point for the application
 func RunApp(configPath string, dryRun bool) error {
-	// Create a context with timeout for the entire process
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
+	return RunAppWithOptions(AppOptions{ConfigPath: configPath, DryRun: dryRun})
+}
+
+// RunAppWithOptions runs the application with the given command-line options
+// Returned errors never contain secrets such as the database password
+func RunAppWithOptions(opts AppOptions) error {
+	return maskError(runApp(opts))
+}
+
+// configFileMissing reports whether the config file itself, not one of its includes, does not exist
+func configFileMissing(configPath string) bool {
+	_, err := os.Stat(cmp.Or(configPath, defaultConfigPath))
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// runApp loads the configuration, connects to the database and runs the synchronization
+func runApp(opts AppOptions) error {
+	start := time.Now()
 
 	// 1. Load configuration
-	config := LoadConfig(configPath)
-	config.DryRun = dryRun // Set dry-run mode from command line flag
+	config, err := LoadConfigForEnv(opts.ConfigPath, opts.Env)
+	if err != nil {
+		// Only a missing config file may fall back to the defaults, and only when explicitly allowed;
+		// a missing include or environment overlay of an existing file is an error like any other
+		if !opts.AllowDefaultConfig || !configFileMissing(opts.ConfigPath) {
+			return fmt.Errorf("configuration error: %w", err)
+		}
+		slog.Warn("Using default configuration (-allow-default-config)", "error", err)
+		config = NewDefaultConfig()
+	}
+	config.DryRun = opts.DryRun // Set dry-run mode from command line flag
 
-	if dryRun {
-		log.Println("Running in DRY-RUN mode - No changes will be applied to the database")
+	if opts.DryRun {
+		slog.Info("Running in DRY-RUN mode - No changes will be applied to the database")
+	}
+	if opts.ReportJSON != "" {
+		config.Report.JSON = opts.ReportJSON
+	}
+	if opts.ReportHTML != "" {
+		config.Report.HTML = opts.ReportHTML
 	}
 
 	if err := ValidateConfig(config); err != nil {
 		// Check if it's a DependencyError for enhanced error reporting
 		var depErr *DependencyError
 		if errors.As(err, &depErr) {
-			log.Printf("%s", depErr.GetDetailedErrorMessage())
-			return fmt.Errorf("configuration validation failed")
+			slog.Error("Missing table dependency", "table", depErr.TableName, "dependency", depErr.MissingDependency,
+				"details", depErr.GetDetailedErrorMessage())
+			return fmt.Errorf("configuration validation failed: table '%s' depends on missing table '%s'",
+				depErr.TableName, depErr.MissingDependency)
 		}
 		// Check if it's a CircularDependencyError for enhanced error reporting
 		var circErr *CircularDependencyError
 		if errors.As(err, &circErr) {
-			log.Printf("%s", circErr.GetDetailedErrorMessage())
-			return fmt.Errorf("configuration validation failed")
+			slog.Error("Circular table dependency", "details", circErr.GetDetailedErrorMessage())
+			return fmt.Errorf("configuration validation failed: %w", circErr)
 		}
 		return fmt.Errorf("configuration error: %w", err)
 	}
 
+	// Collect the statistics for metrics and reports; warnings logged with runCtx end up in the report
+	stats := NewRunStats(config.DryRun)
+	stats.Start = start
+	stats.RunID = opts.RunID
+	if stats.RunID == "" {
+		stats.RunID = newRunID()
+	}
+	runCtx := withRunStats(context.Background(), stats)
+
+	// From here on every error is reported, notified and exported like a failed sync
+	err = applyTableSelection(runCtx, &config, opts.TableSelection)
+
+	// Bound the whole run by the timeout and cancel it cleanly on SIGINT/SIGTERM;
+	// open transactions are rolled back when their context is cancelled
+	ctx, stop := interruptContext(runCtx)
+	defer stop()
+	timeout := resolveTimeout(opts.Timeout, config)
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, fmt.Errorf("timeout of %s exceeded", timeout))
+	defer cancel()
+	ctx, tracker := withPhaseTracker(ctx)
+
+	if err == nil {
+		err = syncWithConfig(ctx, config)
+	}
+	stats.Finish()
+	exportMetrics(config.Metrics, stats, err)
+	if err != nil {
+		err = interruptedError(ctx, tracker, err)
+	}
+	report := newRunReport(stats, err)
+	writeRunReports(config.Report, report)
+	sendNotifications(config.Notify, report)
+	if err != nil {
+		return err
+	}
+
+	warnings := 0
+	for _, w := range report.Warnings {
+		warnings += w.Count
+	}
+	slog.Log(ctx, LevelSummary, "Data synchronization completed successfully",
+		"dry_run", config.DryRun,
+		"tables", syncedTableNames(config),
+		"inserted", report.Totals.Inserted,
+		"updated", report.Totals.Updated,
+		"deleted", report.Totals.Deleted,
+		"skipped", report.Totals.Skipped,
+		"warnings", warnings,
+		"duration", stats.Duration().Round(time.Millisecond))
+	return nil
+}
+
+// applyTableSelection restricts a multi-table sync to the tables selected on the command line
+func applyTableSelection(ctx context.Context, config *Config, selection TableSelection) error {
+	if selection.IsEmpty() {
+		return nil
+	}
+	if !IsMultiTableConfig(*config) {
+		return fmt.Errorf("configuration error: -tables and -exclude-tables require a multi-table configuration")
+	}
+	selected, warnings, err := SelectTables(config.Tables, selection)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	for _, warning := range warnings {
+		slog.WarnContext(ctx, warning)
+	}
+	config.Tables = selected
+	slog.InfoContext(ctx, "Selected tables for synchronization", "tables", syncedTableNames(*config))
+	return nil
+}
+
+// syncedTableNames returns the names of the tables the configuration synchronizes
+func syncedTableNames(config Config) []string {
+	if !IsMultiTableConfig(config) {
+		return []string{config.Sync.TableName}
+	}
+	names := make([]string, 0, len(config.Tables))
+	for _, table := range config.Tables {
+		names = append(names, table.Name)
+	}
+	return names
+}
+
+// syncWithConfig connects to the database and synchronizes the configured tables
+func syncWithConfig(ctx context.Context, config Config) error {
 	// 2. Database connection
-	db, err := sql.Open("mysql", config.DB.DSN)
+	setPhase(ctx, "connecting to the database")
+	db, err := openDB(config.DB)
 	if err != nil {
 		return fmt.Errorf("database connection error: %w", err)
 	}
@@ -95,7 +326,15 @@ func RunApp(configPath string, dryRun bool) error {
 	if err != nil {
 		return fmt.Errorf("database connectivity error: %w", err)
 	}
-	log.Println("Database connection successful")
+	slog.InfoContext(ctx, "Database connection successful")
+
+	// Keep concurrent runs (e.g. cron on several hosts) from syncing the same tables
+	setPhase(ctx, "acquiring sync locks")
+	release, err := acquireSyncLocks(ctx, db, config)
+	if err != nil {
+		return fmt.Errorf("sync lock error: %w", err)
+	}
+	defer release()
 
 	// 3. Check configuration type and execute appropriate synchronization
 	if IsMultiTableConfig(config) {
@@ -106,38 +345,108 @@ func RunApp(configPath string, dryRun bool) error {
 		}
 	} else {
 		// Legacy single table synchronization
-		records, err := loadDataFromFile(&config)
+		setPhase(ctx, "loading file")
+		records, err := openDataFile(&config, runStatsFrom(ctx).RunID)
 		if err != nil {
 			return fmt.Errorf("file reading error: %w", err)
 		}
-		log.Printf("Loaded %d records from file.", len(records))
+		// A streamed file is read here for the first time, so its errors are reported as reading errors too
+		count, err := countRecords(records)
+		if err != nil {
+			return fmt.Errorf("file reading error: %w", err)
+		}
+		tableCtx := withLogTable(ctx, config.Sync.TableName)
+		currentTableStats(tableCtx).RowsRead = count
+		slog.InfoContext(tableCtx, "Loaded records from file", "records", count)
 
-		// 🚨 STRICT PRIMARY KEY VALIDATION - Always enforced for data safety
+		if len(config.Sync.Scope) > 0 {
+			setPhase(ctx, "checking scope")
+			if err := checkScope(config, records); err != nil {
+				return fmt.Errorf("scope check failed: %w", err)
+			}
+		}
+
+		// 🚨 PRIMARY KEY VALIDATION - Strict unless primaryKeyValidation.mode is warn
 		if config.Sync.SyncMode == SyncModeDiff && config.Sync.PrimaryKey != "" {
-			validator := NewPrimaryKeyValidator()
-			validationResult, err := validator.ValidateAllRecords(records, config.Sync.PrimaryKey)
+			setPhase(ctx, "validating primary keys")
+			records, err = validatePrimaryKeys(tableCtx, config.Sync.PrimaryKeyValidation, records, config.Sync.PrimaryKey)
 			if err != nil {
-				// Report detailed validation failure
-				validator.ReportValidationFailure(validationResult)
 				return fmt.Errorf("primary key validation failed: %w", err)
 			}
 		}
 
+		if len(config.Sync.Validate) > 0 {
+			setPhase(ctx, "validating records")
+			if err := validateRecords(tableCtx, config.Sync.Validate, records); err != nil {
+				return fmt.Errorf("record validation failed: %w", err)
+			}
+		}
+
+		if config.Sync.SchemaCheck {
+			setPhase(ctx, "checking values against the table schema")
+			if err := checkTableSchema(tableCtx, db, config, records); err != nil {
+				return fmt.Errorf("schema check failed: %w", err)
+			}
+		}
+
 		err = syncData(ctx, db, config, records)
 		if err != nil {
 			return fmt.Errorf("data synchronization error: %w", err)
 		}
 	}
-
-	log.Println("Data synchronization completed successfully.")
 	return nil
 }
 
-// loadDataFromFile loads data from file using the integrated loader functionality
-func loadDataFromFile(config *Config) ([]DataRecord, error) {
-	dataLoader, err := GetLoader(config.Sync.FilePath)
+// openDataFile opens the data file using the integrated loader functionality as a record source, which sets the
+// constants, applies the transform rules and parses the dates; runID replaces ${RUN_ID} in constants
+// Parquet files are streamed batch by batch; other files are loaded here.
+func openDataFile(config *Config, runID string) (recordSource, error) {
+	sourceLoc, err := loadTimezone(config.Sync.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	dbLoc := dbLocation(config.DB)
+	dataLoader, err := GetLoaderWithOptions(config.Sync.FilePath, LoaderOptions{
+		Encoding: config.Sync.Encoding,
+		CSV:      config.Sync.CSV,
+		JSON:     config.Sync.JSON,
+		XLSX:     config.Sync.XLSX,
+		Location: cmp.Or(sourceLoc, dbLoc),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error creating loader for %s: %w", config.Sync.FilePath, err)
 	}
-	return dataLoader.Load(config.Sync.Columns)
+	transformer, err := NewTransformer(config.Sync.Transform)
+	if err != nil {
+		return nil, fmt.Errorf("error transforming data from %s: %w", config.Sync.FilePath, err)
+	}
+	return openRecords(dataLoader, fileLoadColumns(config.Sync.Columns, config.Sync.Transform, config.Sync.Constants), &recordPreparer{
+		constants:   config.Sync.Constants,
+		runID:       runID,
+		transformer: transformer,
+		dateFormats: config.Sync.DateFormats,
+		source:      sourceLoc,
+		db:          dbLoc,
+		file:        "from " + config.Sync.FilePath,
+	})
+}
+
+// runConfigCommand implements the "config" subcommand
+func runConfigCommand(args []string, w io.Writer) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("unknown config command, usage: mydatasyncer config print [-config path] [-env name]")
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to the configuration file (default: mydatasyncer.yml)")
+	env := fs.String("env", "", "Environment overlay to apply on top of the configuration file")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	config, err := LoadConfigForEnv(*configPath, *env)
+	if err != nil {
+		return err
+	}
+	return PrintConfig(w, config)
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -703,6 +705,200 @@ sync:
 	})
 }
 
+func TestRunAppConfigErrors(t *testing.T) {
+	t.Run("missing config file is fatal by default", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing.yml")
+		err := RunAppWithOptions(AppOptions{ConfigPath: missing})
+		if err == nil {
+			t.Fatal("Expected error for missing config file")
+		}
+		if !strings.Contains(err.Error(), "configuration error") || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("Expected missing config error, got: %v", err)
+		}
+	})
+
+	t.Run("allow-default-config falls back for missing file", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing.yml")
+		err := RunAppWithOptions(AppOptions{ConfigPath: missing, AllowDefaultConfig: true})
+		if err == nil {
+			t.Fatal("Expected validation error for default config without DSN")
+		}
+		// The default configuration has no DSN, so the run stops at validation instead of loading
+		if !strings.Contains(err.Error(), "DSN is required") {
+			t.Errorf("Expected default config to be used, got: %v", err)
+		}
+	})
+
+	t.Run("allow-default-config does not hide a missing include or overlay", func(t *testing.T) {
+		configFile := filepath.Join(t.TempDir(), "config.yml")
+		err := os.WriteFile(configFile, []byte("include: [missing-base.yml]\n"), 0644)
+		if err != nil {
+			t.Fatalf("Failed to create test config file: %v", err)
+		}
+		err = RunAppWithOptions(AppOptions{ConfigPath: configFile, AllowDefaultConfig: true})
+		if err == nil || strings.Contains(err.Error(), "DSN is required") || !strings.Contains(err.Error(), "missing-base.yml") {
+			t.Errorf("Expected missing include error, got: %v", err)
+		}
+
+		if err := os.WriteFile(configFile, []byte("db:\n  dsn: \"user:password@tcp(127.0.0.1:1)/testdb\"\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test config file: %v", err)
+		}
+		err = RunAppWithOptions(AppOptions{ConfigPath: configFile, Env: "prod", AllowDefaultConfig: true})
+		if err == nil || !strings.Contains(err.Error(), "error loading overlay for environment 'prod'") {
+			t.Errorf("Expected missing overlay error, got: %v", err)
+		}
+	})
+
+	t.Run("table selection requires multi-table config", func(t *testing.T) {
+		configFile := filepath.Join(t.TempDir(), "single.yml")
+		err := os.WriteFile(configFile, []byte("db:\n  dsn: \"user:password@tcp(127.0.0.1:3306)/testdb\"\nsync:\n  filePath: data.csv\n  tableName: products\n"), 0644)
+		if err != nil {
+			t.Fatalf("Failed to create test config file: %v", err)
+		}
+		err = RunAppWithOptions(AppOptions{ConfigPath: configFile, TableSelection: TableSelection{Tables: []string{"products"}}})
+		if err == nil || !strings.Contains(err.Error(), "require a multi-table configuration") {
+			t.Errorf("Expected table selection error, got: %v", err)
+		}
+	})
+
+	t.Run("unknown selected table is a configuration error", func(t *testing.T) {
+		err := RunAppWithOptions(AppOptions{
+			ConfigPath:     "testdata/e2e_multi_table_config.yml",
+			TableSelection: TableSelection{Tables: []string{"no_such_table"}},
+		})
+		if err == nil || !strings.Contains(err.Error(), "unknown table 'no_such_table'") {
+			t.Errorf("Expected unknown table error, got: %v", err)
+		}
+	})
+
+	t.Run("allow-default-config does not hide invalid config", func(t *testing.T) {
+		configFile := filepath.Join(t.TempDir(), "typo.yml")
+		err := os.WriteFile(configFile, []byte("sync:\n  tableNam: products\n"), 0644)
+		if err != nil {
+			t.Fatalf("Failed to create test config file: %v", err)
+		}
+		err = RunAppWithOptions(AppOptions{ConfigPath: configFile, AllowDefaultConfig: true})
+		if err == nil {
+			t.Fatal("Expected error for invalid config")
+		}
+		if !strings.Contains(err.Error(), `unknown field "tableNam"`) {
+			t.Errorf("Expected unknown field error, got: %v", err)
+		}
+	})
+}
+
+func TestRunAppReports(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yml")
+	// Nothing listens on port 1, so the run fails while connecting
+	err := os.WriteFile(configFile, []byte(`
+timeout: 5s
+db:
+  dsn: "user:reportsecret@tcp(127.0.0.1:1)/testdb"
+sync:
+  filePath: data.csv
+  tableName: products
+  primaryKey: id
+report:
+  json: `+filepath.Join(dir, "config-report.json")+`
+`), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	jsonPath := filepath.Join(dir, "report.json")
+	htmlPath := filepath.Join(dir, "report.html")
+	err = RunAppWithOptions(AppOptions{ConfigPath: configFile, RunID: "run-42", ReportJSON: jsonPath, ReportHTML: htmlPath})
+	if err == nil {
+		t.Fatal("Expected connection error")
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("Expected JSON report: %v", err)
+	}
+	var report RunReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Invalid JSON report: %v", err)
+	}
+	if report.RunID != "run-42" || report.Status != ReportStatusFailed || !strings.Contains(report.Error, "database connectivity error") {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if strings.Contains(string(data), "reportsecret") {
+		t.Error("Secret leaked into the JSON report")
+	}
+
+	if _, err := os.Stat(htmlPath); err != nil {
+		t.Errorf("Expected HTML report: %v", err)
+	}
+	// The command-line path replaces the configured one
+	if _, err := os.Stat(filepath.Join(dir, "config-report.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no report at the configured path, got %v", err)
+	}
+}
+
+func TestRunAppReportsSelectionError(t *testing.T) {
+	jsonPath := filepath.Join(t.TempDir(), "report.json")
+	err := RunAppWithOptions(AppOptions{
+		ConfigPath:     "testdata/e2e_multi_table_config.yml",
+		ReportJSON:     jsonPath,
+		TableSelection: TableSelection{Tables: []string{"no_such_table"}},
+	})
+	if err == nil {
+		t.Fatal("Expected table selection error")
+	}
+
+	// A failed selection is reported like a failed sync
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("Expected JSON report: %v", err)
+	}
+	var report RunReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Invalid JSON report: %v", err)
+	}
+	if report.Status != ReportStatusFailed || !strings.Contains(report.Error, "unknown table 'no_such_table'") {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
+
+func TestRunConfigCommand(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yml")
+	err := os.WriteFile(configFile, []byte(`
+defaults:
+  syncMode: overwrite
+db:
+  dsn: "user:cmdsecret@tcp(127.0.0.1:3306)/testdb"
+tables:
+  - name: products
+    filePath: products.csv
+`), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	t.Run("print merged config", func(t *testing.T) {
+		var buf strings.Builder
+		if err := runConfigCommand([]string{"print", "-config", configFile}, &buf); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(buf.String(), "syncMode: overwrite") {
+			t.Errorf("Expected defaults to be applied in output, got:\n%s", buf.String())
+		}
+		if strings.Contains(buf.String(), "cmdsecret") {
+			t.Errorf("Expected password to be masked, got:\n%s", buf.String())
+		}
+	})
+
+	t.Run("unknown subcommand", func(t *testing.T) {
+		var buf strings.Builder
+		if err := runConfigCommand([]string{"show"}, &buf); err == nil {
+			t.Error("Expected error for unknown config subcommand")
+		}
+	})
+}
+
 func TestLoadDataFromFileErrorHandling(t *testing.T) {
 	t.Run("unsupported file extension", func(t *testing.T) {
 		config := &Config{
@@ -712,7 +908,7 @@ func TestLoadDataFromFileErrorHandling(t *testing.T) {
 			},
 		}
 
-		_, err := loadDataFromFile(config)
+		_, err := openDataFile(config, "")
 		if err == nil {
 			t.Error("Expected error for unsupported file extension")
 		}
@@ -729,7 +925,7 @@ func TestLoadDataFromFileErrorHandling(t *testing.T) {
 			},
 		}
 
-		_, err := loadDataFromFile(config)
+		_, err := openDataFile(config, "")
 		if err == nil {
 			t.Error("Expected error for non-existent file")
 		}
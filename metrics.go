@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for pushing metrics to a Pushgateway
+const (
+	DefaultMetricsJob     = "mydatasyncer"
+	DefaultMetricsTimeout = 10 * time.Second
+)
+
+// Metrics that carry over from previous runs, because a single run cannot know them
+const (
+	metricLastSuccess = "mydatasyncer_last_success_timestamp_seconds"
+	metricFailures    = "mydatasyncer_failures_total"
+)
+
+// metricSample is one sample of a metric family
+type metricSample struct {
+	labels [][2]string
+	value  float64
+}
+
+// metricFamily is a metric with its HELP and TYPE metadata
+type metricFamily struct {
+	name    string
+	help    string
+	typ     string
+	samples []metricSample
+}
+
+// buildMetrics converts the run statistics into metric families
+// previous holds the carried-over metrics of the last export (nil if unknown); runErr is the result of the run.
+func buildMetrics(stats *RunStats, runErr error, previous map[string]float64) []metricFamily {
+	success := 0.0
+	if runErr == nil {
+		success = 1
+	}
+	gauge := func(name, help string, value float64) metricFamily {
+		return metricFamily{name: name, help: help, typ: "gauge", samples: []metricSample{{value: value}}}
+	}
+
+	families := []metricFamily{
+		gauge("mydatasyncer_last_run_timestamp_seconds", "Time the last run finished, in seconds since the epoch.", unixSeconds(stats.End)),
+		gauge("mydatasyncer_last_run_success", "Whether the last run succeeded (1) or failed (0).", success),
+		gauge("mydatasyncer_last_run_duration_seconds", "Duration of the last run.", stats.Duration().Seconds()),
+		gauge("mydatasyncer_last_run_retries", "Number of transaction retries in the last run.", float64(stats.Retries)),
+	}
+
+	if runErr == nil {
+		families = append(families, gauge(metricLastSuccess, "Time the last successful run finished, in seconds since the epoch.", unixSeconds(stats.End)))
+	} else if last, ok := previous[metricLastSuccess]; ok {
+		families = append(families, gauge(metricLastSuccess, "Time the last successful run finished, in seconds since the epoch.", last))
+	}
+
+	// The failure counter needs the previous value; without one it is left out rather than reset
+	if previous != nil {
+		failures := previous[metricFailures]
+		if runErr != nil {
+			failures++
+		}
+		families = append(families, metricFamily{name: metricFailures, help: "Number of failed runs.", typ: "counter",
+			samples: []metricSample{{value: failures}}})
+	}
+
+	rows := metricFamily{name: "mydatasyncer_table_rows", help: "Rows processed per table and operation in the last run.", typ: "gauge"}
+	durations := metricFamily{name: "mydatasyncer_table_phase_duration_seconds", help: "Time spent per table and phase in the last run.", typ: "gauge"}
+	for _, t := range stats.Tables() {
+		for _, op := range []struct {
+			name  string
+			value int
+		}{
+			{"read", t.RowsRead},
+			{"inserted", t.Inserted},
+			{"updated", t.Updated},
+			{"deleted", t.Deleted},
+			{"rejected", t.Skipped()},
+		} {
+			rows.samples = append(rows.samples, metricSample{labels: [][2]string{{"table", t.Table}, {"operation", op.name}}, value: float64(op.value)})
+		}
+		durations.samples = append(durations.samples,
+			metricSample{labels: [][2]string{{"table", t.Table}, {"phase", "diff"}}, value: t.DiffDuration.Seconds()},
+			metricSample{labels: [][2]string{{"table", t.Table}, {"phase", "write"}}, value: t.WriteDuration.Seconds()})
+	}
+	if len(rows.samples) > 0 {
+		families = append(families, rows, durations)
+	}
+	return families
+}
+
+// writeMetrics writes the metric families in the Prometheus text exposition format
+func writeMetrics(w io.Writer, families []metricFamily) error {
+	var buf bytes.Buffer
+	for _, family := range families {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", family.name, family.typ)
+		for _, sample := range family.samples {
+			buf.WriteString(family.name)
+			if len(sample.labels) > 0 {
+				pairs := make([]string, 0, len(sample.labels))
+				for _, label := range sample.labels {
+					pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", label[0], escapeLabelValue(label[1])))
+				}
+				buf.WriteString("{" + strings.Join(pairs, ",") + "}")
+			}
+			buf.WriteString(" " + strconv.FormatFloat(sample.value, 'f', -1, 64) + "\n")
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// escapeLabelValue escapes a label value for the text exposition format
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// unixSeconds returns t in seconds since the epoch
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+// readPreviousMetrics reads the carried-over metrics from a textfile written by an earlier run
+// A missing file is not an error: it simply has no previous values.
+func readPreviousMetrics(path string) (map[string]float64, error) {
+	previous := make(map[string]float64)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return previous, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok || (name != metricLastSuccess && name != metricFailures) {
+			continue
+		}
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			previous[name] = v
+		}
+	}
+	return previous, scanner.Err()
+}
+
+// writeFileAtomic replaces path with data so that readers never see a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // Fails harmlessly once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close() //nolint:errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// metricsJob returns the Pushgateway job name
+func metricsJob(metrics MetricsConfig) string {
+	if metrics.Job == "" {
+		return DefaultMetricsJob
+	}
+	return metrics.Job
+}
+
+// readPushgatewayMetrics reads the carried-over metrics of our group from the Pushgateway API
+// A group that was never pushed is not an error: it simply has no previous values.
+func readPushgatewayMetrics(ctx context.Context, metrics MetricsConfig) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(metrics.Pushgateway, "/")+"/api/v1/metrics", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pushgateway request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("pushgateway returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	// Each group holds its grouping labels and one entry per metric family
+	var body struct {
+		Data []map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode Pushgateway metrics: %w", err)
+	}
+	want := map[string]string{"job": metricsJob(metrics)}
+	maps.Copy(want, metrics.Labels)
+
+	previous := make(map[string]float64)
+	for _, group := range body.Data {
+		var labels map[string]string
+		if err := json.Unmarshal(group["labels"], &labels); err != nil || !sameGroupingLabels(labels, want) {
+			continue
+		}
+		for _, name := range []string{metricLastSuccess, metricFailures} {
+			var family struct {
+				Metrics []struct {
+					Value string `json:"value"`
+				} `json:"metrics"`
+			}
+			raw, ok := group[name]
+			if !ok || json.Unmarshal(raw, &family) != nil || len(family.Metrics) == 0 {
+				continue
+			}
+			if v, err := strconv.ParseFloat(family.Metrics[0].Value, 64); err == nil {
+				previous[name] = v
+			}
+		}
+	}
+	return previous, nil
+}
+
+// sameGroupingLabels reports whether two label sets are equal, treating empty values as absent
+func sameGroupingLabels(a, b map[string]string) bool {
+	nonEmpty := func(labels map[string]string) map[string]string {
+		out := make(map[string]string, len(labels))
+		for name, value := range labels {
+			if value != "" {
+				out[name] = value
+			}
+		}
+		return out
+	}
+	return maps.Equal(nonEmpty(a), nonEmpty(b))
+}
+
+// pushMetrics sends metrics to a Pushgateway
+// A successful run replaces the whole group (PUT). A failed run only replaces the metrics it sends (POST),
+// so that the last success timestamp of an earlier run is kept.
+func pushMetrics(ctx context.Context, metrics MetricsConfig, body []byte, replace bool) error {
+	target := strings.TrimRight(metrics.Pushgateway, "/") + "/metrics/job/" + url.PathEscape(metricsJob(metrics))
+
+	labelNames := make([]string, 0, len(metrics.Labels))
+	for name := range metrics.Labels {
+		labelNames = append(labelNames, name)
+	}
+	sort.Strings(labelNames)
+	for _, name := range labelNames {
+		target += "/" + url.PathEscape(name) + "/" + url.PathEscape(metrics.Labels[name])
+	}
+
+	method := http.MethodPost
+	if replace {
+		method = http.MethodPut
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Pushgateway request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("pushgateway returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// exportMetrics writes the metrics of a finished run to the configured textfile and Pushgateway
+// Export problems are logged but never change the result of the run.
+func exportMetrics(metrics MetricsConfig, stats *RunStats, runErr error) {
+	if metrics.Textfile == "" && metrics.Pushgateway == "" {
+		return
+	}
+	if stats.DryRun {
+		slog.Info("Dry-run: metrics are not exported")
+		return
+	}
+
+	if metrics.Textfile != "" {
+		if err := exportMetricsTextfile(metrics.Textfile, stats, runErr); err != nil {
+			slog.Warn("Failed to write metrics textfile", "path", metrics.Textfile, "error", err)
+		} else {
+			slog.Info("Wrote metrics textfile", "path", metrics.Textfile)
+		}
+	}
+
+	if metrics.Pushgateway != "" {
+		if err := exportMetricsPushgateway(metrics, stats, runErr); err != nil {
+			slog.Warn("Failed to push metrics", "pushgateway", metrics.Pushgateway, "error", err)
+		} else {
+			slog.Info("Pushed metrics", "pushgateway", metrics.Pushgateway)
+		}
+	}
+}
+
+// exportMetricsPushgateway pushes the metrics to the Pushgateway, carrying over the failure count
+// from the group pushed by the previous run
+// If the previous values cannot be read, the metrics are pushed without the failure counter.
+func exportMetricsPushgateway(metrics MetricsConfig, stats *RunStats, runErr error) error {
+	timeout := metrics.Timeout
+	if timeout == 0 {
+		timeout = DefaultMetricsTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	previous, err := readPushgatewayMetrics(ctx, metrics)
+	if err != nil {
+		slog.Warn("Failed to read previous metrics from Pushgateway; pushing without the failure counter",
+			"pushgateway", metrics.Pushgateway, "error", err)
+		previous = nil
+	}
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, buildMetrics(stats, runErr, previous)); err != nil {
+		return err
+	}
+	return pushMetrics(ctx, metrics, buf.Bytes(), runErr == nil)
+}
+
+// exportMetricsTextfile writes the metrics for the node_exporter textfile collector,
+// carrying over the last success time and failure count from the previous file
+func exportMetricsTextfile(path string, stats *RunStats, runErr error) error {
+	previous, err := readPreviousMetrics(path)
+	if err != nil {
+		return fmt.Errorf("failed to read previous metrics: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, buildMetrics(stats, runErr, previous)); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
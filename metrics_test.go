@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRunStats returns finished run statistics with fixed times and one table
+func newTestRunStats() *RunStats {
+	stats := NewRunStats(false)
+	stats.Start = time.Unix(1700000000, 0)
+	stats.End = time.Unix(1700000090, 0)
+	stats.Retries = 1
+	table := stats.Table("orders")
+	table.RowsRead = 100
+	table.Inserted = 10
+	table.Updated = 5
+	table.Deleted = 2
+	table.Rejected = 1
+	table.DiffDuration = 1500 * time.Millisecond
+	table.WriteDuration = 3 * time.Second
+	return stats
+}
+
+func TestWriteMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, buildMetrics(newTestRunStats(), nil, nil)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `# HELP mydatasyncer_last_run_timestamp_seconds Time the last run finished, in seconds since the epoch.
+# TYPE mydatasyncer_last_run_timestamp_seconds gauge
+mydatasyncer_last_run_timestamp_seconds 1700000090
+# HELP mydatasyncer_last_run_success Whether the last run succeeded (1) or failed (0).
+# TYPE mydatasyncer_last_run_success gauge
+mydatasyncer_last_run_success 1
+# HELP mydatasyncer_last_run_duration_seconds Duration of the last run.
+# TYPE mydatasyncer_last_run_duration_seconds gauge
+mydatasyncer_last_run_duration_seconds 90
+# HELP mydatasyncer_last_run_retries Number of transaction retries in the last run.
+# TYPE mydatasyncer_last_run_retries gauge
+mydatasyncer_last_run_retries 1
+# HELP mydatasyncer_last_success_timestamp_seconds Time the last successful run finished, in seconds since the epoch.
+# TYPE mydatasyncer_last_success_timestamp_seconds gauge
+mydatasyncer_last_success_timestamp_seconds 1700000090
+# HELP mydatasyncer_table_rows Rows processed per table and operation in the last run.
+# TYPE mydatasyncer_table_rows gauge
+mydatasyncer_table_rows{table="orders",operation="read"} 100
+mydatasyncer_table_rows{table="orders",operation="inserted"} 10
+mydatasyncer_table_rows{table="orders",operation="updated"} 5
+mydatasyncer_table_rows{table="orders",operation="deleted"} 2
+mydatasyncer_table_rows{table="orders",operation="rejected"} 1
+# HELP mydatasyncer_table_phase_duration_seconds Time spent per table and phase in the last run.
+# TYPE mydatasyncer_table_phase_duration_seconds gauge
+mydatasyncer_table_phase_duration_seconds{table="orders",phase="diff"} 1.5
+mydatasyncer_table_phase_duration_seconds{table="orders",phase="write"} 3
+`
+	if buf.String() != want {
+		t.Errorf("Unexpected metrics output:\n%s", buf.String())
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if got := escapeLabelValue("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("escapeLabelValue() = %q", got)
+	}
+}
+
+func TestExportMetricsTextfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mydatasyncer.prom")
+	read := func() string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read metrics file: %v", err)
+		}
+		return string(data)
+	}
+
+	// First run succeeds
+	if err := exportMetricsTextfile(path, newTestRunStats(), nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out := read(); !strings.Contains(out, "mydatasyncer_failures_total 0\n") ||
+		!strings.Contains(out, "mydatasyncer_last_success_timestamp_seconds 1700000090\n") {
+		t.Errorf("Unexpected metrics after success:\n%s", out)
+	}
+
+	// Second run fails: the last success time carries over and the failure is counted
+	failed := newTestRunStats()
+	failed.End = time.Unix(1700003600, 0)
+	if err := exportMetricsTextfile(path, failed, errors.New("deadlock")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out := read()
+	for _, want := range []string{
+		"mydatasyncer_last_run_success 0\n",
+		"mydatasyncer_last_run_timestamp_seconds 1700003600\n",
+		"mydatasyncer_last_success_timestamp_seconds 1700000090\n",
+		"mydatasyncer_failures_total 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in metrics after failure:\n%s", want, out)
+		}
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("Failed to list directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the metrics file, got %d entries", len(entries))
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	type request struct {
+		method, path, contentType, body string
+	}
+	var got request
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = request{method: r.Method, path: r.URL.Path, contentType: r.Header.Get("Content-Type"), body: string(body)}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	metrics := MetricsConfig{Pushgateway: server.URL + "/", Labels: map[string]string{"instance": "batch-01", "env": "prod"}}
+
+	t.Run("successful run replaces the group", func(t *testing.T) {
+		if err := pushMetrics(context.Background(), metrics, []byte("metric 1\n"), true); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := request{
+			method:      http.MethodPut,
+			path:        "/metrics/job/mydatasyncer/env/prod/instance/batch-01",
+			contentType: "text/plain; version=0.0.4",
+			body:        "metric 1\n",
+		}
+		if got != want {
+			t.Errorf("Got request %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("failed run only updates the sent metrics", func(t *testing.T) {
+		if err := pushMetrics(context.Background(), MetricsConfig{Pushgateway: server.URL, Job: "nightly"}, []byte("metric 0\n"), false); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got.method != http.MethodPost || got.path != "/metrics/job/nightly" {
+			t.Errorf("Unexpected request %+v", got)
+		}
+	})
+
+	t.Run("error status is reported", func(t *testing.T) {
+		status = http.StatusBadRequest
+		defer func() { status = http.StatusOK }()
+		err := pushMetrics(context.Background(), metrics, []byte("metric 1\n"), true)
+		if err == nil || !strings.Contains(err.Error(), "400") {
+			t.Errorf("Expected error with status 400, got %v", err)
+		}
+	})
+
+	t.Run("exportMetrics pushes without carried-over counters when they cannot be read", func(t *testing.T) {
+		exportMetrics(MetricsConfig{Pushgateway: server.URL}, newTestRunStats(), errors.New("deadlock"))
+		if got.method != http.MethodPost {
+			t.Errorf("Expected POST for a failed run, got %s", got.method)
+		}
+		if strings.Contains(got.body, metricFailures) || strings.Contains(got.body, metricLastSuccess) {
+			t.Errorf("Expected no carried-over metrics in the push:\n%s", got.body)
+		}
+		if !strings.Contains(got.body, "mydatasyncer_last_run_success 0\n") {
+			t.Errorf("Expected the failure to be reported:\n%s", got.body)
+		}
+	})
+
+	t.Run("dry-run does not export", func(t *testing.T) {
+		got = request{}
+		stats := newTestRunStats()
+		stats.DryRun = true
+		exportMetrics(MetricsConfig{Pushgateway: server.URL}, stats, nil)
+		if got.method != "" {
+			t.Errorf("Expected no request in dry-run mode, got %+v", got)
+		}
+	})
+}
+
+func TestExportMetricsPushgateway(t *testing.T) {
+	// Groups as returned by the Pushgateway API; only the first one belongs to this job
+	const groups = `{"status":"success","data":[
+		{"labels":{"job":"mydatasyncer","instance":"batch-01"},
+		 "mydatasyncer_failures_total":{"type":"COUNTER","metrics":[{"labels":{"job":"mydatasyncer","instance":"batch-01"},"value":"2"}]},
+		 "mydatasyncer_last_success_timestamp_seconds":{"type":"GAUGE","metrics":[{"labels":{"job":"mydatasyncer","instance":"batch-01"},"value":"1690000000"}]}},
+		{"labels":{"job":"mydatasyncer","instance":"batch-02"},
+		 "mydatasyncer_failures_total":{"type":"COUNTER","metrics":[{"labels":{"job":"mydatasyncer","instance":"batch-02"},"value":"99"}]}}
+	]}`
+	var method, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/metrics" {
+			io.WriteString(w, groups) //nolint:errcheck
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		method, body = r.Method, string(data)
+	}))
+	defer server.Close()
+	metrics := MetricsConfig{Pushgateway: server.URL, Labels: map[string]string{"instance": "batch-01"}}
+
+	tests := []struct {
+		name       string
+		runErr     error
+		wantMethod string
+		want       []string
+	}{
+		{
+			name:       "failed run increments the previous count",
+			runErr:     errors.New("deadlock"),
+			wantMethod: http.MethodPost,
+			want:       []string{"mydatasyncer_failures_total 3\n", "mydatasyncer_last_success_timestamp_seconds 1690000000\n"},
+		},
+		{
+			name:       "successful run keeps the previous count",
+			wantMethod: http.MethodPut,
+			want:       []string{"mydatasyncer_failures_total 2\n", "mydatasyncer_last_success_timestamp_seconds 1700000090\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := exportMetricsPushgateway(metrics, newTestRunStats(), tt.runErr); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if method != tt.wantMethod {
+				t.Errorf("Expected %s, got %s", tt.wantMethod, method)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("Expected %q in pushed metrics:\n%s", want, body)
+				}
+			}
+		})
+	}
+
+	t.Run("group not pushed yet starts at zero", func(t *testing.T) {
+		other := MetricsConfig{Pushgateway: server.URL, Job: "nightly"}
+		if err := exportMetricsPushgateway(other, newTestRunStats(), nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(body, "mydatasyncer_failures_total 0\n") {
+			t.Errorf("Expected a zero failure count:\n%s", body)
+		}
+	})
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Notification events
+const (
+	NotifyEventSuccess = "success"
+	NotifyEventFailure = "failure"
+	NotifyEventDrift   = "drift"
+)
+
+// notifyRetryBackoff is the wait before the first retry of a notification; it doubles for each further retry
+var notifyRetryBackoff = time.Second
+
+// notifyMaxRetryBackoff caps the wait between notification attempts
+const notifyMaxRetryBackoff = 10 * time.Second
+
+// notifyOutputLimit caps how much of a failed command's output or webhook's response is reported
+const notifyOutputLimit = 512
+
+// NotificationPayload is the generic webhook payload and the JSON a notification command receives on stdin
+type NotificationPayload struct {
+	Event  string    `json:"event"` // "success", "failure" or "drift"
+	Text   string    `json:"text"`  // One-line human-readable summary
+	Report RunReport `json:"report"`
+}
+
+// registerNotifySecrets registers webhook URLs and credential headers as secrets; webhook URLs usually contain a token
+func registerNotifySecrets(notify NotifyConfig) {
+	for _, targets := range [][]NotifyTarget{notify.OnSuccess, notify.OnFailure, notify.OnDrift} {
+		for _, target := range targets {
+			registerSecret(target.Webhook)
+			for name, value := range target.Headers {
+				if isCredentialHeader(name) {
+					registerSecret(value)
+				}
+			}
+		}
+	}
+}
+
+// credentialHeaderWords are the header name fragments that mark a header as carrying a credential
+var credentialHeaderWords = []string{"auth", "token", "key", "secret", "signature", "cookie", "password"}
+
+// isCredentialHeader reports whether a header such as Authorization or X-Api-Key carries a credential.
+// Other headers, such as Content-Type, are not masked: their values are common words that would be
+// masked everywhere in the logs.
+func isCredentialHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range credentialHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationEvents returns the events a finished run triggers
+func notificationEvents(report RunReport) []string {
+	if report.Status != ReportStatusSuccess {
+		return []string{NotifyEventFailure}
+	}
+	events := []string{NotifyEventSuccess}
+	if len(driftedTables(report)) > 0 {
+		events = append(events, NotifyEventDrift)
+	}
+	return events
+}
+
+// driftedTables returns the tables in which the run found rows to insert, update or delete
+func driftedTables(report RunReport) []string {
+	var tables []string
+	for _, t := range report.Tables {
+		if t.Inserted+t.Updated+t.Deleted > 0 {
+			tables = append(tables, t.Table)
+		}
+	}
+	return tables
+}
+
+// notificationText returns the one-line summary of a notification
+func notificationText(event string, report RunReport) string {
+	prefix := ""
+	if report.DryRun {
+		prefix = "[dry-run] "
+	}
+	duration := time.Duration(report.DurationSeconds * float64(time.Second)).Round(time.Millisecond)
+	counts := fmt.Sprintf("%d inserted, %d updated, %d deleted", report.Totals.Inserted, report.Totals.Updated, report.Totals.Deleted)
+
+	switch event {
+	case NotifyEventFailure:
+		return fmt.Sprintf("%smydatasyncer run %s failed after %s: %s", prefix, report.RunID, duration, report.Error)
+	case NotifyEventDrift:
+		return fmt.Sprintf("%smydatasyncer run %s found differences in %s: %s",
+			prefix, report.RunID, strings.Join(driftedTables(report), ", "), counts)
+	default:
+		return fmt.Sprintf("%smydatasyncer run %s succeeded in %s: %d tables, %s, %d skipped",
+			prefix, report.RunID, duration, len(report.Tables), counts, report.Totals.Skipped)
+	}
+}
+
+// webhookPayload renders the webhook body for the target's format
+func webhookPayload(format, event string, report RunReport) ([]byte, error) {
+	text := notificationText(event, report)
+	switch format {
+	case NotifyFormatSlack:
+		return json.Marshal(map[string]any{"text": text})
+	case NotifyFormatTeams:
+		return json.Marshal(teamsMessage(event, text, report))
+	default:
+		return json.Marshal(NotificationPayload{Event: event, Text: text, Report: report})
+	}
+}
+
+// teamsMessage builds an Adaptive Card message as accepted by Teams incoming webhooks and workflows
+func teamsMessage(event, text string, report RunReport) map[string]any {
+	color := "Good"
+	if event == NotifyEventFailure {
+		color = "Attention"
+	} else if event == NotifyEventDrift {
+		color = "Warning"
+	}
+	facts := []map[string]string{
+		{"title": "Run ID", "value": report.RunID},
+		{"title": "Status", "value": report.Status},
+		{"title": "Dry-run", "value": fmt.Sprint(report.DryRun)},
+		{"title": "Duration", "value": fmt.Sprintf("%.3fs", report.DurationSeconds)},
+	}
+	for _, t := range report.Tables {
+		facts = append(facts, map[string]string{
+			"title": t.Table,
+			"value": fmt.Sprintf("%d inserted, %d updated, %d deleted, %d skipped", t.Inserted, t.Updated, t.Deleted, t.Skipped),
+		})
+	}
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body": []map[string]any{
+					{"type": "TextBlock", "text": "mydatasyncer: " + event, "weight": "Bolder", "size": "Medium", "color": color},
+					{"type": "TextBlock", "text": text, "wrap": true},
+					{"type": "FactSet", "facts": facts},
+				},
+			},
+		}},
+	}
+}
+
+// sendNotifications sends the notifications for every event the run triggered
+// Notification problems are logged but never change the result of the run.
+func sendNotifications(notify NotifyConfig, report RunReport) {
+	for _, event := range notificationEvents(report) {
+		targets := notify.OnSuccess
+		switch event {
+		case NotifyEventFailure:
+			targets = notify.OnFailure
+		case NotifyEventDrift:
+			targets = notify.OnDrift
+		}
+
+		for i, target := range targets {
+			// Webhook URLs contain tokens and are masked in logs, so targets are identified by position
+			attrs := []any{"event", event, "target", i}
+			if err := notifyWithRetry(notify, target, event, report); err != nil {
+				slog.Warn("Failed to send notification", append(attrs, "error", err)...)
+				continue
+			}
+			slog.Info("Sent notification", attrs...)
+		}
+	}
+}
+
+// notifyWithRetry sends one notification, retrying failed attempts with backoff
+func notifyWithRetry(notify NotifyConfig, target NotifyTarget, event string, report RunReport) error {
+	maxAttempts := notify.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultNotifyMaxAttempts
+	}
+	timeout := notify.Timeout
+	if timeout == 0 {
+		timeout = DefaultNotifyTimeout
+	}
+	backoff := RetryConfig{InitialBackoff: notifyRetryBackoff, MaxBackoff: max(notifyMaxRetryBackoff, notifyRetryBackoff)}
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := sendNotification(ctx, target, event, report)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if !isRetryableNotifyError(err) {
+			return err
+		}
+		if attempt >= maxAttempts {
+			if maxAttempts > 1 {
+				return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+			return err
+		}
+
+		wait := retryBackoff(backoff, attempt, rand.Float64)
+		slog.Warn("Notification attempt failed, retrying", "event", event, "attempt", attempt, "max_attempts", maxAttempts,
+			"error", err, "backoff", wait.Round(time.Millisecond))
+		time.Sleep(wait)
+	}
+}
+
+// sendNotification makes a single attempt to notify the target
+func sendNotification(ctx context.Context, target NotifyTarget, event string, report RunReport) error {
+	if len(target.Command) > 0 {
+		return runNotifyCommand(ctx, target.Command, event, report)
+	}
+	body, err := webhookPayload(target.Format, event, report)
+	if err != nil {
+		return fmt.Errorf("failed to render notification: %w", err)
+	}
+	return postWebhook(ctx, target, body)
+}
+
+// postWebhook posts a JSON notification to the target's webhook
+func postWebhook(ctx context.Context, target NotifyTarget, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range target.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, notifyOutputLimit))
+		return &webhookStatusError{status: resp.Status, code: resp.StatusCode, body: strings.TrimSpace(string(msg))}
+	}
+	return nil
+}
+
+// webhookStatusError is a webhook response with a status other than 2xx
+type webhookStatusError struct {
+	status string
+	code   int
+	body   string
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("webhook returned %s: %s", e.status, e.body)
+}
+
+// isRetryableNotifyError reports whether a failed notification attempt may succeed if repeated
+// Client errors other than 429 Too Many Requests, such as a revoked token, fail the same way every time;
+// transport errors, server errors and failed commands are retried.
+func isRetryableNotifyError(err error) bool {
+	var statusErr *webhookStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code/100 != 4 || statusErr.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// runNotifyCommand runs a notification command with the generic payload on stdin
+// The event, status and run ID are also passed as MYDATASYNCER_* environment variables.
+func runNotifyCommand(ctx context.Context, command []string, event string, report RunReport) error {
+	payload, err := json.Marshal(NotificationPayload{Event: event, Text: notificationText(event, report), Report: report})
+	if err != nil {
+		return fmt.Errorf("failed to render notification: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.WaitDelay = time.Second // Do not wait for background processes that keep the output open
+	cmd.Env = append(os.Environ(),
+		"MYDATASYNCER_EVENT="+event,
+		"MYDATASYNCER_STATUS="+report.Status,
+		"MYDATASYNCER_RUN_ID="+report.RunID,
+		fmt.Sprintf("MYDATASYNCER_DRY_RUN=%t", report.DryRun),
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if len(msg) > notifyOutputLimit {
+			msg = msg[:notifyOutputLimit] + "..."
+		}
+		if msg == "" {
+			return fmt.Errorf("command '%s' failed: %w", command[0], err)
+		}
+		return fmt.Errorf("command '%s' failed: %w: %s", command[0], err, msg)
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// newTestNotifyReport returns the report of a successful run that changed the customers table
+func newTestNotifyReport() RunReport {
+	return newRunReport(newTestReportStats(), nil)
+}
+
+func TestNotificationEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		report func() RunReport
+		want   []string
+	}{
+		{
+			name:   "success with changes is also drift",
+			report: newTestNotifyReport,
+			want:   []string{NotifyEventSuccess, NotifyEventDrift},
+		},
+		{
+			name: "success without changes",
+			report: func() RunReport {
+				stats := NewRunStats(false)
+				stats.Table("customers").RowsRead = 10
+				return newRunReport(stats, nil)
+			},
+			want: []string{NotifyEventSuccess},
+		},
+		{
+			name: "failure never reports drift",
+			report: func() RunReport {
+				report := newTestNotifyReport()
+				report.Status = ReportStatusFailed
+				return report
+			},
+			want: []string{NotifyEventFailure},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, notificationEvents(tt.report())); diff != "" {
+				t.Errorf("notificationEvents() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNotificationText(t *testing.T) {
+	report := newTestNotifyReport()
+	tests := []struct {
+		event string
+		want  string
+	}{
+		{NotifyEventSuccess, "mydatasyncer run run-1 succeeded in 1m30s: 2 tables, 7 inserted, 3 updated, 4 deleted, 1 skipped"},
+		{NotifyEventDrift, "mydatasyncer run run-1 found differences in customers, orders: 7 inserted, 3 updated, 4 deleted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			if got := notificationText(tt.event, report); got != tt.want {
+				t.Errorf("notificationText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("failure in dry-run mode", func(t *testing.T) {
+		failed := report
+		failed.DryRun = true
+		failed.Error = "deadlock"
+		want := "[dry-run] mydatasyncer run run-1 failed after 1m30s: deadlock"
+		if got := notificationText(NotifyEventFailure, failed); got != want {
+			t.Errorf("notificationText() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestWebhookPayload(t *testing.T) {
+	report := newTestNotifyReport()
+
+	t.Run("generic payload contains the report", func(t *testing.T) {
+		data, err := webhookPayload("", NotifyEventSuccess, report)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var payload NotificationPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if payload.Event != NotifyEventSuccess || payload.Report.RunID != "run-1" || payload.Text == "" {
+			t.Errorf("Unexpected payload: %+v", payload)
+		}
+	})
+
+	t.Run("slack payload is a text message", func(t *testing.T) {
+		data, err := webhookPayload(NotifyFormatSlack, NotifyEventSuccess, report)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := `{"text":"` + notificationText(NotifyEventSuccess, report) + `"}`
+		if string(data) != want {
+			t.Errorf("Got %s, want %s", data, want)
+		}
+	})
+
+	t.Run("teams payload is an adaptive card", func(t *testing.T) {
+		data, err := webhookPayload(NotifyFormatTeams, NotifyEventFailure, report)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var message struct {
+			Type        string `json:"type"`
+			Attachments []struct {
+				ContentType string `json:"contentType"`
+				Content     struct {
+					Type string           `json:"type"`
+					Body []map[string]any `json:"body"`
+				} `json:"content"`
+			} `json:"attachments"`
+		}
+		if err := json.Unmarshal(data, &message); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if message.Type != "message" || len(message.Attachments) != 1 ||
+			message.Attachments[0].ContentType != "application/vnd.microsoft.card.adaptive" ||
+			message.Attachments[0].Content.Type != "AdaptiveCard" {
+			t.Fatalf("Unexpected message: %s", data)
+		}
+		if color := message.Attachments[0].Content.Body[0]["color"]; color != "Attention" {
+			t.Errorf("Expected color Attention for a failure, got %v", color)
+		}
+	})
+}
+
+func TestSendNotifications(t *testing.T) {
+	defer func(backoff time.Duration) { notifyRetryBackoff = backoff }(notifyRetryBackoff)
+	notifyRetryBackoff = time.Millisecond
+
+	t.Run("webhooks receive the events of the run", func(t *testing.T) {
+		var requests []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload NotificationPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Errorf("Invalid payload: %v", err)
+			}
+			requests = append(requests, r.URL.Path+" "+payload.Event+" "+r.Header.Get("Authorization"))
+		}))
+		defer server.Close()
+
+		sendNotifications(NotifyConfig{
+			OnSuccess: []NotifyTarget{{Webhook: server.URL + "/success", Headers: map[string]string{"Authorization": "Bearer t"}}},
+			OnFailure: []NotifyTarget{{Webhook: server.URL + "/failure"}},
+			OnDrift:   []NotifyTarget{{Webhook: server.URL + "/drift"}},
+		}, newTestNotifyReport())
+
+		want := []string{"/success success Bearer t", "/drift drift "}
+		if diff := cmp.Diff(want, requests); diff != "" {
+			t.Errorf("Requests mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("failed attempts are retried", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) < 3 {
+				http.Error(w, "busy", http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		err := notifyWithRetry(NotifyConfig{}, NotifyTarget{Webhook: server.URL}, NotifyEventSuccess, newTestNotifyReport())
+		if err != nil {
+			t.Errorf("Expected success on the third attempt, got %v", err)
+		}
+		if attempts.Load() != 3 {
+			t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			http.Error(w, "nope", http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		err := notifyWithRetry(NotifyConfig{MaxAttempts: 2}, NotifyTarget{Webhook: server.URL}, NotifyEventSuccess, newTestNotifyReport())
+		if err == nil || !strings.Contains(err.Error(), "giving up after 2 attempts") || !strings.Contains(err.Error(), "nope") {
+			t.Errorf("Expected give-up error, got %v", err)
+		}
+		if attempts.Load() != 2 {
+			t.Errorf("Expected 2 attempts, got %d", attempts.Load())
+		}
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		err := notifyWithRetry(NotifyConfig{MaxAttempts: 3}, NotifyTarget{Webhook: server.URL}, NotifyEventSuccess, newTestNotifyReport())
+		if err == nil || !strings.Contains(err.Error(), "401 Unauthorized: invalid token") {
+			t.Errorf("Expected client error, got %v", err)
+		}
+		if attempts.Load() != 1 {
+			t.Errorf("Expected 1 attempt, got %d", attempts.Load())
+		}
+	})
+
+	t.Run("timeout applies to each attempt", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		start := time.Now()
+		err := notifyWithRetry(NotifyConfig{MaxAttempts: 1, Timeout: 50 * time.Millisecond}, NotifyTarget{Webhook: server.URL},
+			NotifyEventSuccess, newTestNotifyReport())
+		if err == nil {
+			t.Error("Expected timeout error")
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Timeout not applied, took %s", elapsed)
+		}
+	})
+}
+
+func TestRunNotifyCommand(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("requires /bin/sh")
+	}
+	report := newTestNotifyReport()
+
+	t.Run("summary on stdin and event in the environment", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "payload.json")
+		command := []string{"/bin/sh", "-c", `cat > "$1"; echo "$MYDATASYNCER_EVENT $MYDATASYNCER_STATUS $MYDATASYNCER_RUN_ID" >> "$1.env"`, "sh", out}
+		if err := notifyWithRetry(NotifyConfig{}, NotifyTarget{Command: command}, NotifyEventDrift, report); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Command did not write the payload: %v", err)
+		}
+		var payload NotificationPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			t.Fatalf("Invalid payload: %v", err)
+		}
+		if payload.Event != NotifyEventDrift || payload.Report.RunID != "run-1" {
+			t.Errorf("Unexpected payload: %+v", payload)
+		}
+		env, err := os.ReadFile(out + ".env")
+		if err != nil || strings.TrimSpace(string(env)) != "drift success run-1" {
+			t.Errorf("Unexpected environment %q: %v", env, err)
+		}
+	})
+
+	t.Run("failing command reports its output", func(t *testing.T) {
+		command := []string{"/bin/sh", "-c", "echo out of paper >&2; exit 3"}
+		err := runNotifyCommand(t.Context(), command, NotifyEventFailure, report)
+		if err == nil || !strings.Contains(err.Error(), "exit status 3") || !strings.Contains(err.Error(), "out of paper") {
+			t.Errorf("Expected command error with output, got %v", err)
+		}
+	})
+}
+
+func TestRegisterNotifySecrets(t *testing.T) {
+	registerNotifySecrets(NotifyConfig{
+		OnFailure: []NotifyTarget{{
+			Webhook: "https://hooks.example.com/services/T000/B000/n0tify-t0ken",
+			Headers: map[string]string{
+				"Authorization": "Bearer n0tify-header-t0ken",
+				"X-Api-Key":     "n0tify-api-k3y",
+				"Content-Type":  "application/x-n0tify-payload",
+			},
+		}},
+	})
+	masked := maskSecrets("POST https://hooks.example.com/services/T000/B000/n0tify-t0ken with Bearer n0tify-header-t0ken and n0tify-api-k3y")
+	for _, secret := range []string{"n0tify-t0ken", "n0tify-header-t0ken", "n0tify-api-k3y"} {
+		if strings.Contains(masked, secret) {
+			t.Errorf("Secret %q not masked: %s", secret, masked)
+		}
+	}
+	if masked := maskSecrets("Content-Type: application/x-n0tify-payload"); !strings.Contains(masked, "application/x-n0tify-payload") {
+		t.Errorf("Content-Type should not be masked: %s", masked)
+	}
+}
+
+func TestIsCredentialHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "Authorization", want: true},
+		{name: "proxy-authorization", want: true},
+		{name: "X-Api-Key", want: true},
+		{name: "X-Auth-Token", want: true},
+		{name: "X-Hub-Signature-256", want: true},
+		{name: "Cookie", want: true},
+		{name: "Content-Type", want: false},
+		{name: "User-Agent", want: false},
+		{name: "X-Request-Source", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCredentialHeader(tt.name); got != tt.want {
+				t.Errorf("isCredentialHeader(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
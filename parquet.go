@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// parquetBatchRows is the number of rows decoded at a time
+const parquetBatchRows = 1024
+
+// julianUnixEpoch is the Julian day number of 1970-01-01, the epoch of legacy INT96 timestamps
+const julianUnixEpoch = 2440588
+
+// ParquetLoader loads data from Parquet files, reading them row group by row group
+// It is a BatchLoader, so the sync holds only one batch of its records in memory at a time.
+type ParquetLoader struct {
+	FilePath string         // Path to file to be loaded
+	Location *time.Location // Time zone of dates and timestamps that are not adjusted to UTC (default UTC)
+}
+
+// NewParquetLoader creates a new Parquet loader instance
+func NewParquetLoader(filePath string) *ParquetLoader {
+	return &ParquetLoader{
+		FilePath: filePath,
+	}
+}
+
+// parquetColumn is a flat top-level column of a Parquet schema
+type parquetColumn struct {
+	name  string
+	index int // Index of the leaf column, as returned by parquet.Value.Column
+	typ   parquet.Type
+}
+
+// Load loads data from the Parquet file.
+// If 'columns' is specified, only those columns will be included in the result.
+// If 'columns' is empty, all flat columns of the schema will be included; nested and repeated columns are skipped.
+// Values are typed by their logical type: decimals become exact decimals, timestamps and dates time.Time,
+// integers int64, strings and enums strings, and JSON columns JSON text.
+func (l *ParquetLoader) Load(columns []string) ([]DataRecord, error) {
+	var records []DataRecord
+	err := l.LoadBatches(columns, func(batch []DataRecord) error {
+		records = append(records, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// LoadBatches reads the Parquet file one batch of at most parquetBatchRows records at a time, calling fn with each batch
+// Only the footer and the batch being decoded are held in memory. Columns and values are those of Load.
+func (l *ParquetLoader) LoadBatches(columns []string, fn func(batch []DataRecord) error) error {
+	file, err := os.Open(l.FilePath)
+	if err != nil {
+		return fmt.Errorf("cannot open file '%s': %w", l.FilePath, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("cannot open file '%s': %w", l.FilePath, err)
+	}
+
+	// Only the footer is read here; pages are read when their row group is
+	pf, err := parquet.OpenFile(file, info.Size(), parquet.SkipPageIndex(true), parquet.SkipBloomFilters(true))
+	if err != nil {
+		return fmt.Errorf("error reading Parquet file '%s': %w", l.FilePath, err)
+	}
+	targetColumns, err := parquetColumns(pf.Schema(), columns)
+	if err != nil {
+		return fmt.Errorf("Parquet file '%s': %w", l.FilePath, err)
+	}
+
+	loc := l.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	byLeaf := make(map[int]parquetColumn, len(targetColumns))
+	for _, column := range targetColumns {
+		byLeaf[column.index] = column
+	}
+	read := 0
+	buffer := make([]parquet.Row, parquetBatchRows)
+	for _, rowGroup := range pf.RowGroups() {
+		if err := l.readRowGroup(rowGroup, buffer, byLeaf, loc, &read, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRowGroup decodes the rows of a row group one batch at a time, calling fn with each batch
+// columns maps the leaf column index of each loaded column to the column; read counts the records read so far.
+func (l *ParquetLoader) readRowGroup(rowGroup parquet.RowGroup, buffer []parquet.Row, columns map[int]parquetColumn, loc *time.Location, read *int, fn func(batch []DataRecord) error) error {
+	rows := rowGroup.Rows()
+	defer rows.Close()
+	for {
+		n, err := rows.ReadRows(buffer)
+		batch := make([]DataRecord, 0, n)
+		for _, row := range buffer[:n] {
+			record, err := parquetRecord(row, columns, loc)
+			if err != nil {
+				return fmt.Errorf("error reading Parquet file '%s', record %d: %w", l.FilePath, *read+len(batch)+1, err)
+			}
+			batch = append(batch, record)
+		}
+		if len(batch) > 0 {
+			*read += len(batch)
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading Parquet file '%s', record %d: %w", l.FilePath, *read+1, err)
+		}
+	}
+}
+
+// parquetRecord converts a row into a record of the loaded columns
+func parquetRecord(row parquet.Row, columns map[int]parquetColumn, loc *time.Location) (DataRecord, error) {
+	record := make(DataRecord, len(columns))
+	for _, value := range row {
+		column, ok := columns[value.Column()]
+		if !ok {
+			continue
+		}
+		v, err := parquetValue(value, column.typ, loc)
+		if err != nil {
+			return nil, fmt.Errorf("column '%s': %w", column.name, err)
+		}
+		record[column.name] = v
+	}
+	return record, nil
+}
+
+// parquetColumns returns the columns of the schema to load
+// Requested columns that are missing from the file are left out, like missing CSV columns.
+func parquetColumns(schema *parquet.Schema, columns []string) ([]parquetColumn, error) {
+	var result []parquetColumn
+	for _, field := range schema.Fields() {
+		requested := len(columns) == 0 || slices.Contains(columns, field.Name())
+		if !requested {
+			continue
+		}
+		if !field.Leaf() || field.Repeated() {
+			if len(columns) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("column '%s' is nested or repeated; only flat columns are supported", field.Name())
+		}
+		leaf, _ := schema.Lookup(field.Name())
+		result = append(result, parquetColumn{name: field.Name(), index: leaf.ColumnIndex, typ: field.Type()})
+	}
+	if len(result) == 0 && len(columns) == 0 {
+		return nil, fmt.Errorf("schema has no flat columns")
+	}
+	return result, nil
+}
+
+// parquetValue converts a Parquet value into a record value according to the logical type of its column
+// Timestamps not adjusted to UTC and dates are read as wall clock times in loc, since they hold no zone.
+func parquetValue(value parquet.Value, typ parquet.Type, loc *time.Location) (any, error) {
+	if value.IsNull() {
+		return nil, nil
+	}
+	if logical := typ.LogicalType(); logical != nil {
+		switch {
+		case logical.Decimal != nil:
+			return parquetDecimal(value, logical.Decimal.Scale)
+		case logical.Timestamp != nil:
+			return parquetTimestamp(value.Int64(), logical.Timestamp, loc), nil
+		case logical.Date != nil:
+			return time.Date(1970, time.January, 1+int(value.Int32()), 0, 0, 0, 0, loc), nil
+		case logical.Time != nil:
+			return parquetTimeOfDay(value, &logical.Time.Unit), nil
+		case logical.Integer != nil:
+			return parquetInteger(value, logical.Integer), nil
+		case logical.Json != nil:
+			return JSONValue(value.ByteArray()), nil
+		case logical.UUID != nil:
+			b := value.ByteArray()
+			if len(b) != 16 {
+				return nil, fmt.Errorf("UUID value has %d bytes instead of 16", len(b))
+			}
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+		case logical.UTF8 != nil, logical.Enum != nil:
+			return convertValue(string(value.ByteArray())), nil
+		case logical.Unknown != nil:
+			return nil, nil
+		}
+	}
+
+	switch value.Kind() {
+	case parquet.Boolean:
+		return value.Boolean(), nil
+	case parquet.Int32:
+		return int64(value.Int32()), nil
+	case parquet.Int64:
+		return value.Int64(), nil
+	case parquet.Int96:
+		// Legacy timestamps of Impala and Spark: nanoseconds of the day, then the Julian day
+		i96 := value.Int96()
+		days := int64(i96[2]) - julianUnixEpoch
+		return time.Unix(days*86400, i96.Int64()).UTC(), nil
+	case parquet.Float:
+		return parquetFloat(float64(value.Float()), 32), nil
+	case parquet.Double:
+		return parquetFloat(value.Double(), 64), nil
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		// Binary without a logical type is read as text, as most writers store unannotated strings this way
+		return string(value.ByteArray()), nil
+	}
+	return nil, fmt.Errorf("unsupported value kind %s", value.Kind())
+}
+
+// parquetDecimal returns the exact decimal of an unscaled integer value; byte arrays hold big-endian two's complement
+func parquetDecimal(value parquet.Value, scale int32) (json.Number, error) {
+	unscaled := new(big.Int)
+	switch value.Kind() {
+	case parquet.Int32:
+		unscaled.SetInt64(int64(value.Int32()))
+	case parquet.Int64:
+		unscaled.SetInt64(value.Int64())
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		b := value.ByteArray()
+		unscaled.SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+		}
+	default:
+		return "", fmt.Errorf("unsupported decimal of kind %s", value.Kind())
+	}
+	if scale <= 0 {
+		return json.Number(unscaled.Mul(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-scale)), nil)).String()), nil
+	}
+	denominator := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	return json.Number(new(big.Rat).SetFrac(unscaled, denominator).FloatString(int(scale))), nil
+}
+
+// parquetTimestamp converts a timestamp in its unit since the Unix epoch
+func parquetTimestamp(v int64, ts *format.TimestampType, loc *time.Location) time.Time {
+	var t time.Time
+	switch {
+	case ts.Unit.Millis != nil:
+		t = time.UnixMilli(v)
+	case ts.Unit.Micros != nil:
+		t = time.UnixMicro(v)
+	default:
+		t = time.Unix(0, v)
+	}
+	t = t.UTC()
+	if ts.IsAdjustedToUTC {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// parquetTimeOfDay returns a time of day as hh:mm:ss text with its fraction, the form of TIME columns
+func parquetTimeOfDay(value parquet.Value, unit *format.TimeUnit) string {
+	var d time.Duration
+	switch {
+	case unit.Millis != nil:
+		d = time.Duration(value.Int32()) * time.Millisecond
+	case unit.Micros != nil:
+		d = time.Duration(value.Int64()) * time.Microsecond
+	default:
+		d = time.Duration(value.Int64())
+	}
+	return time.Time{}.Add(d).Format("15:04:05.999999999")
+}
+
+// parquetInteger returns an integer of any width as int64, or uint64 for unsigned 64-bit integers
+func parquetInteger(value parquet.Value, it *format.IntType) any {
+	switch {
+	case it.BitWidth == 64 && !it.IsSigned:
+		return value.Uint64()
+	case it.BitWidth == 64:
+		return value.Int64()
+	case !it.IsSigned:
+		return int64(value.Uint32())
+	}
+	return int64(value.Int32())
+}
+
+// parquetFloat returns a float with its shortest exact decimal text; NaN and infinities are kept as float64
+func parquetFloat(f float64, bitSize int) any {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	return jsonNumberValue(json.Number(strconv.FormatFloat(f, 'g', -1, bitSize)))
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetProduct is the row type of the Parquet test files
+type parquetProduct struct {
+	ID        int64    `parquet:"id"`
+	Name      *string  `parquet:"name,optional"`
+	Price     int64    `parquet:"price,decimal(2:18)"`
+	Balance   [16]byte `parquet:"balance,decimal(3:38)"`
+	UpdatedAt int64    `parquet:"updated_at,timestamp(microsecond)"`
+	LocalAt   int64    `parquet:"local_at,timestamp(millisecond:local)"`
+	ShippedOn int32    `parquet:"shipped_on,date"`
+	Stock     uint32   `parquet:"stock"`
+	Ratio     float32  `parquet:"ratio"`
+	Active    bool     `parquet:"active"`
+	Tags      []string `parquet:"tags,list"`
+}
+
+// createTempParquet writes the rows to a Parquet file with row groups of at most rowGroupRows rows
+func createTempParquet[T any](t *testing.T, name string, rows []T, rowGroupRows int64) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), name)
+	if err := parquet.WriteFile(filePath, rows, parquet.MaxRowsPerRowGroup(rowGroupRows)); err != nil {
+		t.Fatalf("Failed to create temp Parquet file %s: %v", name, err)
+	}
+	return filePath
+}
+
+// decimal128 returns the 16-byte big-endian two's complement of v
+func decimal128(v int64) (b [16]byte) {
+	for i := 15; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return b
+}
+
+func TestParquetLoader_Load_Success(t *testing.T) {
+	name := "Apple"
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	localAt := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	rows := []parquetProduct{
+		{ID: 1, Name: &name, Price: 1999, Balance: decimal128(-12345), UpdatedAt: updatedAt.UnixMicro(), LocalAt: localAt.UnixMilli(),
+			ShippedOn: 19724, Stock: 4000000000, Ratio: 0.1, Active: true, Tags: []string{"new"}},
+		{ID: 9007199254740993, Price: -5, Balance: decimal128(7)},
+	}
+	tokyo := time.FixedZone("+09:00", 9*3600)
+
+	tests := []struct {
+		name     string
+		location *time.Location
+		columns  []string
+		expected []DataRecord
+	}{
+		{
+			name: "typed values of all flat columns",
+			expected: []DataRecord{
+				{"id": int64(1), "name": "Apple", "price": json.Number("19.99"), "balance": json.Number("-12.345"),
+					"updated_at": updatedAt, "local_at": localAt, "shipped_on": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+					"stock": int64(4000000000), "ratio": json.Number("0.1"), "active": true},
+				{"id": int64(9007199254740993), "name": nil, "price": json.Number("-0.05"), "balance": json.Number("0.007"),
+					"updated_at": time.Unix(0, 0).UTC(), "local_at": time.Unix(0, 0).UTC(), "shipped_on": time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+					"stock": int64(0), "ratio": json.Number("0"), "active": false},
+			},
+		},
+		{
+			name:     "filter columns and read local times in the source time zone",
+			location: tokyo,
+			columns:  []string{"id", "updated_at", "local_at", "shipped_on", "missing"},
+			expected: []DataRecord{
+				{"id": int64(1), "updated_at": updatedAt, "local_at": time.Date(2024, 1, 2, 9, 0, 0, 0, tokyo), "shipped_on": time.Date(2024, 1, 2, 0, 0, 0, 0, tokyo)},
+				{"id": int64(9007199254740993), "updated_at": time.Unix(0, 0).UTC(), "local_at": time.Date(1970, 1, 1, 0, 0, 0, 0, tokyo), "shipped_on": time.Date(1970, 1, 1, 0, 0, 0, 0, tokyo)},
+			},
+		},
+	}
+
+	filePath := createTempParquet(t, "products.parquet", rows, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := GetLoaderWithOptions(filePath, LoaderOptions{Location: tt.location})
+			if err != nil {
+				t.Fatalf("GetLoaderWithOptions() returned error: %v", err)
+			}
+			records, err := loader.Load(tt.columns)
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(records, tt.expected) {
+				t.Errorf("Load() = %v, want %v", records, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParquetLoaderRowGroups(t *testing.T) {
+	type row struct {
+		ID   int64  `parquet:"id"`
+		Code string `parquet:"code"`
+	}
+	rows := make([]row, 2500)
+	for i := range rows {
+		rows[i] = row{ID: int64(i + 1), Code: strings.Repeat("x", i%7)}
+	}
+	// Row groups of 1000 rows are decoded in several batches each
+	records, err := NewParquetLoader(createTempParquet(t, "codes.parquet", rows, 1000)).Load(nil)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if len(records) != len(rows) {
+		t.Fatalf("Load() returned %d records, want %d", len(records), len(rows))
+	}
+	for i, record := range records {
+		if record["id"] != rows[i].ID || record["code"] != rows[i].Code {
+			t.Fatalf("record %d = %v, want %+v", i+1, record, rows[i])
+		}
+	}
+}
+
+func TestParquetLoaderLoadBatches(t *testing.T) {
+	type row struct {
+		ID int64 `parquet:"id"`
+	}
+	rows := make([]row, 2500)
+	for i := range rows {
+		rows[i] = row{ID: int64(i + 1)}
+	}
+	loader := NewParquetLoader(createTempParquet(t, "ids.parquet", rows, 2000))
+
+	next := int64(1)
+	err := loader.LoadBatches(nil, func(batch []DataRecord) error {
+		if len(batch) == 0 || len(batch) > parquetBatchRows {
+			t.Errorf("Batch of %d records, want 1 to %d", len(batch), parquetBatchRows)
+		}
+		for _, record := range batch {
+			if record["id"] != next {
+				t.Fatalf("record %d = %v, want id %d", next, record, next)
+			}
+			next++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("LoadBatches() returned error: %v", err)
+	}
+	if next != int64(len(rows))+1 {
+		t.Errorf("LoadBatches() read %d records, want %d", next-1, len(rows))
+	}
+
+	t.Run("errors of fn stop loading", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := loader.LoadBatches(nil, func([]DataRecord) error {
+			calls++
+			return stop
+		})
+		if err != stop || calls != 1 {
+			t.Errorf("LoadBatches() = %v after %d calls, want the error of fn after 1 call", err, calls)
+		}
+	})
+}
+
+func TestParquetLoader_Load_Error(t *testing.T) {
+	t.Run("nested column", func(t *testing.T) {
+		filePath := createTempParquet(t, "products.parquet", []parquetProduct{{ID: 1}}, 10)
+		_, err := NewParquetLoader(filePath).Load([]string{"id", "tags"})
+		if err == nil || !strings.Contains(err.Error(), "column 'tags' is nested or repeated; only flat columns are supported") {
+			t.Errorf("Load() error = %v, want nested column error", err)
+		}
+	})
+
+	t.Run("not a Parquet file", func(t *testing.T) {
+		filePath := createTempCSV(t, "products.parquet", "id,name\n1,a\n")
+		_, err := NewParquetLoader(filePath).Load(nil)
+		if err == nil || !strings.Contains(err.Error(), "error reading Parquet file") {
+			t.Errorf("Load() error = %v, want read error", err)
+		}
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		loader := NewParquetLoader(filepath.Join(t.TempDir(), "missing.parquet"))
+		if _, err := loader.Load(nil); err == nil || !strings.Contains(err.Error(), "no such file or directory") {
+			t.Errorf("Load() error = %v, want file not found", err)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// phaseTracker records which phase of the run is in progress,
+// so that an interrupted or timed-out run can report where it stopped
+type phaseTracker struct {
+	mu    sync.Mutex
+	phase string
+	tx    txState
+}
+
+// txState is what is known about the outcome of the run's database transaction
+type txState int
+
+const (
+	txNone       txState = iota // No transaction was started
+	txOpen                      // A transaction is open
+	txCommitting                // COMMIT was sent; whether it was applied is unknown until it returns
+	txCommitted                 // The transaction was committed
+	txRolledBack                // The transaction was rolled back
+)
+
+// phaseTrackerKey is the context key for the run's phaseTracker
+type phaseTrackerKey struct{}
+
+// withPhaseTracker returns a context carrying a new phaseTracker
+func withPhaseTracker(ctx context.Context) (context.Context, *phaseTracker) {
+	tracker := &phaseTracker{phase: "starting"}
+	return context.WithValue(ctx, phaseTrackerKey{}, tracker), tracker
+}
+
+// setPhase records the current phase of the run; it is a no-op if ctx carries no tracker
+func setPhase(ctx context.Context, phase string) {
+	tracker, ok := ctx.Value(phaseTrackerKey{}).(*phaseTracker)
+	if !ok {
+		return
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.phase = phase
+}
+
+// Current returns the phase that is currently in progress
+func (p *phaseTracker) Current() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.phase
+}
+
+// setTxState records the state of the run's transaction; it is a no-op if ctx carries no tracker
+func setTxState(ctx context.Context, state txState) {
+	tracker, ok := ctx.Value(phaseTrackerKey{}).(*phaseTracker)
+	if !ok {
+		return
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.tx = state
+}
+
+// TxState returns the last recorded state of the run's transaction
+func (p *phaseTracker) TxState() txState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tx
+}
+
+// rollbackTx rolls back tx and records the rollback; it is deferred right after the transaction starts
+// A transaction that database/sql already rolled back because its context was cancelled counts as rolled back.
+// After a COMMIT was sent the state is left alone: the transaction is then committed or its outcome is unknown.
+func rollbackTx(ctx context.Context, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return
+	}
+	tracker, ok := ctx.Value(phaseTrackerKey{}).(*phaseTracker)
+	if !ok {
+		return
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if tracker.tx == txOpen {
+		tracker.tx = txRolledBack
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPhaseTracker(t *testing.T) {
+	t.Run("starts in the starting phase", func(t *testing.T) {
+		_, tracker := withPhaseTracker(context.Background())
+		if got := tracker.Current(); got != "starting" {
+			t.Errorf("Expected initial phase 'starting', got %q", got)
+		}
+	})
+
+	t.Run("setPhase updates the tracker in the context", func(t *testing.T) {
+		ctx, tracker := withPhaseTracker(context.Background())
+		setPhase(ctx, "loading files")
+		if got := tracker.Current(); got != "loading files" {
+			t.Errorf("Expected phase 'loading files', got %q", got)
+		}
+
+		// Derived contexts share the tracker
+		child, cancel := context.WithCancel(ctx)
+		defer cancel()
+		setPhase(child, "commit")
+		if got := tracker.Current(); got != "commit" {
+			t.Errorf("Expected phase 'commit', got %q", got)
+		}
+	})
+
+	t.Run("setPhase without tracker is a no-op", func(t *testing.T) {
+		setPhase(context.Background(), "anything")
+	})
+
+	t.Run("setTxState records the transaction state", func(t *testing.T) {
+		ctx, tracker := withPhaseTracker(context.Background())
+		if got := tracker.TxState(); got != txNone {
+			t.Errorf("Expected no transaction initially, got %d", got)
+		}
+		setTxState(ctx, txCommitting)
+		if got := tracker.TxState(); got != txCommitting {
+			t.Errorf("Expected txCommitting, got %d", got)
+		}
+		setTxState(context.Background(), txOpen)
+	})
+}
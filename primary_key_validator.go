@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -11,10 +13,16 @@ import (
 // PrimaryKeyValidator validates primary key integrity with configurable settings
 // This validator ensures data consistency by rejecting any records with NULL, empty, or duplicate primary keys
 type PrimaryKeyValidator struct {
-	MaxKeyLength int  // Maximum allowed length for primary keys (default: 255)
-	StrictMode   bool // Whether to enforce strict validation (default: true)
+	MaxKeyLength   int            // Maximum allowed length for primary keys (default: 255)
+	StrictMode     bool           // Whether to enforce strict validation (default: true)
+	NullTokens     []string       // Values treated as NULL, compared case-insensitively (default: defaultNullTokens)
+	Pattern        *regexp.Regexp // Pattern every primary key must match (nil: no pattern)
+	TrimWhitespace bool           // Trim keys in the records instead of rejecting leading or trailing whitespace
 }
 
+// defaultNullTokens are the common representations of NULL that are rejected as primary keys by default
+var defaultNullTokens = []string{"null", "nil", "\\n", "n/a", "na", "none", "undefined"}
+
 // PrimaryKeyValidationResult contains the results of primary key validation
 type PrimaryKeyValidationResult struct {
 	IsValid        bool                      // Overall validation result
@@ -38,6 +46,7 @@ func NewPrimaryKeyValidator() *PrimaryKeyValidator {
 	return &PrimaryKeyValidator{
 		MaxKeyLength: 255,
 		StrictMode:   true,
+		NullTokens:   defaultNullTokens,
 	}
 }
 
@@ -49,70 +58,116 @@ func NewPrimaryKeyValidatorWithConfig(maxKeyLength int, strictMode bool) *Primar
 	return &PrimaryKeyValidator{
 		MaxKeyLength: maxKeyLength,
 		StrictMode:   strictMode,
+		NullTokens:   defaultNullTokens,
+	}
+}
+
+// NewPrimaryKeyValidatorFromConfig creates a validator from a table's primaryKeyValidation settings
+func NewPrimaryKeyValidatorFromConfig(cfg PrimaryKeyValidationConfig) (*PrimaryKeyValidator, error) {
+	if cfg.MaxLength < 0 {
+		return nil, fmt.Errorf("maxLength must not be negative")
+	}
+	if cfg.Mode != "" && cfg.Mode != PrimaryKeyModeStrict && cfg.Mode != PrimaryKeyModeWarn {
+		return nil, fmt.Errorf("mode must be either '%s' or '%s'", PrimaryKeyModeStrict, PrimaryKeyModeWarn)
+	}
+
+	pkv := NewPrimaryKeyValidatorWithConfig(cfg.MaxLength, cfg.Mode != PrimaryKeyModeWarn)
+	if cfg.NullTokens != nil {
+		pkv.NullTokens = cfg.NullTokens
+	}
+	if cfg.Pattern != "" {
+		pattern, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		pkv.Pattern = pattern
 	}
+	pkv.TrimWhitespace = cfg.TrimWhitespace
+	return pkv, nil
 }
 
 // ValidateAllRecords performs comprehensive primary key validation with strict enforcement
 // This function will ALWAYS return an error if any primary key violations are found
 func (pkv *PrimaryKeyValidator) ValidateAllRecords(records []DataRecord, primaryKeyColumn string) (*PrimaryKeyValidationResult, error) {
+	return pkv.ValidateAllRecordsContext(context.Background(), records, primaryKeyColumn)
+}
+
+// ValidateAllRecordsContext is ValidateAllRecords with ctx supplying the attributes of its log records
+func (pkv *PrimaryKeyValidator) ValidateAllRecordsContext(ctx context.Context, records []DataRecord, primaryKeyColumn string) (*PrimaryKeyValidationResult, error) {
+	return pkv.validateRecordSource(ctx, memoryRecords(records), primaryKeyColumn)
+}
+
+// validateRecordSource is ValidateAllRecordsContext for a record source, read one batch at a time
+// Only the keys seen so far are kept across batches, to find duplicates.
+func (pkv *PrimaryKeyValidator) validateRecordSource(ctx context.Context, records recordSource, primaryKeyColumn string) (*PrimaryKeyValidationResult, error) {
 	if primaryKeyColumn == "" {
 		return nil, fmt.Errorf("CRITICAL: Primary key column name cannot be empty")
 	}
 
 	result := &PrimaryKeyValidationResult{
-		TotalRecords:   len(records),
 		IsValid:        true,
 		InvalidRecords: make([]InvalidPrimaryKeyRecord, 0),
 		DuplicateKeys:  make(map[string][]int),
 	}
 
-	if len(records) == 0 {
-		log.Printf("Warning: No records to validate")
-		return result, nil
-	}
-
 	seenKeys := make(map[string]int) // Map key -> first occurrence index
 
-	log.Printf("Starting strict primary key validation for %d records...", len(records))
-
-	for i, record := range records {
-		// 1. Check if primary key column exists in record
-		pkValue, exists := record[primaryKeyColumn]
-		if !exists {
-			pkv.addInvalidRecord(result, i, record, "primary_key_column_missing", "")
-			continue
-		}
+	slog.DebugContext(ctx, "Starting strict primary key validation")
+
+	err := records.each(func(offset int, batch []DataRecord) error {
+		result.TotalRecords += len(batch)
+		for j, record := range batch {
+			i := offset + j
+			// 1. Check if primary key column exists in record
+			pkValue, exists := record[primaryKeyColumn]
+			if !exists {
+				pkv.addInvalidRecord(result, i, record, "primary_key_column_missing", "")
+				continue
+			}
 
-		// 2. Convert to string for validation
-		pkStr := convertValueToString(pkValue)
+			// 2. Convert to string for validation, trimming the key in the record if configured
+			pkStr := convertValueToString(pkValue)
+			if pkv.TrimWhitespace {
+				pkStr = trimPrimaryKey(record, primaryKeyColumn)
+			}
 
-		// 3. STRICT NULL/empty check - this is CRITICAL for data integrity
-		if pkv.isNullOrEmpty(pkStr) {
-			pkv.addInvalidRecord(result, i, record, "primary_key_null_or_empty", pkStr)
-			continue
-		}
+			// 3. STRICT NULL/empty check - this is CRITICAL for data integrity
+			if pkv.isNullOrEmpty(pkStr) {
+				pkv.addInvalidRecord(result, i, record, "primary_key_null_or_empty", pkStr)
+				continue
+			}
 
-		// 4. Check for duplicates
-		if firstIndex, isDuplicate := seenKeys[pkStr]; isDuplicate {
-			// Record both occurrences as invalid
-			pkv.addInvalidRecord(result, i, record, "primary_key_duplicate", pkStr)
+			// 4. Check for duplicates
+			if firstIndex, isDuplicate := seenKeys[pkStr]; isDuplicate {
+				// Record both occurrences as invalid
+				pkv.addInvalidRecord(result, i, record, "primary_key_duplicate", pkStr)
+
+				// Track duplicates for detailed reporting
+				if _, exists := result.DuplicateKeys[pkStr]; !exists {
+					result.DuplicateKeys[pkStr] = []int{firstIndex}
+				}
+				result.DuplicateKeys[pkStr] = append(result.DuplicateKeys[pkStr], i)
+				continue
+			}
 
-			// Track duplicates for detailed reporting
-			if _, exists := result.DuplicateKeys[pkStr]; !exists {
-				result.DuplicateKeys[pkStr] = []int{firstIndex}
+			// 5. Additional validation for primary key format
+			if err := pkv.validatePrimaryKeyFormat(pkStr); err != nil {
+				pkv.addInvalidRecord(result, i, record, "primary_key_invalid_format", pkStr)
+				continue
 			}
-			result.DuplicateKeys[pkStr] = append(result.DuplicateKeys[pkStr], i)
-			continue
-		}
 
-		// 5. Additional validation for primary key format
-		if err := pkv.validatePrimaryKeyFormat(pkStr); err != nil {
-			pkv.addInvalidRecord(result, i, record, "primary_key_invalid_format", pkStr)
-			continue
+			// Record valid primary key
+			seenKeys[pkStr] = i
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// Record valid primary key
-		seenKeys[pkStr] = i
+	if result.TotalRecords == 0 {
+		slog.WarnContext(ctx, "No records to validate")
+		return result, nil
 	}
 
 	// Calculate valid records count
@@ -128,10 +183,11 @@ func (pkv *PrimaryKeyValidator) ValidateAllRecords(records []DataRecord, primary
 			return result, fmt.Errorf("🚨 PRIMARY KEY VALIDATION FAILED: %s", result.ErrorSummary)
 		}
 		// In non-strict mode, log warnings but don't fail
-		log.Printf("⚠️ PRIMARY KEY VALIDATION WARNINGS: %s", result.ErrorSummary)
+		slog.WarnContext(ctx, "Primary key validation found invalid records", "summary", result.ErrorSummary)
+		return result, nil
 	}
 
-	log.Printf("✅ Primary key validation passed: %d valid records", result.ValidRecords)
+	slog.InfoContext(ctx, "Primary key validation passed", "valid_records", result.ValidRecords)
 	return result, nil
 }
 
@@ -153,11 +209,11 @@ func (pkv *PrimaryKeyValidator) isNullOrEmpty(value string) bool {
 		return true
 	}
 
-	// Normalize and check common null representations
-	lower := strings.ToLower(strings.TrimSpace(value))
-	nullValues := []string{"null", "nil", "\\n", "n/a", "na", "none", "undefined"}
-
-	return slices.Contains(nullValues, lower)
+	// Normalize and check the configured null representations
+	normalized := strings.TrimSpace(value)
+	return slices.ContainsFunc(pkv.NullTokens, func(token string) bool {
+		return strings.EqualFold(token, normalized)
+	})
 }
 
 // validatePrimaryKeyFormat performs additional format validation for primary keys
@@ -181,6 +237,10 @@ func (pkv *PrimaryKeyValidator) validatePrimaryKeyFormat(pkValue string) error {
 		return fmt.Errorf("primary key has leading or trailing whitespace")
 	}
 
+	if pkv.Pattern != nil && !pkv.Pattern.MatchString(pkValue) {
+		return fmt.Errorf("primary key does not match pattern %s", pkv.Pattern)
+	}
+
 	return nil
 }
 
@@ -212,61 +272,57 @@ func (pkv *PrimaryKeyValidator) generateErrorSummary(result *PrimaryKeyValidatio
 
 // ReportValidationFailure provides detailed reporting of primary key validation failures
 func (pkv *PrimaryKeyValidator) ReportValidationFailure(result *PrimaryKeyValidationResult) {
+	pkv.ReportValidationFailureContext(context.Background(), result)
+}
+
+// ReportValidationFailureContext logs the validation failure as structured records;
+// ctx supplies the table and phase attributes
+func (pkv *PrimaryKeyValidator) ReportValidationFailureContext(ctx context.Context, result *PrimaryKeyValidationResult) {
 	if result.IsValid {
 		return
 	}
 
-	log.Printf("❌ PRIMARY KEY VALIDATION FAILED - SYNC ABORTED")
-	log.Printf("═══════════════════════════════════════════════")
-	log.Printf("Total records: %d", result.TotalRecords)
-	log.Printf("Valid records: %d", result.ValidRecords)
-	log.Printf("Invalid records: %d", len(result.InvalidRecords))
-	log.Printf("")
+	// In strict mode the invalid records abort the sync; otherwise they are skipped
+	level, message := slog.LevelError, "Primary key validation failed, sync aborted for data safety"
+	if !pkv.StrictMode {
+		level, message = slog.LevelWarn, "Primary key validation failed, skipping invalid records"
+	}
 
 	// Report issues by category
 	issueCount := make(map[string]int)
 	for _, invalid := range result.InvalidRecords {
 		issueCount[invalid.Reason]++
 	}
-
-	log.Printf("Issues breakdown:")
+	issues := make([]any, 0, len(issueCount))
 	for reason, count := range issueCount {
-		log.Printf("  ▶ %s: %d records", pkv.formatReasonDescription(reason), count)
+		issues = append(issues, slog.Int(reason, count))
 	}
-	log.Printf("")
+
+	slog.Log(ctx, level, message,
+		"total_records", result.TotalRecords,
+		"valid_records", result.ValidRecords,
+		"invalid_records", len(result.InvalidRecords),
+		slog.Group("issues", issues...))
 
 	// Report duplicate keys if any
-	if len(result.DuplicateKeys) > 0 {
-		log.Printf("Duplicate primary keys found:")
-		for key, indices := range result.DuplicateKeys {
-			log.Printf("  ▶ Key '%s' appears in records: %v", key, pkv.formatIndicesForDisplay(indices))
-		}
-		log.Printf("")
+	for key, indices := range result.DuplicateKeys {
+		slog.Log(ctx, level, "Duplicate primary key",
+			"primary_key", key,
+			"records", pkv.formatIndicesForDisplay(indices))
 	}
 
 	// Show sample invalid records (first 10)
 	maxSamples := 10
-	if len(result.InvalidRecords) > 0 {
-		log.Printf("Sample invalid records (first %d):", min(maxSamples, len(result.InvalidRecords)))
-		for i, invalid := range result.InvalidRecords {
-			if i >= maxSamples {
-				break
-			}
-			log.Printf("  Record %d: %s - Primary Key: '%s'",
-				invalid.RecordIndex+1,
-				pkv.formatReasonDescription(invalid.Reason),
-				invalid.PrimaryKeyValue)
-		}
-
-		if len(result.InvalidRecords) > maxSamples {
-			log.Printf("  ... and %d more invalid records", len(result.InvalidRecords)-maxSamples)
+	for i, invalid := range result.InvalidRecords {
+		if i >= maxSamples {
+			slog.Log(ctx, level, "More invalid records not shown", "count", len(result.InvalidRecords)-maxSamples)
+			break
 		}
+		slog.Log(ctx, level, "Invalid primary key",
+			"record", invalid.RecordIndex+1,
+			"reason", pkv.formatReasonDescription(invalid.Reason),
+			"primary_key", invalid.PrimaryKeyValue)
 	}
-
-	log.Printf("")
-	log.Printf("🚨 DATA SYNC ABORTED FOR DATA SAFETY")
-	log.Printf("Please fix the primary key issues and retry the synchronization.")
-	log.Printf("═══════════════════════════════════════════════")
 }
 
 // formatReasonDescription converts internal reason codes to user-friendly descriptions
@@ -292,3 +348,77 @@ func (pkv *PrimaryKeyValidator) formatIndicesForDisplay(indices []int) string {
 	}
 	return strings.Join(displayIndices, ", ")
 }
+
+// trimPrimaryKey trims the whitespace around the primary key of a record in place and returns the key as text
+func trimPrimaryKey(record DataRecord, primaryKeyColumn string) string {
+	pkStr := convertValueToString(record[primaryKeyColumn])
+	if trimmed := strings.TrimSpace(pkStr); trimmed != pkStr {
+		pkStr = trimmed
+		record[primaryKeyColumn] = trimmed
+	}
+	return pkStr
+}
+
+// validKeyRecords is the source of the records that passed validation; of duplicate keys only the first occurrence is kept
+// Its records are numbered from zero without the invalid ones. Keys are trimmed again on every pass when trimKeys
+// is set, since a streamed source reads them from its file each time.
+type validKeyRecords struct {
+	records    recordSource
+	invalid    map[int]bool
+	primaryKey string
+	trimKeys   bool
+}
+
+func (v validKeyRecords) each(fn func(offset int, batch []DataRecord) error) error {
+	next := 0
+	return v.records.each(func(offset int, batch []DataRecord) error {
+		valid := make([]DataRecord, 0, len(batch))
+		for i, record := range batch {
+			if v.invalid[offset+i] {
+				continue
+			}
+			if v.trimKeys {
+				trimPrimaryKey(record, v.primaryKey)
+			}
+			valid = append(valid, record)
+		}
+		if len(valid) == 0 {
+			return nil
+		}
+		if err := fn(next, valid); err != nil {
+			return err
+		}
+		next += len(valid)
+		return nil
+	})
+}
+
+// validatePrimaryKeys validates the primary keys of a table's records with its primaryKeyValidation settings
+// In strict mode invalid keys abort the sync; in warn mode the invalid records are reported, dropped and counted as skipped.
+func validatePrimaryKeys(ctx context.Context, cfg PrimaryKeyValidationConfig, records recordSource, primaryKey string) (recordSource, error) {
+	validator, err := NewPrimaryKeyValidatorFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	result, err := validator.validateRecordSource(ctx, records, primaryKey)
+	if err != nil {
+		// Report detailed validation failure; there is no result if the records could not be read
+		if result != nil {
+			validator.ReportValidationFailureContext(ctx, result)
+		}
+		return nil, err
+	}
+	if result.IsValid && !validator.TrimWhitespace {
+		return records, nil
+	}
+
+	invalid := make(map[int]bool, len(result.InvalidRecords))
+	for _, record := range result.InvalidRecords {
+		invalid[record.RecordIndex] = true
+	}
+	if !result.IsValid {
+		validator.ReportValidationFailureContext(ctx, result)
+		currentTableStats(ctx).Dropped = len(result.InvalidRecords)
+	}
+	return validKeyRecords{records: records, invalid: invalid, primaryKey: primaryKey, trimKeys: validator.TrimWhitespace}, nil
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"log/slog"
 	"slices"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestPrimaryKeyValidator_ValidateAllRecords(t *testing.T) {
@@ -458,3 +463,231 @@ func TestPrimaryKeyValidator_CustomConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestNewPrimaryKeyValidatorFromConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         PrimaryKeyValidationConfig
+		records     []DataRecord
+		wantInvalid []string // Reasons of the invalid records
+		wantIDs     []string // Primary keys of the records after validation
+	}{
+		{
+			name:        "defaults reject null tokens",
+			records:     []DataRecord{{"id": "1"}, {"id": "NA"}},
+			wantInvalid: []string{"primary_key_null_or_empty"},
+			wantIDs:     []string{"1", "NA"},
+		},
+		{
+			name:    "custom null tokens",
+			cfg:     PrimaryKeyValidationConfig{NullTokens: []string{"-"}},
+			records: []DataRecord{{"id": "NA"}, {"id": "-"}},
+			// "NA" is a valid key (e.g. a country code) once the tokens are replaced
+			wantInvalid: []string{"primary_key_null_or_empty"},
+			wantIDs:     []string{"NA", "-"},
+		},
+		{
+			name:    "empty null token list",
+			cfg:     PrimaryKeyValidationConfig{NullTokens: []string{}},
+			records: []DataRecord{{"id": "null"}, {"id": ""}},
+			// Empty keys are always rejected
+			wantInvalid: []string{"primary_key_null_or_empty"},
+			wantIDs:     []string{"null", ""},
+		},
+		{
+			name:        "pattern",
+			cfg:         PrimaryKeyValidationConfig{Pattern: `^[A-Z]{2}-\d+$`},
+			records:     []DataRecord{{"id": "AB-1"}, {"id": "ab-1"}, {"id": "AB-1x"}},
+			wantInvalid: []string{"primary_key_invalid_format", "primary_key_invalid_format"},
+			wantIDs:     []string{"AB-1", "ab-1", "AB-1x"},
+		},
+		{
+			name:        "whitespace rejected by default",
+			records:     []DataRecord{{"id": " 1"}},
+			wantInvalid: []string{"primary_key_invalid_format"},
+			wantIDs:     []string{" 1"},
+		},
+		{
+			name:    "trimWhitespace trims the keys",
+			cfg:     PrimaryKeyValidationConfig{TrimWhitespace: true},
+			records: []DataRecord{{"id": " 1"}, {"id": "2\t"}},
+			wantIDs: []string{"1", "2"},
+		},
+		{
+			name:        "trimmed keys are checked for duplicates",
+			cfg:         PrimaryKeyValidationConfig{TrimWhitespace: true},
+			records:     []DataRecord{{"id": "1"}, {"id": "1 "}},
+			wantInvalid: []string{"primary_key_duplicate"},
+			wantIDs:     []string{"1", "1"},
+		},
+		{
+			name:        "maxLength",
+			cfg:         PrimaryKeyValidationConfig{MaxLength: 3},
+			records:     []DataRecord{{"id": "123"}, {"id": "1234"}},
+			wantInvalid: []string{"primary_key_invalid_format"},
+			wantIDs:     []string{"123", "1234"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := NewPrimaryKeyValidatorFromConfig(tt.cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			result, _ := validator.ValidateAllRecords(tt.records, "id")
+
+			var reasons []string
+			for _, invalid := range result.InvalidRecords {
+				reasons = append(reasons, invalid.Reason)
+			}
+			if diff := cmp.Diff(tt.wantInvalid, reasons); diff != "" {
+				t.Errorf("Invalid records mismatch (-want +got):\n%s", diff)
+			}
+			var ids []string
+			for _, record := range tt.records {
+				ids = append(ids, record["id"].(string))
+			}
+			if diff := cmp.Diff(tt.wantIDs, ids); diff != "" {
+				t.Errorf("Primary keys mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewPrimaryKeyValidatorFromConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     PrimaryKeyValidationConfig
+		wantErr string
+	}{
+		{"negative maxLength", PrimaryKeyValidationConfig{MaxLength: -1}, "maxLength must not be negative"},
+		{"unknown mode", PrimaryKeyValidationConfig{Mode: "lenient"}, "mode must be either 'strict' or 'warn'"},
+		{"invalid pattern", PrimaryKeyValidationConfig{Pattern: "("}, "invalid pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPrimaryKeyValidatorFromConfig(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidatePrimaryKeys(t *testing.T) {
+	newRecords := func() []DataRecord {
+		return []DataRecord{
+			{"id": "1", "name": "Alice"},
+			{"id": "", "name": "Bob"},
+			{"id": "1", "name": "Carol"},
+			{"id": "2", "name": "Dave"},
+		}
+	}
+
+	t.Run("strict mode aborts", func(t *testing.T) {
+		records, err := validatePrimaryKeys(context.Background(), PrimaryKeyValidationConfig{}, memoryRecords(newRecords()), "id")
+		if err == nil {
+			t.Fatal("Expected error but got none")
+		}
+		if records != nil {
+			t.Errorf("Expected no records, got %v", records)
+		}
+	})
+
+	t.Run("warn mode skips invalid records", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, lerr := newLogger(&buf, LogOptions{Format: LogFormatJSON}, "run")
+		if lerr != nil {
+			t.Fatalf("Unexpected error: %v", lerr)
+		}
+		defer slog.SetDefault(slog.Default())
+		slog.SetDefault(logger)
+
+		stats := NewRunStats(false)
+		ctx := withLogTable(withRunStats(context.Background(), stats), "users")
+
+		valid, err := validatePrimaryKeys(ctx, PrimaryKeyValidationConfig{Mode: PrimaryKeyModeWarn}, memoryRecords(newRecords()), "id")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		records, err := collectRecords(valid)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// The first record with a duplicate key is kept
+		want := []DataRecord{{"id": "1", "name": "Alice"}, {"id": "2", "name": "Dave"}}
+		if diff := cmp.Diff(want, records); diff != "" {
+			t.Errorf("Records mismatch (-want +got):\n%s", diff)
+		}
+		if got := stats.Table("users"); got.Dropped != 2 || got.Skipped() != 2 {
+			t.Errorf("Expected 2 dropped records, got %+v", got)
+		}
+		// The skipped records and the validation summary end up in the run report
+		var messages []string
+		for _, w := range stats.Warnings() {
+			messages = append(messages, w.Message)
+		}
+		if !slices.Contains(messages, "Primary key validation found invalid records") {
+			t.Errorf("Expected the invalid records to be recorded as run warnings, got %v", messages)
+		}
+	})
+
+	t.Run("valid records are returned unchanged", func(t *testing.T) {
+		in := []DataRecord{{"id": "1"}, {"id": "2"}}
+		valid, err := validatePrimaryKeys(context.Background(), PrimaryKeyValidationConfig{Mode: PrimaryKeyModeWarn}, memoryRecords(in), "id")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		records, err := collectRecords(valid)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(in, records); diff != "" {
+			t.Errorf("Records mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("streamed records are validated across batches", func(t *testing.T) {
+		stats := NewRunStats(false)
+		ctx := withLogTable(withRunStats(context.Background(), stats), "users")
+		records := newStreamedRecords(t,
+			[]DataRecord{{"id": " 1 ", "name": "Alice"}, {"id": "", "name": "Bob"}},
+			[]DataRecord{{"id": "1", "name": "Carol"}, {"id": "2 ", "name": "Dave"}},
+		)
+
+		valid, err := validatePrimaryKeys(ctx, PrimaryKeyValidationConfig{Mode: PrimaryKeyModeWarn, TrimWhitespace: true}, records, "id")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// The file is read again on every pass, so the keys must be trimmed on every pass too
+		for pass := 1; pass <= 2; pass++ {
+			var offsets []int
+			var got []DataRecord
+			err := valid.each(func(offset int, batch []DataRecord) error {
+				offsets = append(offsets, offset)
+				got = append(got, batch...)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			want := []DataRecord{{"id": "1", "name": "Alice"}, {"id": "2", "name": "Dave"}}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Pass %d: records mismatch (-want +got):\n%s", pass, diff)
+			}
+			if diff := cmp.Diff([]int{0, 1}, offsets); diff != "" {
+				t.Errorf("Pass %d: offsets mismatch (-want +got):\n%s", pass, diff)
+			}
+		}
+		if got := stats.Table("users"); got.Dropped != 2 {
+			t.Errorf("Expected 2 dropped records, got %+v", got)
+		}
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		if _, err := validatePrimaryKeys(context.Background(), PrimaryKeyValidationConfig{Pattern: "("}, memoryRecords(newRecords()), "id"); err == nil {
+			t.Error("Expected error but got none")
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errStopRecords ends a pass over a record source early
+var errStopRecords = errors.New("stop reading records")
+
+// recordSource holds the records of a table file, which every stage of the sync reads in its own pass
+// A streamed source decodes its file again on each pass, so that only one batch of it is held in memory at a time.
+type recordSource interface {
+	// each calls fn with the batches of records in order, never with an empty batch; offset is the index
+	// of the batch's first record. An error returned by fn ends the pass and is returned.
+	each(fn func(offset int, batch []DataRecord) error) error
+}
+
+// memoryRecords is a source of records loaded into memory, read as a single batch
+type memoryRecords []DataRecord
+
+func (r memoryRecords) each(fn func(offset int, batch []DataRecord) error) error {
+	if len(r) == 0 {
+		return nil
+	}
+	return fn(0, r)
+}
+
+// streamedRecords is a source read from a BatchLoader on every pass, each batch prepared as it is decoded
+type streamedRecords struct {
+	loader   BatchLoader
+	columns  []string
+	preparer *recordPreparer
+}
+
+func (s streamedRecords) each(fn func(offset int, batch []DataRecord) error) error {
+	offset := 0
+	return s.loader.LoadBatches(s.columns, func(batch []DataRecord) error {
+		if err := s.preparer.prepare(offset, batch); err != nil {
+			return err
+		}
+		if err := fn(offset, batch); err != nil {
+			return err
+		}
+		offset += len(batch)
+		return nil
+	})
+}
+
+// recordPreparer sets the constants, applies the transform rules and parses the dates of loaded records
+type recordPreparer struct {
+	constants   map[string]string
+	runID       string // Replaces ${RUN_ID} in constants
+	transformer *Transformer
+	dateFormats map[string]string
+	source, db  *time.Location // Time zones of the file's dates and of the database session
+	file        string         // Names the file in errors, e.g. "from users.csv"
+}
+
+// prepare prepares a batch of records in place; offset is the index of its first record, for the record numbers of errors
+func (p *recordPreparer) prepare(offset int, records []DataRecord) error {
+	applyConstants(p.constants, p.runID, records)
+	if err := p.transformer.applyFrom(offset, records); err != nil {
+		return fmt.Errorf("error transforming data %s: %w", p.file, err)
+	}
+	if err := parseDateColumnsFrom(offset, records, p.dateFormats, p.source, p.db); err != nil {
+		return fmt.Errorf("error parsing dates %s: %w", p.file, err)
+	}
+	return nil
+}
+
+// openRecords returns the records of a file as a source, prepared by preparer
+// Files of a BatchLoader are streamed; the records of other loaders are loaded and prepared here, all at once.
+func openRecords(loader Loader, columns []string, preparer *recordPreparer) (recordSource, error) {
+	if batchLoader, ok := loader.(BatchLoader); ok {
+		return streamedRecords{loader: batchLoader, columns: columns, preparer: preparer}, nil
+	}
+	records, err := loader.Load(columns)
+	if err != nil {
+		return nil, fmt.Errorf("error loading data %s: %w", preparer.file, err)
+	}
+	if err := preparer.prepare(0, records); err != nil {
+		return nil, err
+	}
+	return memoryRecords(records), nil
+}
+
+// countRecords returns the number of records of a source
+func countRecords(records recordSource) (int, error) {
+	count := 0
+	err := records.each(func(_ int, batch []DataRecord) error {
+		count += len(batch)
+		return nil
+	})
+	return count, err
+}
+
+// firstRecord returns the first record of a source, or nil if it has none; a streamed file is only read up to its first batch
+func firstRecord(records recordSource) (DataRecord, error) {
+	var first DataRecord
+	err := records.each(func(_ int, batch []DataRecord) error {
+		first = batch[0]
+		return errStopRecords
+	})
+	if err != nil && !errors.Is(err, errStopRecords) {
+		return nil, err
+	}
+	return first, nil
+}
+
+// collectRecords returns all records of a source in memory
+func collectRecords(records recordSource) ([]DataRecord, error) {
+	if r, ok := records.(memoryRecords); ok {
+		return r, nil
+	}
+	var all []DataRecord
+	err := records.each(func(_ int, batch []DataRecord) error {
+		all = append(all, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
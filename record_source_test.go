@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeBatchLoader is a BatchLoader that yields copies of its batches, like a file decoded again on every pass
+type fakeBatchLoader struct {
+	batches [][]DataRecord
+	passes  int
+}
+
+func (l *fakeBatchLoader) Load(columns []string) ([]DataRecord, error) {
+	var records []DataRecord
+	err := l.LoadBatches(columns, func(batch []DataRecord) error {
+		records = append(records, batch...)
+		return nil
+	})
+	return records, err
+}
+
+func (l *fakeBatchLoader) LoadBatches(_ []string, fn func(batch []DataRecord) error) error {
+	l.passes++
+	for _, batch := range l.batches {
+		records := make([]DataRecord, len(batch))
+		for i, record := range batch {
+			records[i] = maps.Clone(record)
+		}
+		if err := fn(records); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newStreamedRecords returns a streamed source of the batches, prepared without constants, transforms or dates
+func newStreamedRecords(t *testing.T, batches ...[]DataRecord) recordSource {
+	t.Helper()
+	transformer, err := NewTransformer(nil)
+	if err != nil {
+		t.Fatalf("NewTransformer() returned error: %v", err)
+	}
+	records, err := openRecords(&fakeBatchLoader{batches: batches}, nil, &recordPreparer{transformer: transformer, db: time.UTC})
+	if err != nil {
+		t.Fatalf("openRecords() returned error: %v", err)
+	}
+	return records
+}
+
+func TestOpenRecords(t *testing.T) {
+	batches := [][]DataRecord{
+		{{"id": "1", "on": "2024/06/17"}, {"id": "2", "on": "2024/06/18"}},
+		{{"id": "3", "on": "2024/06/19"}},
+	}
+	transformer, err := NewTransformer([]TransformRule{{Column: "id", Steps: []TransformStep{{Concat: "u{id}"}}}})
+	if err != nil {
+		t.Fatalf("NewTransformer() returned error: %v", err)
+	}
+	preparer := &recordPreparer{
+		constants:   map[string]string{"batch": "${RUN_ID}"},
+		runID:       "run-1",
+		transformer: transformer,
+		dateFormats: map[string]string{"on": "2006/01/02"},
+		db:          time.UTC,
+		file:        "from users.parquet",
+	}
+	loader := &fakeBatchLoader{batches: batches}
+	records, err := openRecords(loader, nil, preparer)
+	if err != nil {
+		t.Fatalf("openRecords() returned error: %v", err)
+	}
+	if loader.passes != 0 {
+		t.Errorf("openRecords() read a batch loader %d times, want it to stream on each pass", loader.passes)
+	}
+
+	var offsets []int
+	var got []DataRecord
+	err = records.each(func(offset int, batch []DataRecord) error {
+		offsets = append(offsets, offset)
+		got = append(got, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("each() returned error: %v", err)
+	}
+	want := []DataRecord{
+		{"id": "u1", "on": time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC), "batch": "run-1"},
+		{"id": "u2", "on": time.Date(2024, 6, 18, 0, 0, 0, 0, time.UTC), "batch": "run-1"},
+		{"id": "u3", "on": time.Date(2024, 6, 19, 0, 0, 0, 0, time.UTC), "batch": "run-1"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Records mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{0, 2}, offsets); diff != "" {
+		t.Errorf("Offsets mismatch (-want +got):\n%s", diff)
+	}
+
+	if count, err := countRecords(records); err != nil || count != 3 {
+		t.Errorf("countRecords() = %d, %v, want 3", count, err)
+	}
+	if first, err := firstRecord(records); err != nil || first["id"] != "u1" {
+		t.Errorf("firstRecord() = %v, %v, want the first record", first, err)
+	}
+	if loader.passes != 3 {
+		t.Errorf("Loader read %d times, want once per pass", loader.passes)
+	}
+
+	t.Run("errors number records across batches", func(t *testing.T) {
+		batches[1][0]["on"] = "19.06.2024"
+		_, err := collectRecords(records)
+		if err == nil || !strings.Contains(err.Error(), "error parsing dates from users.parquet: record 3, column 'on'") {
+			t.Errorf("collectRecords() error = %v, want a date error for record 3", err)
+		}
+	})
+
+	t.Run("errors of fn end the pass", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := newStreamedRecords(t, batches...).each(func(int, []DataRecord) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("each() = %v after %d calls, want the error of fn after 1 call", err, calls)
+		}
+	})
+
+	t.Run("other loaders are loaded at once", func(t *testing.T) {
+		records, err := openRecords(NewCSVLoader(createTempCSV(t, "users.csv", "id\n1\n2\n")), nil, &recordPreparer{transformer: transformer, db: time.UTC})
+		if err != nil {
+			t.Fatalf("openRecords() returned error: %v", err)
+		}
+		if diff := cmp.Diff(memoryRecords{{"id": "u1"}, {"id": "u2"}}, records); diff != "" {
+			t.Errorf("Records mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxReportedRecords is how many record numbers are listed for each failed rule
+const maxReportedRecords = 10
+
+// RecordValidator checks loaded records against the column validation rules of a table
+type RecordValidator struct {
+	rules []compiledRule
+}
+
+// compiledRule is a validation rule with its regular expression compiled
+type compiledRule struct {
+	ValidationRule
+	regex *regexp.Regexp
+}
+
+// RecordValidationResult contains the results of record validation
+type RecordValidationResult struct {
+	TotalRecords int             // Total number of records processed
+	Violations   []RuleViolation // Every failed check, in record order
+}
+
+// RuleViolation is a record that failed one check of a validation rule
+type RuleViolation struct {
+	RecordIndex int    // Zero-based index in the record slice
+	Column      string // Column that was checked
+	Check       string // Failed check: notNull, regex, min, max, minLength, maxLength, enum, unique or dateFormat
+	Severity    string // "error" or "warning"
+	Value       string // The offending value
+}
+
+// NewRecordValidator creates a validator for the given rules
+func NewRecordValidator(rules []ValidationRule) (*RecordValidator, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Severity == "" {
+			rule.Severity = SeverityError
+		}
+		if rule.Severity != SeverityError && rule.Severity != SeverityWarning {
+			return nil, fmt.Errorf("severity must be either '%s' or '%s'", SeverityError, SeverityWarning)
+		}
+		if !rule.NotNull && rule.Regex == "" && rule.Min == nil && rule.Max == nil && rule.MinLength == nil &&
+			rule.MaxLength == nil && len(rule.Enum) == 0 && !rule.Unique && rule.DateFormat == "" {
+			return nil, fmt.Errorf("at least one check is required")
+		}
+		if rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max {
+			return nil, fmt.Errorf("min must not be greater than max")
+		}
+		if rule.MinLength != nil && rule.MaxLength != nil && *rule.MinLength > *rule.MaxLength {
+			return nil, fmt.Errorf("minLength must not be greater than maxLength")
+		}
+
+		c := compiledRule{ValidationRule: rule}
+		if rule.Regex != "" {
+			re, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex: %w", err)
+			}
+			c.regex = re
+		}
+		compiled = append(compiled, c)
+	}
+	return &RecordValidator{rules: compiled}, nil
+}
+
+// ValidateAllRecords checks every record against every rule
+func (rv *RecordValidator) ValidateAllRecords(records []DataRecord) *RecordValidationResult {
+	result, _ := rv.validateRecordSource(memoryRecords(records)) // Records in memory cannot fail to read
+	return result
+}
+
+// validateRecordSource is ValidateAllRecords for a record source, read one batch at a time
+// Only the values of the unique checks are kept across batches.
+func (rv *RecordValidator) validateRecordSource(records recordSource) (*RecordValidationResult, error) {
+	result := &RecordValidationResult{}
+	seen := make([]map[string]bool, len(rv.rules))
+	for i := range seen {
+		seen[i] = make(map[string]bool)
+	}
+
+	err := records.each(func(offset int, batch []DataRecord) error {
+		result.TotalRecords += len(batch)
+		for i, record := range batch {
+			for r, rule := range rv.rules {
+				value := convertValueToString(record[rule.Column])
+				_, parsed := record[rule.Column].(time.Time)
+				for _, check := range rule.failedChecks(value, parsed, seen[r]) {
+					result.Violations = append(result.Violations, RuleViolation{
+						RecordIndex: offset + i,
+						Column:      rule.Column,
+						Check:       check,
+						Severity:    rule.Severity,
+						Value:       value,
+					})
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// failedChecks returns the checks of the rule that value fails
+// seen holds the values of earlier records, for the unique check. parsed marks a value that was
+// already read as a time, by dateFormats or as RFC3339, whose text is no longer the file's.
+func (r *compiledRule) failedChecks(value string, parsed bool, seen map[string]bool) []string {
+	if value == "" {
+		if r.NotNull {
+			return []string{"notNull"}
+		}
+		return nil
+	}
+
+	var failed []string
+	if r.regex != nil && !r.regex.MatchString(value) {
+		failed = append(failed, "regex")
+	}
+	if r.Min != nil || r.Max != nil {
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if r.Min != nil && (err != nil || number < *r.Min) {
+			failed = append(failed, "min")
+		}
+		if r.Max != nil && (err != nil || number > *r.Max) {
+			failed = append(failed, "max")
+		}
+	}
+	length := utf8.RuneCountInString(value)
+	if r.MinLength != nil && length < *r.MinLength {
+		failed = append(failed, "minLength")
+	}
+	if r.MaxLength != nil && length > *r.MaxLength {
+		failed = append(failed, "maxLength")
+	}
+	if len(r.Enum) > 0 && !slices.Contains(r.Enum, value) {
+		failed = append(failed, "enum")
+	}
+	if r.Unique {
+		if seen[value] {
+			failed = append(failed, "unique")
+		}
+		seen[value] = true
+	}
+	if r.DateFormat != "" && !parsed {
+		if _, err := time.Parse(r.DateFormat, value); err != nil {
+			failed = append(failed, "dateFormat")
+		}
+	}
+	return failed
+}
+
+// Count returns the number of violations with the given severity
+func (r *RecordValidationResult) Count(severity string) int {
+	count := 0
+	for _, v := range r.Violations {
+		if v.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// ReportValidationResult logs the violations as structured records: a summary per severity,
+// then one record per failed check listing the affected record numbers (1-based)
+// Errors are logged at error level and warnings at warning level; ctx supplies the table and phase attributes.
+func (rv *RecordValidator) ReportValidationResult(ctx context.Context, result *RecordValidationResult) {
+	for _, severity := range []string{SeverityError, SeverityWarning} {
+		level := slog.LevelError
+		message := "Record validation failed, sync aborted"
+		if severity == SeverityWarning {
+			level = slog.LevelWarn
+			message = "Record validation found warnings"
+		}
+
+		// Group the violations by column and check, keeping the order in which they first occur
+		type issue struct {
+			column, check string
+			indices       []int
+			example       string
+		}
+		var issues []*issue
+		for _, v := range result.Violations {
+			if v.Severity != severity {
+				continue
+			}
+			idx := slices.IndexFunc(issues, func(i *issue) bool { return i.column == v.Column && i.check == v.Check })
+			if idx < 0 {
+				issues = append(issues, &issue{column: v.Column, check: v.Check, example: v.Value})
+				idx = len(issues) - 1
+			}
+			issues[idx].indices = append(issues[idx].indices, v.RecordIndex)
+		}
+		if len(issues) == 0 {
+			continue
+		}
+
+		counts := make([]any, 0, len(issues))
+		for _, i := range issues {
+			counts = append(counts, slog.Int(i.column+"."+i.check, len(i.indices)))
+		}
+		slog.Log(ctx, level, message,
+			"total_records", result.TotalRecords,
+			"violations", result.Count(severity),
+			slog.Group("issues", counts...))
+
+		for _, i := range issues {
+			attrs := []any{"severity", severity, "records", formatRecordNumbers(i.indices), "example", i.example}
+			slog.Log(ctx, level, fmt.Sprintf("Column '%s' failed %s in %d records", i.column, i.check, len(i.indices)), attrs...)
+		}
+	}
+}
+
+// formatRecordNumbers lists the first record numbers (1-based) of a failed check
+func formatRecordNumbers(indices []int) string {
+	shown := indices[:min(len(indices), maxReportedRecords)]
+	numbers := make([]string, 0, len(shown))
+	for _, idx := range shown {
+		numbers = append(numbers, strconv.Itoa(idx+1))
+	}
+	text := strings.Join(numbers, ", ")
+	if len(indices) > len(shown) {
+		text += fmt.Sprintf(" and %d more", len(indices)-len(shown))
+	}
+	return text
+}
+
+// validateRecords checks the records of a table against its validation rules and reports the violations
+// It returns an error if any rule with error severity failed; warnings only get reported.
+func validateRecords(ctx context.Context, rules []ValidationRule, records recordSource) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	validator, err := NewRecordValidator(rules)
+	if err != nil {
+		return err
+	}
+	result, err := validator.validateRecordSource(records)
+	if err != nil {
+		return err
+	}
+	validator.ReportValidationResult(ctx, result)
+
+	if errorCount := result.Count(SeverityError); errorCount > 0 {
+		return fmt.Errorf("%d validation errors in %d records", errorCount, result.TotalRecords)
+	}
+	slog.InfoContext(ctx, "Record validation passed", "records", result.TotalRecords, "warnings", result.Count(SeverityWarning))
+	return nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecordValidator_ValidateAllRecords(t *testing.T) {
+	minPrice, maxPrice := 0.0, 1000.0
+	minLen, maxLen := 2, 5
+
+	tests := []struct {
+		name    string
+		rule    ValidationRule
+		values  []any
+		want    []string // "<record index>:<check>" for every violation
+		wantSev string
+	}{
+		{
+			name:   "notNull rejects missing and empty values",
+			rule:   ValidationRule{Column: "v", NotNull: true},
+			values: []any{"a", "", nil},
+			want:   []string{"1:notNull", "2:notNull"},
+		},
+		{
+			name:   "empty values pass every other check",
+			rule:   ValidationRule{Column: "v", Regex: "^x$", Min: &minPrice, Enum: []string{"x"}},
+			values: []any{"", nil},
+		},
+		{
+			name:   "regex",
+			rule:   ValidationRule{Column: "v", Regex: `^[^@\s]+@[^@\s]+\.[a-z]+$`},
+			values: []any{"a@example.com", "not-an-email", "a@b@c.com"},
+			want:   []string{"1:regex", "2:regex"},
+		},
+		{
+			name:   "min and max",
+			rule:   ValidationRule{Column: "v", Min: &minPrice, Max: &maxPrice},
+			values: []any{"0", "999.99", "-1", "1000.01", 12, "abc"},
+			want:   []string{"2:min", "3:max", "5:min", "5:max"},
+		},
+		{
+			name:   "length counts characters",
+			rule:   ValidationRule{Column: "v", MinLength: &minLen, MaxLength: &maxLen},
+			values: []any{"ab", "東京都渋谷", "a", "abcdef"},
+			want:   []string{"2:minLength", "3:maxLength"},
+		},
+		{
+			name:   "enum",
+			rule:   ValidationRule{Column: "v", Enum: []string{"new", "paid"}},
+			values: []any{"new", "paid", "PAID", "shipped"},
+			want:   []string{"2:enum", "3:enum"},
+		},
+		{
+			name:   "unique reports repeated values",
+			rule:   ValidationRule{Column: "v", Unique: true},
+			values: []any{"a", "b", "a", "a"},
+			want:   []string{"2:unique", "3:unique"},
+		},
+		{
+			name:   "date format",
+			rule:   ValidationRule{Column: "v", DateFormat: "2006-01-02"},
+			values: []any{"2024-06-17", "2024/06/17", "2024-02-30"},
+			want:   []string{"1:dateFormat", "2:dateFormat"},
+		},
+		{
+			name:   "times parsed while loading pass the date format",
+			rule:   ValidationRule{Column: "v", DateFormat: "2006/01/02"},
+			values: []any{time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC), "2024-06-17"},
+			want:   []string{"1:dateFormat"},
+		},
+		{
+			name:    "warning severity",
+			rule:    ValidationRule{Column: "v", Severity: SeverityWarning, NotNull: true},
+			values:  []any{""},
+			want:    []string{"0:notNull"},
+			wantSev: SeverityWarning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := NewRecordValidator([]ValidationRule{tt.rule})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			records := make([]DataRecord, 0, len(tt.values))
+			for _, v := range tt.values {
+				records = append(records, DataRecord{"v": v})
+			}
+
+			result := validator.ValidateAllRecords(records)
+			var got []string
+			for _, v := range result.Violations {
+				got = append(got, strconv.Itoa(v.RecordIndex)+":"+v.Check)
+				wantSev := tt.wantSev
+				if wantSev == "" {
+					wantSev = SeverityError
+				}
+				if v.Severity != wantSev {
+					t.Errorf("Expected severity %s, got %s", wantSev, v.Severity)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Violations mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateRecordsWithParsedDates(t *testing.T) {
+	records := []DataRecord{
+		{"ordered_on": "2024/06/17", "paid_at": time.Date(2024, 6, 17, 1, 0, 0, 0, time.UTC)}, // paid_at as loaded from RFC3339
+	}
+	if err := parseDateColumns(records, map[string]string{"ordered_on": "2006/01/02"}, nil, time.UTC); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rules := []ValidationRule{
+		{Column: "ordered_on", DateFormat: "2006/01/02"},
+		{Column: "paid_at", DateFormat: time.RFC3339},
+	}
+	if err := validateRecords(context.Background(), rules, memoryRecords(records)); err != nil {
+		t.Errorf("validateRecords() returned error: %v", err)
+	}
+}
+func TestNewRecordValidatorErrors(t *testing.T) {
+	one, two := 1.0, 2.0
+	tests := []struct {
+		name    string
+		rule    ValidationRule
+		wantErr string
+	}{
+		{"unknown severity", ValidationRule{Column: "v", NotNull: true, Severity: "fatal"}, "severity must be either"},
+		{"no check", ValidationRule{Column: "v"}, "at least one check is required"},
+		{"min above max", ValidationRule{Column: "v", Min: &two, Max: &one}, "min must not be greater than max"},
+		{"invalid regex", ValidationRule{Column: "v", Regex: "("}, "invalid regex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRecordValidator([]ValidationRule{tt.rule})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateRecords(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, LogOptions{Format: LogFormatJSON}, "run")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	records := []DataRecord{
+		{"price": "10", "status": "new"},
+		{"price": "-5", "status": "lost"},
+		{"price": "-1", "status": "new"},
+	}
+	minPrice := 0.0
+	rules := []ValidationRule{
+		{Column: "price", Min: &minPrice},
+		{Column: "status", Enum: []string{"new", "paid"}, Severity: SeverityWarning},
+	}
+
+	t.Run("errors abort and are reported with record numbers", func(t *testing.T) {
+		buf.Reset()
+		stats := NewRunStats(false)
+		ctx := withLogTable(withRunStats(context.Background(), stats), "orders")
+
+		err := validateRecords(ctx, rules, memoryRecords(records))
+		if err == nil || err.Error() != "2 validation errors in 3 records" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		var messages []string
+		for _, record := range decodeLogLines(t, &buf) {
+			messages = append(messages, record["level"].(string)+" "+record["msg"].(string))
+			if record["msg"] == "Column 'price' failed min in 2 records" && record["records"] != "2, 3" {
+				t.Errorf("Expected records 2, 3, got %v", record["records"])
+			}
+		}
+		want := []string{
+			"ERROR Record validation failed, sync aborted",
+			"ERROR Column 'price' failed min in 2 records",
+			"WARN Record validation found warnings",
+			"WARN Column 'status' failed enum in 1 records",
+		}
+		if diff := cmp.Diff(want, messages); diff != "" {
+			t.Errorf("Log mismatch (-want +got):\n%s", diff)
+		}
+		// Warnings also end up in the run report
+		if warnings := stats.Warnings(); len(warnings) != 2 || warnings[0].Table != "orders" {
+			t.Errorf("Unexpected run warnings: %+v", warnings)
+		}
+	})
+
+	t.Run("warnings alone do not abort", func(t *testing.T) {
+		if err := validateRecords(context.Background(), rules[1:], memoryRecords(records)); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("no rules", func(t *testing.T) {
+		if err := validateRecords(context.Background(), nil, memoryRecords(records)); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("streamed records are checked across batches", func(t *testing.T) {
+		buf.Reset()
+		streamed := newStreamedRecords(t,
+			[]DataRecord{{"email": "a@example.com"}, {"email": "b@example.com"}},
+			[]DataRecord{{"email": "c@example.com"}, {"email": "a@example.com"}},
+		)
+		err := validateRecords(context.Background(), []ValidationRule{{Column: "email", Unique: true}}, streamed)
+		if err == nil || err.Error() != "1 validation errors in 4 records" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		found := false
+		for _, record := range decodeLogLines(t, &buf) {
+			if record["msg"] == "Column 'email' failed unique in 1 records" {
+				found = record["records"] == "4"
+			}
+		}
+		if !found {
+			t.Errorf("Expected the duplicate to be reported as record 4, got:\n%s", buf.String())
+		}
+	})
+}
+
+func TestFormatRecordNumbers(t *testing.T) {
+	indices := make([]int, 12)
+	for i := range indices {
+		indices[i] = i
+	}
+	want := "1, 2, 3, 4, 5, 6, 7, 8, 9, 10 and 2 more"
+	if got := formatRecordNumbers(indices); got != want {
+		t.Errorf("formatRecordNumbers() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"time"
+)
+
+// Run report statuses
+const (
+	ReportStatusSuccess = "success"
+	ReportStatusFailed  = "failed"
+)
+
+// RunReport is the end-of-run summary written as JSON and HTML
+type RunReport struct {
+	RunID           string          `json:"run_id"`
+	Status          string          `json:"status"` // "success" or "failed"
+	Error           string          `json:"error,omitempty"`
+	DryRun          bool            `json:"dry_run"` // Counts are planned changes, nothing was applied
+	StartedAt       time.Time       `json:"started_at"`
+	FinishedAt      time.Time       `json:"finished_at"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	Retries         int             `json:"retries"`
+	InsertOrder     []string        `json:"insert_order,omitempty"` // Dependency order for inserts and updates (parent→child)
+	DeleteOrder     []string        `json:"delete_order,omitempty"` // Dependency order for deletes (child→parent)
+	Totals          ReportCounts    `json:"totals"`
+	Tables          []TableReport   `json:"tables"`
+	Warnings        []ReportWarning `json:"warnings"`
+}
+
+// ReportCounts holds the row counts of a table or of the whole run
+type ReportCounts struct {
+	RowsRead int `json:"rows_read"`
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Deleted  int `json:"deleted"`
+	Skipped  int `json:"skipped"` // File rows that could not be synchronized, e.g. without a primary key
+}
+
+// TableReport is the summary of one table
+type TableReport struct {
+	Table string `json:"table"`
+	Mode  string `json:"mode"`
+	ReportCounts
+	DiffSeconds  float64 `json:"diff_seconds"`
+	WriteSeconds float64 `json:"write_seconds"`
+	Warnings     int     `json:"warnings"`
+}
+
+// ReportWarning is a warning logged during the run
+type ReportWarning struct {
+	Table   string `json:"table,omitempty"`
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+// newRunReport builds the report of a finished run from its statistics and result
+func newRunReport(stats *RunStats, runErr error) RunReport {
+	report := RunReport{
+		RunID:           stats.RunID,
+		Status:          ReportStatusSuccess,
+		DryRun:          stats.DryRun,
+		StartedAt:       stats.Start,
+		FinishedAt:      stats.End,
+		DurationSeconds: stats.Duration().Seconds(),
+		Retries:         stats.Retries,
+		InsertOrder:     stats.InsertOrder,
+		DeleteOrder:     stats.DeleteOrder,
+		Tables:          []TableReport{},
+		Warnings:        []ReportWarning{},
+	}
+	if runErr != nil {
+		report.Status = ReportStatusFailed
+		report.Error = maskSecrets(runErr.Error())
+	}
+
+	warningsPerTable := make(map[string]int)
+	for _, w := range stats.Warnings() {
+		report.Warnings = append(report.Warnings, ReportWarning{Table: w.Table, Message: w.Message, Count: w.Count})
+		warningsPerTable[w.Table] += w.Count
+	}
+
+	for _, t := range stats.Tables() {
+		table := TableReport{
+			Table: t.Table,
+			Mode:  t.SyncMode,
+			ReportCounts: ReportCounts{
+				RowsRead: t.RowsRead,
+				Inserted: t.Inserted,
+				Updated:  t.Updated,
+				Deleted:  t.Deleted,
+				Skipped:  t.Skipped(),
+			},
+			DiffSeconds:  t.DiffDuration.Seconds(),
+			WriteSeconds: t.WriteDuration.Seconds(),
+			Warnings:     warningsPerTable[t.Table],
+		}
+		report.Tables = append(report.Tables, table)
+		report.Totals.RowsRead += table.RowsRead
+		report.Totals.Inserted += table.Inserted
+		report.Totals.Updated += table.Updated
+		report.Totals.Deleted += table.Deleted
+		report.Totals.Skipped += table.Skipped
+	}
+	return report
+}
+
+// renderReportJSON renders the report as indented JSON
+func renderReportJSON(report RunReport) ([]byte, error) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// reportTemplate renders a self-contained HTML report: styles are inline and nothing is loaded from elsewhere
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"seconds": func(s float64) string { return fmt.Sprintf("%.3fs", s) },
+	"time":    func(t time.Time) string { return t.Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>mydatasyncer run report {{.RunID}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 1.5em; }
+table { border-collapse: collapse; margin-top: 0.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.7em; text-align: left; }
+td.num, th.num { text-align: right; }
+th { background: #f3f3f3; }
+tfoot td { font-weight: bold; }
+.status { display: inline-block; padding: 0.2em 0.6em; border-radius: 0.3em; color: #fff; font-weight: bold; }
+.success { background: #2e7d32; }
+.failed { background: #c62828; }
+.dry-run { background: #6d6d6d; }
+.error { color: #c62828; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>mydatasyncer run report</h1>
+<p><span class="status {{.Status}}">{{.Status}}</span>{{if .DryRun}} <span class="status dry-run">dry-run: planned changes, nothing was applied</span>{{end}}</p>
+<table>
+<tr><th>Run ID</th><td>{{.RunID}}</td></tr>
+<tr><th>Started</th><td>{{time .StartedAt}}</td></tr>
+<tr><th>Finished</th><td>{{time .FinishedAt}}</td></tr>
+<tr><th>Duration</th><td>{{seconds .DurationSeconds}}</td></tr>
+<tr><th>Retries</th><td>{{.Retries}}</td></tr>
+</table>
+{{- if .Error}}
+<h2>Error</h2>
+<p class="error">{{.Error}}</p>
+{{- end}}
+{{- if .InsertOrder}}
+<h2>Dependency order</h2>
+<table>
+<tr><th>Insert/update (parent→child)</th><td>{{range $i, $t := .InsertOrder}}{{if $i}} → {{end}}{{$t}}{{end}}</td></tr>
+<tr><th>Delete (child→parent)</th><td>{{range $i, $t := .DeleteOrder}}{{if $i}} → {{end}}{{$t}}{{end}}</td></tr>
+</table>
+{{- end}}
+<h2>Tables</h2>
+<table>
+<thead>
+<tr><th>Table</th><th>Mode</th><th class="num">Rows read</th><th class="num">Inserted</th><th class="num">Updated</th><th class="num">Deleted</th><th class="num">Skipped</th><th class="num">Diff time</th><th class="num">Write time</th><th class="num">Warnings</th></tr>
+</thead>
+<tbody>
+{{- range .Tables}}
+<tr><td>{{.Table}}</td><td>{{.Mode}}</td><td class="num">{{.RowsRead}}</td><td class="num">{{.Inserted}}</td><td class="num">{{.Updated}}</td><td class="num">{{.Deleted}}</td><td class="num">{{.Skipped}}</td><td class="num">{{seconds .DiffSeconds}}</td><td class="num">{{seconds .WriteSeconds}}</td><td class="num">{{.Warnings}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot>
+<tr><td colspan="2">Total</td><td class="num">{{.Totals.RowsRead}}</td><td class="num">{{.Totals.Inserted}}</td><td class="num">{{.Totals.Updated}}</td><td class="num">{{.Totals.Deleted}}</td><td class="num">{{.Totals.Skipped}}</td><td colspan="3"></td></tr>
+</tfoot>
+</table>
+<h2>Warnings</h2>
+{{- if .Warnings}}
+<table>
+<thead><tr><th>Table</th><th>Message</th><th class="num">Count</th></tr></thead>
+<tbody>
+{{- range .Warnings}}
+<tr><td>{{.Table}}</td><td>{{.Message}}</td><td class="num">{{.Count}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- else}}
+<p>No warnings.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// renderReportHTML renders the report as a self-contained HTML page
+func renderReportHTML(report RunReport) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, report); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeRunReports writes the configured JSON and HTML reports of a finished run
+// Report problems are logged but never change the result of the run.
+func writeRunReports(reportConfig ReportConfig, report RunReport) {
+	if reportConfig.JSON == "" && reportConfig.HTML == "" {
+		return
+	}
+
+	for _, output := range []struct {
+		path   string
+		render func(RunReport) ([]byte, error)
+	}{
+		{reportConfig.JSON, renderReportJSON},
+		{reportConfig.HTML, renderReportHTML},
+	} {
+		if output.path == "" {
+			continue
+		}
+		data, err := output.render(report)
+		if err == nil {
+			err = writeFileAtomic(output.path, data)
+		}
+		if err != nil {
+			slog.Warn("Failed to write run report", "path", output.path, "error", err)
+			continue
+		}
+		slog.Info("Wrote run report", "path", output.path)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// newTestReportStats returns finished run statistics of a two-table run with warnings
+func newTestReportStats() *RunStats {
+	stats := NewRunStats(false)
+	stats.RunID = "run-1"
+	stats.Start = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	stats.End = stats.Start.Add(90 * time.Second)
+	stats.setSyncOrder([]string{"customers", "orders"}, []string{"orders", "customers"})
+
+	customers := stats.Table("customers")
+	customers.SyncMode = SyncModeDiff
+	customers.RowsRead = 10
+	customers.Inserted = 2
+	customers.Updated = 3
+	customers.Rejected = 1
+	customers.DiffDuration = 2 * time.Second
+
+	orders := stats.Table("orders")
+	orders.SyncMode = SyncModeOverwrite
+	orders.RowsRead = 5
+	orders.Inserted = 5
+	orders.Deleted = 4
+	orders.WriteDuration = 500 * time.Millisecond
+
+	stats.addWarning("customers", "Skipping database record with nil primary key")
+	stats.addWarning("customers", "Skipping database record with nil primary key")
+	stats.addWarning("", "Table <b> is not configured")
+	return stats
+}
+
+func TestNewRunReport(t *testing.T) {
+	t.Run("successful run", func(t *testing.T) {
+		report := newRunReport(newTestReportStats(), nil)
+
+		if report.Status != ReportStatusSuccess || report.Error != "" || report.DurationSeconds != 90 {
+			t.Errorf("Unexpected run fields: %+v", report)
+		}
+		wantTotals := ReportCounts{RowsRead: 15, Inserted: 7, Updated: 3, Deleted: 4, Skipped: 1}
+		if report.Totals != wantTotals {
+			t.Errorf("Totals = %+v, want %+v", report.Totals, wantTotals)
+		}
+		wantTables := []TableReport{
+			{Table: "customers", Mode: SyncModeDiff, ReportCounts: ReportCounts{RowsRead: 10, Inserted: 2, Updated: 3, Skipped: 1}, DiffSeconds: 2, Warnings: 2},
+			{Table: "orders", Mode: SyncModeOverwrite, ReportCounts: ReportCounts{RowsRead: 5, Inserted: 5, Deleted: 4}, WriteSeconds: 0.5},
+		}
+		if diff := cmp.Diff(wantTables, report.Tables); diff != "" {
+			t.Errorf("Tables mismatch (-want +got):\n%s", diff)
+		}
+		if len(report.Warnings) != 2 || report.Warnings[0].Count != 2 {
+			t.Errorf("Unexpected warnings: %+v", report.Warnings)
+		}
+	})
+
+	t.Run("failed run masks secrets in the error", func(t *testing.T) {
+		registerSecret("s3cr3t-report-value")
+		report := newRunReport(newTestReportStats(), errors.New("access denied for s3cr3t-report-value"))
+		if report.Status != ReportStatusFailed {
+			t.Errorf("Expected status failed, got %s", report.Status)
+		}
+		if strings.Contains(report.Error, "s3cr3t-report-value") {
+			t.Errorf("Secret leaked into the report: %s", report.Error)
+		}
+	})
+}
+
+func TestRenderReportJSON(t *testing.T) {
+	data, err := renderReportJSON(newRunReport(newTestReportStats(), nil))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	for _, key := range []string{"run_id", "status", "dry_run", "started_at", "finished_at", "duration_seconds", "retries", "insert_order", "delete_order", "totals", "tables", "warnings"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("Expected key %q in JSON report", key)
+		}
+	}
+	table := decoded["tables"].([]any)[0].(map[string]any)
+	for _, key := range []string{"table", "mode", "rows_read", "inserted", "updated", "deleted", "skipped", "diff_seconds", "write_seconds", "warnings"} {
+		if _, ok := table[key]; !ok {
+			t.Errorf("Expected key %q in table report", key)
+		}
+	}
+}
+
+func TestRenderReportHTML(t *testing.T) {
+	data, err := renderReportHTML(newRunReport(newTestReportStats(), errors.New("deadlock")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	html := string(data)
+
+	for _, want := range []string{
+		"<title>mydatasyncer run report run-1</title>",
+		`<span class="status failed">failed</span>`,
+		"customers → orders",
+		"<td>customers</td><td>diff</td>",
+		"Table &lt;b&gt; is not configured", // Messages are escaped
+		"2026-01-02T03:04:05Z",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected %q in HTML report", want)
+		}
+	}
+	// Self-contained: nothing is loaded from elsewhere
+	for _, external := range []string{"<script", "<link", "src="} {
+		if strings.Contains(html, external) {
+			t.Errorf("Unexpected external reference %q in HTML report", external)
+		}
+	}
+}
+
+func TestWriteRunReports(t *testing.T) {
+	dir := t.TempDir()
+	reportConfig := ReportConfig{JSON: filepath.Join(dir, "report.json"), HTML: filepath.Join(dir, "report.html")}
+	writeRunReports(reportConfig, newRunReport(newTestReportStats(), nil))
+
+	for _, path := range []string{reportConfig.JSON, reportConfig.HTML} {
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("Expected report at %s: %v", path, err)
+		}
+	}
+
+	// A report that cannot be written is only logged
+	writeRunReports(ReportConfig{JSON: filepath.Join(dir, "missing", "report.json")}, newRunReport(newTestReportStats(), nil))
+}
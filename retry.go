@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL error numbers that abort a transaction but succeed when it is run again
+const (
+	mysqlErrLockWaitTimeout = 1205 // ER_LOCK_WAIT_TIMEOUT
+	mysqlErrDeadlock        = 1213 // ER_LOCK_DEADLOCK
+)
+
+// commitError marks a failed COMMIT. Whether the transaction was applied is unknown,
+// so a commit error is never retried.
+type commitError struct {
+	err error
+}
+
+func (e *commitError) Error() string {
+	return fmt.Sprintf("transaction commit error: %v", e.err)
+}
+
+func (e *commitError) Unwrap() error {
+	return e.err
+}
+
+// isRetryableError reports whether err is a transient error after which the whole transaction can be run again
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var commitErr *commitError
+	if errors.As(err, &commitErr) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDeadlock || mysqlErr.Number == mysqlErrLockWaitTimeout
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// withDefaults returns the retry settings with unset values replaced by the defaults
+func (r RetryConfig) withDefaults() RetryConfig {
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if r.InitialBackoff == 0 {
+		r.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if r.MaxBackoff == 0 {
+		r.MaxBackoff = max(DefaultRetryMaxBackoff, r.InitialBackoff)
+	}
+	return r
+}
+
+// retryBackoff returns the wait before the given retry (1 for the first retry):
+// exponential backoff capped at MaxBackoff, with jitter drawn from [backoff/2, backoff)
+func retryBackoff(retry RetryConfig, retryNumber int, random func() float64) time.Duration {
+	backoff := retry.InitialBackoff
+	for i := 1; i < retryNumber && backoff < retry.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, retry.MaxBackoff)
+	return backoff/2 + time.Duration(random()*float64(backoff/2))
+}
+
+// withRetry runs fn and runs it again while it fails with a retryable error, up to retry.MaxAttempts times in total
+// fn must start and finish its own transaction so that every attempt starts from a clean state.
+func withRetry(ctx context.Context, retry RetryConfig, operation string, fn func() error) error {
+	retry = retry.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			if attempt > 1 {
+				slog.InfoContext(ctx, "Retry succeeded", "operation", operation, "attempt", attempt, "max_attempts", retry.MaxAttempts)
+			}
+			return nil
+		}
+		if !isRetryableError(err) {
+			return err
+		}
+		if attempt >= retry.MaxAttempts {
+			if retry.MaxAttempts > 1 {
+				return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+			return err
+		}
+
+		runStatsFrom(ctx).recordRetry()
+		wait := retryBackoff(retry, attempt, rand.Float64)
+		slog.WarnContext(ctx, "Attempt failed with a retryable error, retrying",
+			"operation", operation, "attempt", attempt, "max_attempts", retry.MaxAttempts,
+			"error", err, "backoff", wait.Round(time.Millisecond))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
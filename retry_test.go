@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "deadlock", err: &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}, want: true},
+		{name: "lock wait timeout", err: &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, want: true},
+		{name: "wrapped deadlock", err: fmt.Errorf("DELETE execution error: %w", &mysql.MySQLError{Number: 1213}), want: true},
+		{name: "duplicate key", err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}, want: false},
+		{name: "bad connection", err: driver.ErrBadConn, want: true},
+		{name: "invalid connection", err: mysql.ErrInvalidConn, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "network error", err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, want: true},
+		{name: "commit error is never retried", err: &commitError{err: mysql.ErrInvalidConn}, want: false},
+		{name: "wrapped commit error", err: fmt.Errorf("sync: %w", &commitError{err: &mysql.MySQLError{Number: 1213}}), want: false},
+		{name: "context cancelled", err: context.Canceled, want: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: false},
+		{name: "plain error", err: errors.New("file not found"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	retry := RetryConfig{MaxAttempts: 10, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	tests := []struct {
+		retryNumber int
+		random      float64
+		want        time.Duration
+	}{
+		{retryNumber: 1, random: 0, want: 500 * time.Millisecond},
+		{retryNumber: 1, random: 0.5, want: 750 * time.Millisecond},
+		{retryNumber: 2, random: 0, want: time.Second},
+		{retryNumber: 3, random: 0, want: 2 * time.Second},
+		{retryNumber: 4, random: 0, want: 2500 * time.Millisecond}, // capped at MaxBackoff
+		{retryNumber: 50, random: 0.999, want: 2500*time.Millisecond + time.Duration(0.999*float64(2500*time.Millisecond))},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("retry %d random %.3f", tt.retryNumber, tt.random), func(t *testing.T) {
+			got := retryBackoff(retry, tt.retryNumber, func() float64 { return tt.random })
+			if got != tt.want {
+				t.Errorf("retryBackoff() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryConfigWithDefaults(t *testing.T) {
+	got := RetryConfig{}.withDefaults()
+	want := RetryConfig{MaxAttempts: DefaultRetryMaxAttempts, InitialBackoff: DefaultRetryInitialBackoff, MaxBackoff: DefaultRetryMaxBackoff}
+	if got != want {
+		t.Errorf("withDefaults() = %+v, want %+v", got, want)
+	}
+
+	got = RetryConfig{MaxAttempts: 1, InitialBackoff: time.Minute}.withDefaults()
+	if got.MaxAttempts != 1 || got.MaxBackoff != time.Minute {
+		t.Errorf("Expected explicit values to be kept and MaxBackoff >= InitialBackoff, got %+v", got)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	fast := RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+
+	t.Run("retries until success", func(t *testing.T) {
+		calls := 0
+		err := withRetry(context.Background(), fast, "test", func() error {
+			calls++
+			if calls < 3 {
+				return deadlock
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("Expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		calls := 0
+		err := withRetry(context.Background(), fast, "test", func() error {
+			calls++
+			return deadlock
+		})
+		if calls != 3 {
+			t.Errorf("Expected 3 calls, got %d", calls)
+		}
+		if !errors.Is(err, deadlock) || !strings.Contains(err.Error(), "giving up after 3 attempts") {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("does not retry non-retryable errors", func(t *testing.T) {
+		calls := 0
+		wantErr := errors.New("primary key validation failed")
+		err := withRetry(context.Background(), fast, "test", func() error {
+			calls++
+			return wantErr
+		})
+		if calls != 1 || err != wantErr {
+			t.Errorf("Expected a single call returning the error, got %d calls and %v", calls, err)
+		}
+	})
+
+	t.Run("does not retry commit errors", func(t *testing.T) {
+		calls := 0
+		err := withRetry(context.Background(), fast, "test", func() error {
+			calls++
+			return &commitError{err: mysql.ErrInvalidConn}
+		})
+		if calls != 1 {
+			t.Errorf("Expected a single call, got %d", calls)
+		}
+		if err == nil || !strings.Contains(err.Error(), "transaction commit error") {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("stops waiting when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		slow := RetryConfig{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+		calls := 0
+		err := withRetry(ctx, slow, "test", func() error {
+			calls++
+			cancel()
+			return deadlock
+		})
+		if calls != 1 || !errors.Is(err, deadlock) {
+			t.Errorf("Expected a single call returning the deadlock, got %d calls and %v", calls, err)
+		}
+	})
+}
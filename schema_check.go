@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ColumnSchema describes a table column as reported by INFORMATION_SCHEMA.COLUMNS
+type ColumnSchema struct {
+	Name       string
+	DataType   string // Base type, e.g. "varchar", "int" or "decimal"
+	ColumnType string // Full type, e.g. "int unsigned" or "enum('new','paid')"
+	MaxLength  int64  // Maximum length of string and binary columns (characters, bytes for TEXT and binary types); 0 otherwise
+	Precision  int64  // Total number of digits of DECIMAL columns
+	Scale      int64  // Number of digits after the decimal point of DECIMAL columns
+	Nullable   bool
+}
+
+// SchemaViolation is a file value that does not fit the type of its column
+type SchemaViolation struct {
+	RecordIndex int    // Zero-based index in the record slice
+	Column      string // Column that was checked
+	Check       string // Failed check: null, length, type, range, scale or enum
+	Value       string // The offending value
+}
+
+// integerRanges are the signed ranges of the MySQL integer types
+var integerRanges = map[string]struct{ min, max int64 }{
+	"tinyint":   {-1 << 7, 1<<7 - 1},
+	"smallint":  {-1 << 15, 1<<15 - 1},
+	"mediumint": {-1 << 23, 1<<23 - 1},
+	"int":       {-1 << 31, 1<<31 - 1},
+	"bigint":    {-1 << 63, 1<<63 - 1},
+}
+
+// Layouts accepted for DATE and DATETIME/TIMESTAMP values
+var (
+	dateLayouts     = []string{time.DateOnly}
+	datetimeLayouts = []string{time.DateOnly, "2006-01-02 15:04:05.999999", "2006-01-02T15:04:05.999999"}
+)
+
+var (
+	decimalPattern = regexp.MustCompile(`^[+-]?(\d*)(?:\.(\d*))?(?:[eE]([+-]?\d+))?$`)
+	floatPattern   = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+	timePattern    = regexp.MustCompile(`^-?\d{1,3}:\d{2}(:\d{2}(\.\d{1,6})?)?$`)
+)
+
+// getTableSchema retrieves the column types of a given table
+func getTableSchema(ctx context.Context, db *sql.DB, tableName string) ([]ColumnSchema, error) {
+	query := "SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE, IS_NULLABLE " +
+		"FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION"
+	rows, err := db.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query column types for table %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	var schema []ColumnSchema
+	for rows.Next() {
+		var column ColumnSchema
+		var maxLength, precision, scale sql.NullInt64
+		var nullable string
+		if err := rows.Scan(&column.Name, &column.DataType, &column.ColumnType, &maxLength, &precision, &scale, &nullable); err != nil {
+			return nil, fmt.Errorf("failed to scan column type for table %s: %w", tableName, err)
+		}
+		column.DataType = strings.ToLower(column.DataType)
+		column.ColumnType = strings.ToLower(column.ColumnType)
+		column.MaxLength = maxLength.Int64
+		column.Precision = precision.Int64
+		column.Scale = scale.Int64
+		column.Nullable = nullable == "YES"
+		schema = append(schema, column)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows for table %s column types: %w", tableName, err)
+	}
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("no columns found for table %s or table does not exist", tableName)
+	}
+	return schema, nil
+}
+
+// checkSchema checks the values of the given columns of every record against the column types
+// Columns that are not in the schema are ignored, as they are not synced.
+func checkSchema(schema []ColumnSchema, records []DataRecord, columns []string) []SchemaViolation {
+	var violations []SchemaViolation
+	for _, column := range schema {
+		if !slices.Contains(columns, column.Name) {
+			continue
+		}
+		for i, record := range records {
+			value, exists := record[column.Name]
+			if !exists {
+				continue
+			}
+			if check := column.check(value); check != "" {
+				violations = append(violations, SchemaViolation{
+					RecordIndex: i,
+					Column:      column.Name,
+					Check:       check,
+					Value:       schemaValueString(value),
+				})
+			}
+		}
+	}
+
+	slices.SortStableFunc(violations, func(a, b SchemaViolation) int { return a.RecordIndex - b.RecordIndex })
+	return violations
+}
+
+// schemaValueString formats a value the way it is sent to the database
+func schemaValueString(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999")
+	}
+	return convertValueToString(value)
+}
+
+// check returns the check that value fails for the column, or "" if the value fits
+func (c ColumnSchema) check(value any) string {
+	if value == nil {
+		if !c.Nullable {
+			return "null"
+		}
+		return ""
+	}
+	text := schemaValueString(value)
+
+	switch c.DataType {
+	case "char", "varchar":
+		if c.MaxLength > 0 && int64(utf8.RuneCountInString(text)) > c.MaxLength {
+			return "length"
+		}
+	case "tinytext", "text", "mediumtext", "longtext", "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		if c.MaxLength > 0 && int64(len(text)) > c.MaxLength {
+			return "length"
+		}
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+		if _, ok := value.(bool); ok {
+			return ""
+		}
+		return c.checkInteger(text)
+	case "decimal":
+		return c.checkDecimal(text)
+	case "float", "double":
+		// ParseFloat also accepts NaN, Inf and hexadecimal floats, which MySQL rejects
+		text = strings.TrimSpace(text)
+		if !floatPattern.MatchString(text) {
+			return "type"
+		}
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return "range"
+			}
+			return "type"
+		}
+	case "year":
+		year, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			return "type"
+		}
+		if year != 0 && (year < 1901 || year > 2155) {
+			return "range"
+		}
+	case "date":
+		return checkTimeValue(value, text, dateLayouts)
+	case "datetime", "timestamp":
+		return checkTimeValue(value, text, datetimeLayouts)
+	case "time":
+		if !timePattern.MatchString(text) {
+			return "type"
+		}
+	case "enum":
+		if !slices.ContainsFunc(enumValues(c.ColumnType), func(v string) bool { return strings.EqualFold(v, text) }) {
+			return "enum"
+		}
+	case "set":
+		if text == "" {
+			return ""
+		}
+		allowed := enumValues(c.ColumnType)
+		for member := range strings.SplitSeq(text, ",") {
+			if !slices.ContainsFunc(allowed, func(v string) bool { return strings.EqualFold(v, member) }) {
+				return "enum"
+			}
+		}
+	case "json":
+		if !json.Valid([]byte(text)) {
+			return "type"
+		}
+	}
+	return ""
+}
+
+// checkInteger checks a value for an integer column, honoring the unsigned attribute
+func (c ColumnSchema) checkInteger(text string) string {
+	text = strings.TrimSpace(text)
+	bounds := integerRanges[c.DataType]
+	if strings.Contains(c.ColumnType, "unsigned") {
+		n, err := strconv.ParseUint(strings.TrimPrefix(text, "+"), 10, 64)
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) || strings.HasPrefix(text, "-") {
+				return "range"
+			}
+			return "type"
+		}
+		if c.DataType != "bigint" && n > uint64(bounds.max)*2+1 {
+			return "range"
+		}
+		return ""
+	}
+
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return "range"
+		}
+		return "type"
+	}
+	if n < bounds.min || n > bounds.max {
+		return "range"
+	}
+	return ""
+}
+
+// checkDecimal checks a value for a DECIMAL(precision, scale) column
+func (c ColumnSchema) checkDecimal(text string) string {
+	match := decimalPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil || match[1]+match[2] == "" {
+		return "type"
+	}
+	exponent := 0
+	if match[3] != "" {
+		var err error
+		if exponent, err = strconv.Atoi(match[3]); err != nil {
+			return "range"
+		}
+	}
+
+	// Count the significant digits on either side of the decimal point after applying the exponent
+	digits := match[1] + match[2]
+	first := strings.IndexFunc(digits, func(r rune) bool { return r != '0' })
+	if first < 0 {
+		return ""
+	}
+	last := strings.LastIndexFunc(digits, func(r rune) bool { return r != '0' })
+	point := int64(len(match[1])) + int64(exponent)
+	integerDigits := max(point-int64(first), 0)
+	fractionDigits := max(int64(last)+1-point, 0)
+	if c.Precision > 0 && integerDigits > c.Precision-c.Scale {
+		return "range"
+	}
+	if fractionDigits > c.Scale {
+		return "scale"
+	}
+	return ""
+}
+
+// checkTimeValue checks a value for a date or time column against the accepted layouts
+func checkTimeValue(value any, text string, layouts []string) string {
+	if _, ok := value.(time.Time); ok {
+		return ""
+	}
+	for _, layout := range layouts {
+		if _, err := time.Parse(layout, text); err == nil {
+			return ""
+		}
+	}
+	return "type"
+}
+
+// enumValues returns the allowed values of an enum('a','b') or set('a','b') column type
+func enumValues(columnType string) []string {
+	start, end := strings.Index(columnType, "("), strings.LastIndex(columnType, ")")
+	if start < 0 || end <= start {
+		return nil
+	}
+	var values []string
+	for _, quoted := range splitQuotedList(columnType[start+1 : end]) {
+		values = append(values, strings.ReplaceAll(quoted, "''", "'"))
+	}
+	return values
+}
+
+// splitQuotedList splits a list of single-quoted values such as 'a','b,c' into the values, keeping doubled quotes
+func splitQuotedList(list string) []string {
+	var values []string
+	inQuotes := false
+	var current strings.Builder
+	for i := 0; i < len(list); i++ {
+		ch := list[i]
+		switch {
+		case ch == '\'' && inQuotes && i+1 < len(list) && list[i+1] == '\'':
+			current.WriteString("''")
+			i++
+		case ch == '\'':
+			inQuotes = !inQuotes
+			if !inQuotes {
+				values = append(values, current.String())
+				current.Reset()
+			}
+		case inQuotes:
+			current.WriteByte(ch)
+		}
+	}
+	return values
+}
+
+// reportSchemaViolations logs the violations per column and check, with the affected record numbers (1-based)
+func reportSchemaViolations(ctx context.Context, schema []ColumnSchema, violations []SchemaViolation, totalRecords int) {
+	type issue struct {
+		column, check string
+		indices       []int
+		example       string
+	}
+	var issues []*issue
+	for _, v := range violations {
+		idx := slices.IndexFunc(issues, func(i *issue) bool { return i.column == v.Column && i.check == v.Check })
+		if idx < 0 {
+			issues = append(issues, &issue{column: v.Column, check: v.Check, example: v.Value})
+			idx = len(issues) - 1
+		}
+		issues[idx].indices = append(issues[idx].indices, v.RecordIndex)
+	}
+
+	slog.ErrorContext(ctx, "Schema check failed, sync aborted",
+		"total_records", totalRecords,
+		"violations", len(violations))
+	for _, i := range issues {
+		columnType := ""
+		if idx := slices.IndexFunc(schema, func(c ColumnSchema) bool { return c.Name == i.column }); idx >= 0 {
+			columnType = schema[idx].ColumnType
+		}
+		slog.ErrorContext(ctx, fmt.Sprintf("Column '%s' failed %s check in %d records", i.column, i.check, len(i.indices)),
+			"column_type", columnType, "records", formatRecordNumbers(i.indices), "example", i.example)
+	}
+}
+
+// checkTableSchema checks the records of a single-table config against the column types of its table
+// It runs outside the write transaction, so that incompatible values are reported before anything is written.
+func checkTableSchema(ctx context.Context, db *sql.DB, config Config, records recordSource) error {
+	first, err := firstRecord(records)
+	if err != nil || first == nil {
+		return err
+	}
+	stmtCtx, cancel := statementContext(ctx, config)
+	schema, err := getTableSchema(stmtCtx, db, config.Sync.TableName)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(schema))
+	for _, column := range schema {
+		names = append(names, column.Name)
+	}
+	fileHeaders := make([]string, 0, len(first))
+	for k := range first {
+		fileHeaders = append(fileHeaders, k)
+	}
+	columns := filterColumnsByConfig(findCommonColumns(fileHeaders, names), config.Sync.Columns)
+
+	// Batches are checked in order, so the violations stay sorted by record
+	var violations []SchemaViolation
+	total := 0
+	err = records.each(func(offset int, batch []DataRecord) error {
+		total += len(batch)
+		for _, v := range checkSchema(schema, batch, columns) {
+			v.RecordIndex += offset
+			violations = append(violations, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		reportSchemaViolations(ctx, schema, violations, total)
+		return fmt.Errorf("%d values in %d records do not fit the column types", len(violations), total)
+	}
+	slog.InfoContext(ctx, "Schema check passed", "records", total, "columns", len(columns))
+	return nil
+}
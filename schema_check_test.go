@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestColumnSchemaCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		column ColumnSchema
+		value  any
+		want   string
+	}{
+		{"NULL in nullable column", ColumnSchema{DataType: "int", Nullable: true}, nil, ""},
+		{"NULL in NOT NULL column", ColumnSchema{DataType: "varchar", MaxLength: 10}, nil, "null"},
+		{"varchar fits", ColumnSchema{DataType: "varchar", MaxLength: 5}, "héllo", ""},
+		{"varchar too long", ColumnSchema{DataType: "varchar", MaxLength: 5}, "hello!", "length"},
+		{"text limit is in bytes", ColumnSchema{DataType: "tinytext", MaxLength: 4}, "héllo", "length"},
+		{"int", ColumnSchema{DataType: "int", ColumnType: "int"}, "-2147483648", ""},
+		{"int out of range", ColumnSchema{DataType: "int", ColumnType: "int"}, "2147483648", "range"},
+		{"text in int column", ColumnSchema{DataType: "int", ColumnType: "int"}, "abc", "type"},
+		{"empty string in int column", ColumnSchema{DataType: "int", ColumnType: "int", Nullable: true}, "", "type"},
+		{"fraction in int column", ColumnSchema{DataType: "int", ColumnType: "int"}, 1.5, "type"},
+		{"whole JSON number in int column", ColumnSchema{DataType: "int", ColumnType: "int"}, 42.0, ""},
+		{"bool in tinyint column", ColumnSchema{DataType: "tinyint", ColumnType: "tinyint(1)"}, true, ""},
+		{"tinyint unsigned", ColumnSchema{DataType: "tinyint", ColumnType: "tinyint unsigned"}, "255", ""},
+		{"tinyint unsigned out of range", ColumnSchema{DataType: "tinyint", ColumnType: "tinyint unsigned"}, "256", "range"},
+		{"negative in unsigned column", ColumnSchema{DataType: "int", ColumnType: "int unsigned"}, "-1", "range"},
+		{"bigint unsigned", ColumnSchema{DataType: "bigint", ColumnType: "bigint unsigned"}, "18446744073709551615", ""},
+		{"decimal", ColumnSchema{DataType: "decimal", Precision: 5, Scale: 2}, "-123.45", ""},
+		{"decimal trailing zeros", ColumnSchema{DataType: "decimal", Precision: 5, Scale: 2}, "0123.4500", ""},
+		{"decimal too many integer digits", ColumnSchema{DataType: "decimal", Precision: 5, Scale: 2}, "1234.5", "range"},
+		{"decimal too many fraction digits", ColumnSchema{DataType: "decimal", Precision: 5, Scale: 2}, "1.234", "scale"},
+		{"text in decimal column", ColumnSchema{DataType: "decimal", Precision: 5, Scale: 2}, "1,50", "type"},
+		{"decimal in exponent notation", ColumnSchema{DataType: "decimal", Precision: 5, Scale: 2}, "1e2", ""},
+		{"decimal with negative exponent", ColumnSchema{DataType: "decimal", Precision: 5, Scale: 2}, "125E-2", ""},
+		{"decimal exponent beyond precision", ColumnSchema{DataType: "decimal", Precision: 5, Scale: 2}, "1e3", "range"},
+		{"decimal exponent beyond scale", ColumnSchema{DataType: "decimal", Precision: 5, Scale: 2}, "1.5e-2", "scale"},
+		{"zero with large exponent", ColumnSchema{DataType: "decimal", Precision: 5, Scale: 2}, "0e999999", ""},
+		{"double", ColumnSchema{DataType: "double"}, "1.5e10", ""},
+		{"text in double column", ColumnSchema{DataType: "double"}, "n/a", "type"},
+		{"NaN in double column", ColumnSchema{DataType: "double"}, math.NaN(), "type"},
+		{"infinity text in float column", ColumnSchema{DataType: "float"}, "-Inf", "type"},
+		{"hexadecimal float in double column", ColumnSchema{DataType: "double"}, "0x1p-2", "type"},
+		{"double out of range", ColumnSchema{DataType: "double"}, "1e400", "range"},
+		{"year", ColumnSchema{DataType: "year"}, "2024", ""},
+		{"year out of range", ColumnSchema{DataType: "year"}, "1800", "range"},
+		{"date", ColumnSchema{DataType: "date"}, "2024-02-29", ""},
+		{"invalid date", ColumnSchema{DataType: "date"}, "2023-02-29", "type"},
+		{"date with other layout", ColumnSchema{DataType: "date"}, "29/02/2024", "type"},
+		{"datetime", ColumnSchema{DataType: "datetime"}, "2024-01-02 15:04:05.123", ""},
+		{"parsed timestamp", ColumnSchema{DataType: "timestamp"}, time.Now(), ""},
+		{"time", ColumnSchema{DataType: "time"}, "-838:59:59", ""},
+		{"invalid time", ColumnSchema{DataType: "time"}, "noon", "type"},
+		{"enum", ColumnSchema{DataType: "enum", ColumnType: "enum('new','it''s paid')"}, "it's paid", ""},
+		{"enum is case-insensitive", ColumnSchema{DataType: "enum", ColumnType: "enum('new','paid')"}, "NEW", ""},
+		{"unknown enum value", ColumnSchema{DataType: "enum", ColumnType: "enum('new','paid')"}, "lost", "enum"},
+		{"set", ColumnSchema{DataType: "set", ColumnType: "set('a','b','c')"}, "a,c", ""},
+		{"empty set", ColumnSchema{DataType: "set", ColumnType: "set('a','b')"}, "", ""},
+		{"unknown set member", ColumnSchema{DataType: "set", ColumnType: "set('a','b')"}, "a,x", "enum"},
+		{"json", ColumnSchema{DataType: "json"}, `{"a": [1, 2]}`, ""},
+		{"invalid json", ColumnSchema{DataType: "json"}, `{"a": `, "type"},
+		{"unchecked type", ColumnSchema{DataType: "geometry"}, "anything", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.column.check(tt.value); got != tt.want {
+				t.Errorf("check(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	tests := []struct {
+		columnType string
+		want       []string
+	}{
+		{"enum('a','b')", []string{"a", "b"}},
+		{"set('x,y','it''s','')", []string{"x,y", "it's", ""}},
+		{"int", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.columnType, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, enumValues(tt.columnType)); diff != "" {
+				t.Errorf("enumValues mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	schema := []ColumnSchema{
+		{Name: "id", DataType: "int", ColumnType: "int"},
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(5)", MaxLength: 5, Nullable: true},
+		{Name: "price", DataType: "decimal", ColumnType: "decimal(5,2)", Precision: 5, Scale: 2},
+		{Name: "note", DataType: "varchar", ColumnType: "varchar(1)", MaxLength: 1},
+	}
+	records := []DataRecord{
+		{"id": "1", "name": "Alice", "price": "9.99", "note": "too long, but not synced"},
+		{"id": "x", "name": "Bobby Tables", "price": "1.5"},
+		{"id": "3", "name": nil, "price": "99999"},
+	}
+
+	got := checkSchema(schema, records, []string{"id", "name", "price"})
+	want := []SchemaViolation{
+		{RecordIndex: 1, Column: "id", Check: "type", Value: "x"},
+		{RecordIndex: 1, Column: "name", Check: "length", Value: "Bobby Tables"},
+		{RecordIndex: 2, Column: "price", Check: "range", Value: "99999"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Violations mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReportSchemaViolations(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, LogOptions{Format: LogFormatJSON}, "run")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	schema := []ColumnSchema{{Name: "name", DataType: "varchar", ColumnType: "varchar(5)", MaxLength: 5}}
+	violations := []SchemaViolation{
+		{RecordIndex: 1, Column: "name", Check: "length", Value: "Bobby Tables"},
+		{RecordIndex: 4, Column: "name", Check: "length", Value: "Mallory"},
+	}
+	reportSchemaViolations(withLogTable(context.Background(), "users"), schema, violations, 5)
+
+	lines := decodeLogLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log records, got %d: %v", len(lines), lines)
+	}
+	detail := lines[1]
+	want := map[string]any{
+		"msg":         "Column 'name' failed length check in 2 records",
+		"column_type": "varchar(5)",
+		"records":     "2, 5",
+		"example":     "Bobby Tables",
+		"table":       "users",
+	}
+	for key, value := range want {
+		if detail[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, detail[key])
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// runIDPlaceholder is replaced by the ID of the run in constant values
+// It is reserved: environment variable expansion leaves it untouched.
+const runIDPlaceholder = "${RUN_ID}"
+
+// applyConstants sets the constant columns of a table in every record, with ${RUN_ID} replaced by the run ID
+// It runs right after loading, so that transforms can use the constants and dates in them are parsed.
+func applyConstants(constants map[string]string, runID string, records []DataRecord) {
+	if len(constants) == 0 {
+		return
+	}
+	for _, record := range records {
+		for column, value := range constants {
+			record[column] = strings.ReplaceAll(value, runIDPlaceholder, runID)
+		}
+	}
+}
+
+// isRunScoped reports whether a constant value changes with every run
+func isRunScoped(value string) bool {
+	return strings.Contains(value, runIDPlaceholder)
+}
+
+// runScopedColumns returns the constant columns whose values change with every run, e.g. a batch ID
+// They are written with inserts and updates but neither compared in the diff nor used to scope it.
+func runScopedColumns(config Config) []string {
+	var columns []string
+	for column, value := range config.Sync.Constants {
+		if isRunScoped(value) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// scopeFilters returns the column values that the sync of a table is limited to:
+// its scope filter and its constants, except those that change with every run
+func scopeFilters(config Config) map[string]string {
+	filters := make(map[string]string, len(config.Sync.Constants)+len(config.Sync.Scope))
+	for column, value := range config.Sync.Constants {
+		if !isRunScoped(value) {
+			filters[column] = value
+		}
+	}
+	maps.Copy(filters, config.Sync.Scope)
+	return filters
+}
+
+// scopeCondition returns the SQL condition that restricts a sync to the rows of its scope
+// e.g. "`region` = ? AND `tenant_id` = ?" for scope {region: JP} and constants {tenant_id: 42}, so that
+// the diff, deletes and overwrites only see those rows. It returns "" if the sync is not scoped.
+// Values of dateFormats columns are parsed like the file values, so that a date constant matches its column.
+func scopeCondition(config Config) (string, []any) {
+	filters := scopeFilters(config)
+	values := parsedScopeValues(config, filters)
+	var conditions []string
+	var args []any
+	for _, column := range slices.Sorted(maps.Keys(filters)) {
+		conditions = append(conditions, "`"+column+"` = ?")
+		args = append(args, values[column])
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// parsedScopeValues returns the filter values with those of dateFormats columns parsed like the file values
+func parsedScopeValues(config Config, filters map[string]string) DataRecord {
+	values := make(DataRecord, len(filters))
+	for column, value := range filters {
+		values[column] = value
+	}
+	if len(config.Sync.DateFormats) > 0 {
+		// A value that does not parse is kept as is; loading the file fails on it before any query runs
+		sourceLoc, _ := loadTimezone(config.Sync.Timezone)
+		_ = parseDateColumns([]DataRecord{values}, config.Sync.DateFormats, sourceLoc, dbLocation(config.DB))
+	}
+	return values
+}
+
+// describeScope returns the scope condition with its values for display, e.g. "region = 'JP'"
+func describeScope(config Config) string {
+	filters := scopeFilters(config)
+	conditions := make([]string, 0, len(filters))
+	for _, column := range slices.Sorted(maps.Keys(filters)) {
+		conditions = append(conditions, fmt.Sprintf("%s = '%s'", column, filters[column]))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// checkScope returns an error listing the records (1-based) whose values fall outside the scope filter
+// The scope values are parsed like in scopeCondition, so dates and numbers match by value, e.g. 1.0 matches 1.
+func checkScope(config Config, records recordSource) error {
+	scope := config.Sync.Scope
+	if len(scope) == 0 {
+		return nil
+	}
+	values := parsedScopeValues(config, scope)
+	var outside []int
+	err := records.each(func(offset int, batch []DataRecord) error {
+		for i, record := range batch {
+			for column, value := range values {
+				if v, ok := record[column]; !ok || v == nil || !scopeValueEqual(v, value) {
+					outside = append(outside, offset+i)
+					break
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(outside) > 0 {
+		return fmt.Errorf("%d records fall outside the scope %s: records %s",
+			len(outside), describeScope(Config{Sync: SyncConfig{Scope: scope}}), formatRecordNumbers(outside))
+	}
+	return nil
+}
+
+// scopeValueEqual reports whether a file value equals a value of parsedScopeValues
+func scopeValueEqual(fileVal, scopeVal any) bool {
+	if t, ok := scopeVal.(time.Time); ok {
+		fileTime, isTime := fileVal.(time.Time)
+		return isTime && fileTime.Equal(t)
+	}
+	return valuesEqual(fileVal, scopeVal)
+}
+
+// whereScope returns the WHERE clause of scopeCondition, or "" if the sync is not scoped
+func whereScope(config Config) (string, []any) {
+	condition, args := scopeCondition(config)
+	if condition == "" {
+		return "", nil
+	}
+	return " WHERE " + condition, args
+}
+
+// andScope returns scopeCondition as an additional AND condition, or "" if the sync is not scoped
+func andScope(config Config) (string, []any) {
+	condition, args := scopeCondition(config)
+	if condition == "" {
+		return "", nil
+	}
+	return " AND " + condition, args
+}
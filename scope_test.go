@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyConstants(t *testing.T) {
+	records := []DataRecord{{"id": "1", "tenant_id": "7"}, {"id": "2"}}
+	applyConstants(map[string]string{"tenant_id": "42", "batch_id": "import-${RUN_ID}"}, "run-42", records)
+
+	want := []DataRecord{
+		{"id": "1", "tenant_id": "42", "batch_id": "import-run-42"},
+		{"id": "2", "tenant_id": "42", "batch_id": "import-run-42"},
+	}
+	if diff := cmp.Diff(want, records); diff != "" {
+		t.Errorf("Records mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestScopeCondition(t *testing.T) {
+	tests := []struct {
+		name        string
+		constants   map[string]string
+		scope       map[string]string
+		wantWhere   string
+		wantAnd     string
+		wantArgs    []any
+		dateFormats map[string]string
+	}{
+		{"no constants", nil, nil, "", "", nil, nil},
+		{"run-scoped constants do not scope", map[string]string{"batch_id": "${RUN_ID}"}, nil, "", "", nil, nil},
+		{
+			name:      "constants in column order",
+			constants: map[string]string{"tenant_id": "42", "source": "erp", "batch_id": "${RUN_ID}"},
+			wantWhere: " WHERE `source` = ? AND `tenant_id` = ?",
+			wantAnd:   " AND `source` = ? AND `tenant_id` = ?",
+			wantArgs:  []any{"erp", "42"},
+		},
+		{
+			name:      "scope filter combined with constants",
+			constants: map[string]string{"tenant_id": "42"},
+			scope:     map[string]string{"region": "JP"},
+			wantWhere: " WHERE `region` = ? AND `tenant_id` = ?",
+			wantAnd:   " AND `region` = ? AND `tenant_id` = ?",
+			wantArgs:  []any{"JP", "42"},
+		},
+		{
+			name:        "date constants are parsed",
+			constants:   map[string]string{"imported_on": "2024/06/17"},
+			dateFormats: map[string]string{"imported_on": "2006/01/02"},
+			wantWhere:   " WHERE `imported_on` = ?",
+			wantAnd:     " AND `imported_on` = ?",
+			wantArgs:    []any{time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Sync: SyncConfig{Constants: tt.constants, Scope: tt.scope, DateFormats: tt.dateFormats}}
+			where, args := whereScope(config)
+			if where != tt.wantWhere {
+				t.Errorf("whereScope() = %q, want %q", where, tt.wantWhere)
+			}
+			if diff := cmp.Diff(tt.wantArgs, args); diff != "" {
+				t.Errorf("Args mismatch (-want +got):\n%s", diff)
+			}
+			if and, _ := andScope(config); and != tt.wantAnd {
+				t.Errorf("andScope() = %q, want %q", and, tt.wantAnd)
+			}
+		})
+	}
+}
+
+func TestDiffDataIgnoresRunScopedConstants(t *testing.T) {
+	config := Config{Sync: SyncConfig{
+		PrimaryKey:      "id",
+		DeleteNotInFile: true,
+		Constants:       map[string]string{"tenant_id": "42", "batch_id": "${RUN_ID}"},
+	}}
+	columns := []string{"id", "name", "tenant_id", "batch_id"}
+
+	fileRecords := []DataRecord{
+		{"id": "1", "name": "same", "tenant_id": "42", "batch_id": "new-run"},
+		{"id": "2", "name": "changed", "tenant_id": "42", "batch_id": "new-run"},
+	}
+	dbRecords := map[string]DataRecord{
+		"1": {"id": "1", "name": "same", "tenant_id": "42", "batch_id": "old-run"},
+		"2": {"id": "2", "name": "old", "tenant_id": "42", "batch_id": "old-run"},
+	}
+
+	toInsert, toUpdate, toDelete := diffData(context.Background(), config, fileRecords, dbRecords, columns)
+	if len(toInsert) != 0 || len(toDelete) != 0 {
+		t.Errorf("Expected no inserts or deletes, got %v and %v", toInsert, toDelete)
+	}
+	// Only the real change is updated, and the update writes the new batch ID
+	want := []UpdateOperation{{Before: dbRecords["2"], After: fileRecords[1]}}
+	if diff := cmp.Diff(want, toUpdate); diff != "" {
+		t.Errorf("Update mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDescribeScope(t *testing.T) {
+	config := Config{Sync: SyncConfig{
+		Constants: map[string]string{"tenant_id": "42", "batch_id": "${RUN_ID}"},
+		Scope:     map[string]string{"region": "JP"},
+	}}
+	if got, want := describeScope(config), "region = 'JP' AND tenant_id = '42'"; got != want {
+		t.Errorf("describeScope() = %q, want %q", got, want)
+	}
+	if got := describeScope(Config{}); got != "" {
+		t.Errorf("describeScope() = %q, want empty", got)
+	}
+}
+
+func TestCheckScope(t *testing.T) {
+	scope := map[string]string{"region": "JP", "tenant_id": "42"}
+	tests := []struct {
+		name    string
+		records []DataRecord
+		wantErr string
+	}{
+		{"no records", nil, ""},
+		{"all records in scope", []DataRecord{{"region": "JP", "tenant_id": 42.0}, {"region": "JP", "tenant_id": "42"}}, ""},
+		{
+			name: "records outside the scope",
+			records: []DataRecord{
+				{"region": "JP", "tenant_id": "42"},
+				{"region": "US", "tenant_id": "42"},
+				{"region": "JP", "tenant_id": nil},
+				{"tenant_id": "42"},
+			},
+			wantErr: "3 records fall outside the scope region = 'JP' AND tenant_id = '42': records 2, 3, 4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkScope(Config{Sync: SyncConfig{Scope: scope}}, memoryRecords(tt.records))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if err := checkScope(Config{}, memoryRecords{{"region": "US"}}); err != nil {
+		t.Errorf("Unexpected error without a scope: %v", err)
+	}
+
+	t.Run("date-formatted scope column", func(t *testing.T) {
+		config := Config{Sync: SyncConfig{
+			Scope:       map[string]string{"business_date": "2024/06/17"},
+			DateFormats: map[string]string{"business_date": "2006/01/02"},
+		}}
+		records := []DataRecord{{"business_date": "2024/06/17"}, {"business_date": "2024/06/18"}}
+		if err := parseDateColumns(records, config.Sync.DateFormats, nil, time.UTC); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		err := checkScope(config, memoryRecords(records))
+		if err == nil || err.Error() != "1 records fall outside the scope business_date = '2024/06/17': records 2" {
+			t.Errorf("Expected only record 2 outside the scope, got %v", err)
+		}
+	})
+
+	t.Run("streamed records are numbered across batches", func(t *testing.T) {
+		config := Config{Sync: SyncConfig{Scope: map[string]string{"region": "EU"}}}
+		records := newStreamedRecords(t, []DataRecord{{"region": "EU"}, {"region": "EU"}}, []DataRecord{{"region": "US"}})
+		err := checkScope(config, records)
+		if err == nil || err.Error() != "1 records fall outside the scope region = 'EU': records 3" {
+			t.Errorf("Expected only record 3 outside the scope, got %v", err)
+		}
+	})
+
+	t.Run("JSON number scope column", func(t *testing.T) {
+		config := Config{Sync: SyncConfig{Scope: map[string]string{"tenant_id": "1"}}}
+		records := []DataRecord{{"tenant_id": json.Number("1.0")}, {"tenant_id": json.Number("1")}, {"tenant_id": json.Number("1.5")}}
+		err := checkScope(config, memoryRecords(records))
+		if err == nil || err.Error() != "1 records fall outside the scope tenant_id = '1': records 3" {
+			t.Errorf("Expected only record 3 outside the scope, got %v", err)
+		}
+	})
+}
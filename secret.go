@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// secretMask replaces secret values in log output and error messages
+const secretMask = "****"
+
+// secretRegistry holds secret values (database passwords) that must never be written out
+type secretRegistry struct {
+	mu      sync.RWMutex
+	secrets []string
+}
+
+// secrets is the process-wide registry consulted by maskSecrets
+var secrets = &secretRegistry{}
+
+// registerSecret adds a value to the set of secrets masked in logs and errors
+func registerSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+	if slices.Contains(secrets.secrets, secret) {
+		return
+	}
+	secrets.secrets = append(secrets.secrets, secret)
+	// Mask longer secrets first so a secret containing another one is fully replaced
+	slices.SortFunc(secrets.secrets, func(a, b string) int { return len(b) - len(a) })
+}
+
+// registerDSNSecrets registers the password contained in a MySQL DSN, if any
+func registerDSNSecrets(dsn string) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return // Invalid DSNs are reported later by sql.Open / ValidateConfig
+	}
+	registerSecret(cfg.Passwd)
+}
+
+// maskSecrets replaces every registered secret in s with a mask
+func maskSecrets(s string) string {
+	secrets.mu.RLock()
+	defer secrets.mu.RUnlock()
+	for _, secret := range secrets.secrets {
+		s = strings.ReplaceAll(s, secret, secretMask)
+	}
+	return s
+}
+
+// maskedError wraps an error so its message never reveals registered secrets
+type maskedError struct {
+	err error
+}
+
+func (e *maskedError) Error() string {
+	return maskSecrets(e.err.Error())
+}
+
+func (e *maskedError) Unwrap() error {
+	return e.err
+}
+
+// maskError wraps err so that its message is masked; nil stays nil
+func maskError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var masked *maskedError
+	if errors.As(err, &masked) {
+		return err
+	}
+	return &maskedError{err: err}
+}
+
+// maskingWriter masks registered secrets in everything written through it (used for log output)
+type maskingWriter struct {
+	w io.Writer
+}
+
+// newMaskingWriter wraps w so that registered secrets are masked
+func newMaskingWriter(w io.Writer) io.Writer {
+	return &maskingWriter{w: w}
+}
+
+func (m *maskingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(m.w, maskSecrets(string(p))); err != nil {
+		return 0, err
+	}
+	// Report the original length so callers such as log.Logger do not treat masking as a short write
+	return len(p), nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	registerSecret("s3cr3t-value")
+	registerSecret("s3cr3t-value-longer")
+	registerSecret("") // Ignored
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "no secret", input: "nothing to hide", expected: "nothing to hide"},
+		{name: "secret in DSN", input: "user:s3cr3t-value@tcp(localhost)/db", expected: "user:****@tcp(localhost)/db"},
+		{name: "longer secret masked whole", input: "pw=s3cr3t-value-longer", expected: "pw=****"},
+		{name: "repeated secret", input: "s3cr3t-value s3cr3t-value", expected: "**** ****"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskSecrets(tt.input); got != tt.expected {
+				t.Errorf("maskSecrets(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRegisterDSNSecrets(t *testing.T) {
+	registerDSNSecrets("app:dsn-password-1@tcp(127.0.0.1:3306)/testdb?parseTime=true")
+	if got := maskSecrets("failed: dsn-password-1"); strings.Contains(got, "dsn-password-1") {
+		t.Errorf("Expected DSN password to be masked, got %q", got)
+	}
+
+	// Invalid DSNs are ignored rather than causing a panic
+	registerDSNSecrets("invalid:dsn:format")
+}
+
+func TestMaskError(t *testing.T) {
+	registerSecret("error-secret-9")
+	base := errors.New("access denied")
+	err := maskError(fmt.Errorf("connect with error-secret-9: %w", base))
+
+	if strings.Contains(err.Error(), "error-secret-9") {
+		t.Errorf("Expected secret to be masked, got %q", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Error("Expected masked error to unwrap to the original error")
+	}
+	if maskError(err) != err {
+		t.Error("Expected already masked error to be returned unchanged")
+	}
+	if maskError(nil) != nil {
+		t.Error("Expected nil error to stay nil")
+	}
+}
+
+func TestMaskingWriter(t *testing.T) {
+	registerSecret("log-secret-7")
+
+	var buf bytes.Buffer
+	logger := log.New(newMaskingWriter(&buf), "", 0)
+	logger.Printf("connecting with log-secret-7")
+
+	if strings.Contains(buf.String(), "log-secret-7") {
+		t.Errorf("Expected secret to be masked in log output, got %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "connecting with ****") {
+		t.Errorf("Unexpected log output: %q", buf.String())
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TableStats holds the counters and timings of one table in a run
+type TableStats struct {
+	Table         string
+	SyncMode      string
+	RowsRead      int           // Rows loaded from the file
+	Inserted      int           // Rows inserted (planned in dry-run mode)
+	Updated       int           // Rows updated (planned in dry-run mode)
+	Deleted       int           // Rows deleted (planned in dry-run mode)
+	Rejected      int           // File rows skipped because they could not be synchronized
+	Dropped       int           // File rows dropped before the sync by primary key validation in warn mode
+	DiffDuration  time.Duration // Time spent reading the table and computing differences
+	WriteDuration time.Duration // Time spent executing INSERT, UPDATE and DELETE statements
+}
+
+// RunWarning is a warning logged during a run; repeated warnings are counted instead of listed again
+type RunWarning struct {
+	Table   string // Empty for warnings that do not concern a single table
+	Message string
+	Count   int
+}
+
+// Skipped returns the number of file rows that were not synchronized
+func (t *TableStats) Skipped() int {
+	return t.Rejected + t.Dropped
+}
+
+// resetAttempt clears everything the transaction produced, so that a retried attempt starts from zero
+func (t *TableStats) resetAttempt() {
+	t.Inserted, t.Updated, t.Deleted, t.Rejected = 0, 0, 0, 0
+	t.DiffDuration, t.WriteDuration = 0, 0
+}
+
+// RunStats collects the statistics of a synchronization run
+type RunStats struct {
+	mu          sync.Mutex
+	RunID       string
+	Start       time.Time
+	End         time.Time
+	DryRun      bool
+	Retries     int      // Number of times a transaction was retried
+	InsertOrder []string // Dependency order used for inserts and updates (multi-table runs)
+	DeleteOrder []string // Dependency order used for deletes (multi-table runs)
+	tables      []*TableStats
+	warnings    []RunWarning
+}
+
+// NewRunStats returns statistics for a run starting now
+func NewRunStats(dryRun bool) *RunStats {
+	return &RunStats{Start: time.Now(), DryRun: dryRun}
+}
+
+// Table returns the statistics of the named table, adding them on first use
+func (r *RunStats) Table(name string) *TableStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tables {
+		if t.Table == name {
+			return t
+		}
+	}
+	t := &TableStats{Table: name}
+	r.tables = append(r.tables, t)
+	return t
+}
+
+// Tables returns the statistics of all tables in the order they were first used
+func (r *RunStats) Tables() []*TableStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*TableStats(nil), r.tables...)
+}
+
+// resetAttempt clears the per-attempt counters of all tables
+func (r *RunStats) resetAttempt() {
+	for _, t := range r.Tables() {
+		t.resetAttempt()
+	}
+}
+
+// recordRetry counts a retried transaction
+func (r *RunStats) recordRetry() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Retries++
+}
+
+// setSyncOrder records the dependency order used by a multi-table run
+func (r *RunStats) setSyncOrder(insertOrder, deleteOrder []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.InsertOrder, r.DeleteOrder = insertOrder, deleteOrder
+}
+
+// addWarning records a warning, counting repeats of the same message for the same table
+func (r *RunStats) addWarning(table, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.warnings {
+		if r.warnings[i].Table == table && r.warnings[i].Message == message {
+			r.warnings[i].Count++
+			return
+		}
+	}
+	r.warnings = append(r.warnings, RunWarning{Table: table, Message: message, Count: 1})
+}
+
+// Warnings returns the recorded warnings in the order they first occurred
+func (r *RunStats) Warnings() []RunWarning {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RunWarning(nil), r.warnings...)
+}
+
+// Finish records the end of the run
+func (r *RunStats) Finish() {
+	r.End = time.Now()
+}
+
+// Duration returns how long the run took (so far, if it has not finished)
+func (r *RunStats) Duration() time.Duration {
+	if r.End.IsZero() {
+		return time.Since(r.Start)
+	}
+	return r.End.Sub(r.Start)
+}
+
+// runStatsKey is the context key for the run's RunStats
+type runStatsKey struct{}
+
+// withRunStats returns a context carrying stats
+func withRunStats(ctx context.Context, stats *RunStats) context.Context {
+	return context.WithValue(ctx, runStatsKey{}, stats)
+}
+
+// runStatsFrom returns the RunStats of the context, or throwaway statistics if there are none
+func runStatsFrom(ctx context.Context) *RunStats {
+	if stats, ok := ctx.Value(runStatsKey{}).(*RunStats); ok {
+		return stats
+	}
+	return &RunStats{}
+}
+
+// currentTableStats returns the statistics of the table the context is working on (see withLogTable)
+func currentTableStats(ctx context.Context) *TableStats {
+	table, _ := ctx.Value(logTableKey{}).(string)
+	return runStatsFrom(ctx).Table(table)
+}
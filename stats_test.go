@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunStats(t *testing.T) {
+	t.Run("tables keep first-use order and identity", func(t *testing.T) {
+		stats := NewRunStats(false)
+		orders := stats.Table("orders")
+		stats.Table("customers")
+		if stats.Table("orders") != orders {
+			t.Error("Expected the same TableStats for the same table")
+		}
+
+		tables := stats.Tables()
+		if len(tables) != 2 || tables[0].Table != "orders" || tables[1].Table != "customers" {
+			t.Errorf("Unexpected tables: %+v", tables)
+		}
+	})
+
+	t.Run("resetAttempt keeps rows read", func(t *testing.T) {
+		stats := NewRunStats(false)
+		table := stats.Table("orders")
+		*table = TableStats{Table: "orders", SyncMode: SyncModeDiff, RowsRead: 10, Inserted: 1, Updated: 2, Deleted: 3, Rejected: 4,
+			DiffDuration: time.Second, WriteDuration: time.Second}
+
+		stats.resetAttempt()
+		want := TableStats{Table: "orders", SyncMode: SyncModeDiff, RowsRead: 10}
+		if *table != want {
+			t.Errorf("After reset got %+v, want %+v", *table, want)
+		}
+	})
+
+	t.Run("repeated warnings are counted", func(t *testing.T) {
+		stats := NewRunStats(false)
+		stats.addWarning("orders", "Skipping record")
+		stats.addWarning("customers", "Skipping record")
+		stats.addWarning("orders", "Skipping record")
+
+		want := []RunWarning{
+			{Table: "orders", Message: "Skipping record", Count: 2},
+			{Table: "customers", Message: "Skipping record", Count: 1},
+		}
+		if got := stats.Warnings(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("Warnings() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("duration uses the end time once finished", func(t *testing.T) {
+		stats := NewRunStats(false)
+		stats.Start = time.Now().Add(-time.Minute)
+		stats.Finish()
+		if d := stats.Duration(); d < time.Minute || d > time.Minute+time.Second {
+			t.Errorf("Unexpected duration %s", d)
+		}
+	})
+}
+
+func TestCurrentTableStats(t *testing.T) {
+	t.Run("uses the table from the context", func(t *testing.T) {
+		stats := NewRunStats(false)
+		ctx := withLogTable(withRunStats(context.Background(), stats), "orders")
+		currentTableStats(ctx).Inserted += 5
+
+		if got := stats.Table("orders").Inserted; got != 5 {
+			t.Errorf("Expected 5 inserted rows, got %d", got)
+		}
+	})
+
+	t.Run("without run stats the counters are discarded", func(t *testing.T) {
+		ctx := withLogTable(context.Background(), "orders")
+		currentTableStats(ctx).Inserted += 5 // Must not panic
+	})
+
+	t.Run("retries are counted through the context", func(t *testing.T) {
+		stats := NewRunStats(false)
+		runStatsFrom(withRunStats(context.Background(), stats)).recordRetry()
+		if stats.Retries != 1 {
+			t.Errorf("Expected 1 retry, got %d", stats.Retries)
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultDateOutputLayout is the layout of transformed dates when the date step sets none
+const defaultDateOutputLayout = time.DateTime
+
+var (
+	concatPlaceholder  = regexp.MustCompile(`\{([^{}]+)\}`)             // {column} placeholders of concat templates
+	plainNumberPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)$`) // Results of the number step
+)
+
+// Transformer applies the transform rules of a table to loaded records
+type Transformer struct {
+	rules []compiledTransform
+}
+
+// compiledTransform is a transform rule with its regular expressions compiled
+type compiledTransform struct {
+	column string
+	steps  []compiledStep
+}
+
+// compiledStep is a transform step with its regular expression compiled
+type compiledStep struct {
+	TransformStep
+	extract *regexp.Regexp
+}
+
+// NewTransformer creates a transformer for the given rules
+func NewTransformer(rules []TransformRule) (*Transformer, error) {
+	compiled := make([]compiledTransform, 0, len(rules))
+	for _, rule := range rules {
+		if len(rule.Steps) == 0 {
+			return nil, fmt.Errorf("at least one step is required")
+		}
+		c := compiledTransform{column: rule.Column}
+		for i, step := range rule.Steps {
+			if n := step.functionCount(); n != 1 {
+				return nil, fmt.Errorf("steps[%d]: exactly one function must be set, got %d", i, n)
+			}
+			cs := compiledStep{TransformStep: step}
+			if step.Extract != "" {
+				re, err := regexp.Compile(step.Extract)
+				if err != nil {
+					return nil, fmt.Errorf("steps[%d]: invalid extract regex: %w", i, err)
+				}
+				cs.extract = re
+			}
+			if step.Replace != nil && step.Replace.Old == "" {
+				return nil, fmt.Errorf("steps[%d]: replace.old is required", i)
+			}
+			if step.Date != nil && step.Date.From == "" {
+				return nil, fmt.Errorf("steps[%d]: date.from is required", i)
+			}
+			if step.Number != nil && step.Number.Decimal != "" && step.Number.Decimal == step.Number.Thousands {
+				return nil, fmt.Errorf("steps[%d]: number.decimal and number.thousands must differ", i)
+			}
+			c.steps = append(c.steps, cs)
+		}
+		compiled = append(compiled, c)
+	}
+	return &Transformer{rules: compiled}, nil
+}
+
+// functionCount returns how many functions the step sets
+func (s TransformStep) functionCount() int {
+	count := 0
+	for _, set := range []bool{s.Trim, s.Upper, s.Lower, s.Replace != nil, s.Extract != "", s.Map != nil,
+		s.Default != nil, s.Concat != "", s.Date != nil, s.Number != nil} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
+// Apply transforms the records in place; rules run in order, so a rule sees the results of earlier rules
+// It stops at the first value that cannot be transformed, reporting its record number (1-based).
+func (t *Transformer) Apply(records []DataRecord) error {
+	return t.applyFrom(0, records)
+}
+
+// applyFrom is Apply for a batch of records whose first record has index offset in its file
+func (t *Transformer) applyFrom(offset int, records []DataRecord) error {
+	for i, record := range records {
+		for _, rule := range t.rules {
+			value := record[rule.column]
+			for _, step := range rule.steps {
+				var err error
+				if value, err = step.apply(value, record); err != nil {
+					return fmt.Errorf("record %d, column '%s': %w", offset+i+1, rule.column, err)
+				}
+			}
+			record[rule.column] = value
+		}
+	}
+	return nil
+}
+
+// apply runs one step on a value; record provides the columns of concat templates
+func (s compiledStep) apply(value any, record DataRecord) (any, error) {
+	switch {
+	case s.Default != nil:
+		if value == nil || convertValueToString(value) == "" {
+			return *s.Default, nil
+		}
+		return value, nil
+	case s.Concat != "":
+		return concatPlaceholder.ReplaceAllStringFunc(s.Concat, func(ref string) string {
+			return convertValueToString(record[ref[1:len(ref)-1]])
+		}), nil
+	case value == nil:
+		return nil, nil
+	}
+
+	if t, ok := value.(time.Time); ok && s.Date != nil {
+		return t.Format(s.dateLayout()), nil
+	}
+	text := convertValueToString(value)
+	switch {
+	case s.Trim:
+		return strings.TrimSpace(text), nil
+	case s.Upper:
+		return strings.ToUpper(text), nil
+	case s.Lower:
+		return strings.ToLower(text), nil
+	case s.Replace != nil:
+		return strings.ReplaceAll(text, s.Replace.Old, s.Replace.New), nil
+	case s.extract != nil:
+		match := s.extract.FindStringSubmatch(text)
+		switch {
+		case match == nil:
+			return "", nil
+		case len(match) > 1:
+			return match[1], nil
+		default:
+			return match[0], nil
+		}
+	case s.Map != nil:
+		if mapped, ok := s.Map[text]; ok {
+			return mapped, nil
+		}
+		return value, nil
+	case s.Date != nil:
+		if text == "" {
+			return value, nil
+		}
+		t, err := time.Parse(s.Date.From, text)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse '%s' as a date in layout '%s'", text, s.Date.From)
+		}
+		return t.Format(s.dateLayout()), nil
+	case s.Number != nil:
+		return s.parseNumber(text)
+	}
+	return value, nil
+}
+
+// dateLayout returns the output layout of a date step
+func (s compiledStep) dateLayout() string {
+	if s.Date.To == "" {
+		return defaultDateOutputLayout
+	}
+	return s.Date.To
+}
+
+// parseNumber converts a localized number such as "1.234,5" into "1234.5"
+func (s compiledStep) parseNumber(text string) (any, error) {
+	number := strings.TrimSpace(text)
+	if number == "" {
+		return text, nil
+	}
+	if s.Number.Thousands != "" {
+		number = strings.ReplaceAll(number, s.Number.Thousands, "")
+	}
+	if s.Number.Decimal != "" && s.Number.Decimal != "." {
+		number = strings.Replace(number, s.Number.Decimal, ".", 1)
+	}
+	if !plainNumberPattern.MatchString(number) {
+		return nil, fmt.Errorf("cannot parse '%s' as a number", text)
+	}
+	return number, nil
+}
+
+// transformedColumns returns the columns that the rules transform or compute
+func transformedColumns(rules []TransformRule) []string {
+	var columns []string
+	for _, rule := range rules {
+		if !slices.Contains(columns, rule.Column) {
+			columns = append(columns, rule.Column)
+		}
+	}
+	return columns
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTransformer_Apply(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		rules   []TransformRule
+		records []DataRecord
+		want    []DataRecord
+	}{
+		{
+			name:    "trim and upper",
+			rules:   []TransformRule{{Column: "code", Steps: []TransformStep{{Trim: true}, {Upper: true}}}},
+			records: []DataRecord{{"code": "  ab-1 "}},
+			want:    []DataRecord{{"code": "AB-1"}},
+		},
+		{
+			name:    "lower",
+			rules:   []TransformRule{{Column: "email", Steps: []TransformStep{{Lower: true}}}},
+			records: []DataRecord{{"email": "Alice@Example.COM"}},
+			want:    []DataRecord{{"email": "alice@example.com"}},
+		},
+		{
+			name:    "replace",
+			rules:   []TransformRule{{Column: "phone", Steps: []TransformStep{{Replace: &ReplaceStep{Old: "-", New: ""}}}}},
+			records: []DataRecord{{"phone": "03-1234-5678"}},
+			want:    []DataRecord{{"phone": "0312345678"}},
+		},
+		{
+			name:    "extract capture group, whole match and no match",
+			rules:   []TransformRule{{Column: "a", Steps: []TransformStep{{Extract: `#(\d+)`}}}, {Column: "b", Steps: []TransformStep{{Extract: `\d+`}}}},
+			records: []DataRecord{{"a": "order #42", "b": "x7y"}, {"a": "none", "b": "-"}},
+			want:    []DataRecord{{"a": "42", "b": "7"}, {"a": "", "b": ""}},
+		},
+		{
+			name:    "map lookup keeps unmapped values",
+			rules:   []TransformRule{{Column: "active", Steps: []TransformStep{{Map: map[string]string{"有効": "1", "無効": "0"}}}}},
+			records: []DataRecord{{"active": "有効"}, {"active": "無効"}, {"active": "1"}},
+			want:    []DataRecord{{"active": "1"}, {"active": "0"}, {"active": "1"}},
+		},
+		{
+			name:    "default fills NULL and empty values",
+			rules:   []TransformRule{{Column: "country", Steps: []TransformStep{{Trim: true}, {Default: str("JP")}}}},
+			records: []DataRecord{{"country": nil}, {"country": " "}, {"country": "US"}, {}},
+			want:    []DataRecord{{"country": "JP"}, {"country": "JP"}, {"country": "US"}, {"country": "JP"}},
+		},
+		{
+			name: "concat computes a column from transformed values",
+			rules: []TransformRule{
+				{Column: "last_name", Steps: []TransformStep{{Upper: true}}},
+				{Column: "full_name", Steps: []TransformStep{{Concat: "{last_name}, {first_name}"}}},
+			},
+			records: []DataRecord{{"first_name": "Taro", "last_name": "Yamada"}, {"first_name": "Hanako", "last_name": nil}},
+			want: []DataRecord{
+				{"first_name": "Taro", "last_name": "YAMADA", "full_name": "YAMADA, Taro"},
+				{"first_name": "Hanako", "last_name": nil, "full_name": ", Hanako"},
+			},
+		},
+		{
+			name:    "date",
+			rules:   []TransformRule{{Column: "d", Steps: []TransformStep{{Date: &DateStep{From: "02/01/2006", To: "2006-01-02"}}}}},
+			records: []DataRecord{{"d": "31/12/2024"}, {"d": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}, {"d": ""}},
+			want:    []DataRecord{{"d": "2024-12-31"}, {"d": "2024-01-02"}, {"d": ""}},
+		},
+		{
+			name:    "date with default output layout",
+			rules:   []TransformRule{{Column: "d", Steps: []TransformStep{{Date: &DateStep{From: "2006/1/2 15:04"}}}}},
+			records: []DataRecord{{"d": "2024/3/5 09:30"}},
+			want:    []DataRecord{{"d": "2024-03-05 09:30:00"}},
+		},
+		{
+			name:    "number",
+			rules:   []TransformRule{{Column: "price", Steps: []TransformStep{{Number: &NumberStep{Decimal: ",", Thousands: "."}}}}},
+			records: []DataRecord{{"price": "1.234,50"}, {"price": "-7"}, {"price": ""}},
+			want:    []DataRecord{{"price": "1234.50"}, {"price": "-7"}, {"price": ""}},
+		},
+		{
+			name:    "NULL values are left unchanged",
+			rules:   []TransformRule{{Column: "v", Steps: []TransformStep{{Trim: true}, {Upper: true}, {Map: map[string]string{"": "x"}}}}},
+			records: []DataRecord{{"v": nil}},
+			want:    []DataRecord{{"v": nil}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transformer, err := NewTransformer(tt.rules)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := transformer.Apply(tt.records); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, tt.records); diff != "" {
+				t.Errorf("Records mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTransformer_ApplyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    TransformStep
+		value   string
+		wantErr string
+	}{
+		{"invalid date", TransformStep{Date: &DateStep{From: "2006-01-02"}}, "31/12/2024", "record 2, column 'v': cannot parse '31/12/2024' as a date"},
+		{"invalid number", TransformStep{Number: &NumberStep{}}, "12abc", "record 2, column 'v': cannot parse '12abc' as a number"},
+		{"second decimal point", TransformStep{Number: &NumberStep{Decimal: ","}}, "1,2,3", "cannot parse '1,2,3' as a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transformer, err := NewTransformer([]TransformRule{{Column: "v", Steps: []TransformStep{tt.step}}})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			err = transformer.Apply([]DataRecord{{"v": nil}, {"v": tt.value}})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewTransformerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    TransformRule
+		wantErr string
+	}{
+		{"no steps", TransformRule{Column: "v"}, "at least one step is required"},
+		{"empty step", TransformRule{Column: "v", Steps: []TransformStep{{}}}, "steps[0]: exactly one function must be set, got 0"},
+		{"two functions in one step", TransformRule{Column: "v", Steps: []TransformStep{{Trim: true}, {Upper: true, Lower: true}}},
+			"steps[1]: exactly one function must be set, got 2"},
+		{"invalid extract regex", TransformRule{Column: "v", Steps: []TransformStep{{Extract: "("}}}, "invalid extract regex"},
+		{"replace without old", TransformRule{Column: "v", Steps: []TransformStep{{Replace: &ReplaceStep{New: "x"}}}}, "replace.old is required"},
+		{"date without from", TransformRule{Column: "v", Steps: []TransformStep{{Date: &DateStep{To: "2006"}}}}, "date.from is required"},
+		{"same number separators", TransformRule{Column: "v", Steps: []TransformStep{{Number: &NumberStep{Decimal: ",", Thousands: ","}}}},
+			"number.decimal and number.thousands must differ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTransformer([]TransformRule{tt.rule})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadTableRecordsWithTransform(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "users.json")
+	content := `[{"id": 1, "first_name": "Taro", "last_name": "Yamada", "status": "有効"}]`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	records, err := loadTableRecords(TableSyncConfig{
+		Name:     "users",
+		FilePath: filePath,
+		Columns:  []string{"id", "name", "status"},
+		Transform: []TransformRule{
+			{Column: "name", Steps: []TransformStep{{Concat: "{first_name} {last_name}"}}},
+			{Column: "status", Steps: []TransformStep{{Map: map[string]string{"有効": "1"}}}},
+		},
+	}, time.UTC, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []DataRecord{{"id": json.Number("1"), "first_name": "Taro", "last_name": "Yamada", "name": "Taro Yamada", "status": "1"}}
+	if diff := cmp.Diff(want, records); diff != "" {
+		t.Errorf("Records mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadTableRecordsWithConstants(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(filePath, []byte(`[{"id": 1, "code": "A1"}]`), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Constants are set before transforms and date parsing, so concat can use them and their dates are parsed
+	records, err := loadTableRecords(TableSyncConfig{
+		Name:        "users",
+		FilePath:    filePath,
+		Columns:     []string{"id", "tenant_id", "key", "imported_on", "batch_id"},
+		Constants:   map[string]string{"tenant_id": "42", "imported_on": "2024/06/17", "batch_id": "${RUN_ID}"},
+		Transform:   []TransformRule{{Column: "key", Steps: []TransformStep{{Concat: "{tenant_id}-{code}"}}}},
+		DateFormats: map[string]string{"imported_on": "2006/01/02"},
+	}, time.UTC, "run-42")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []DataRecord{{
+		"id":          json.Number("1"),
+		"code":        "A1",
+		"tenant_id":   "42",
+		"key":         "42-A1",
+		"imported_on": time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC),
+		"batch_id":    "run-42",
+	}}
+	if diff := cmp.Diff(want, records); diff != "" {
+		t.Errorf("Records mismatch (-want +got):\n%s", diff)
+	}
+}
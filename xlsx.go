@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// XLSXLoader loads data from a sheet of an Excel workbook
+type XLSXLoader struct {
+	FilePath   string         // Path to file to be loaded
+	Sheet      string         // Name of the sheet; empty uses SheetIndex
+	SheetIndex int            // 1-based position of the sheet in the workbook; 0 (with no Sheet) is the first sheet
+	HeaderRow  int            // 1-based row number of the header row; 0 is the first row of the range
+	Range      string         // Cell range of the header and data rows, e.g. B3:F200; empty reads the whole sheet
+	Location   *time.Location // Time zone of the wall clock of date cells (default UTC)
+}
+
+// NewXLSXLoader creates a new Excel loader instance
+func NewXLSXLoader(filePath string) *XLSXLoader {
+	return &XLSXLoader{
+		FilePath: filePath,
+	}
+}
+
+// WithOptions applies the configured sheet, header row and range to the Excel loader
+func (l *XLSXLoader) WithOptions(opts XLSXOptions) {
+	l.Sheet = opts.Sheet
+	l.SheetIndex = opts.SheetIndex
+	l.HeaderRow = opts.HeaderRow
+	l.Range = opts.Range
+}
+
+// xlsxRange is a rectangular cell range with 1-based, inclusive bounds
+type xlsxRange struct {
+	firstCol, firstRow int
+	lastCol, lastRow   int
+}
+
+// wholeSheet is the range read when no range is configured
+var wholeSheet = xlsxRange{firstCol: 1, firstRow: 1, lastCol: math.MaxInt, lastRow: math.MaxInt}
+
+// parseXLSXRange parses a cell range such as B3:F200; the corners may be given in any order
+func parseXLSXRange(ref string) (xlsxRange, error) {
+	first, last, ok := strings.Cut(ref, ":")
+	if !ok {
+		return xlsxRange{}, fmt.Errorf("'%s' is not a cell range such as A1:D100", ref)
+	}
+	col1, row1, err := excelize.CellNameToCoordinates(strings.TrimSpace(first))
+	if err != nil {
+		return xlsxRange{}, fmt.Errorf("'%s' is not a cell range such as A1:D100", ref)
+	}
+	col2, row2, err := excelize.CellNameToCoordinates(strings.TrimSpace(last))
+	if err != nil {
+		return xlsxRange{}, fmt.Errorf("'%s' is not a cell range such as A1:D100", ref)
+	}
+	return xlsxRange{firstCol: min(col1, col2), firstRow: min(row1, row2), lastCol: max(col1, col2), lastRow: max(row1, row2)}, nil
+}
+
+// xlsxColumn is a named column of the header row
+type xlsxColumn struct {
+	name  string
+	index int // 1-based column number
+}
+
+// Load loads data from the sheet of the Excel file.
+// The header row names the columns; the rows below it, up to the end of the range, are the records.
+// If 'columns' is specified, only those columns will be included in the result.
+// If 'columns' is empty, all named columns of the header row will be included.
+// Numbers, dates, booleans and text keep their cell types; empty cells are NULL and rows without any value are skipped.
+func (l *XLSXLoader) Load(columns []string) ([]DataRecord, error) {
+	area := wholeSheet
+	if l.Range != "" {
+		var err error
+		if area, err = parseXLSXRange(l.Range); err != nil {
+			return nil, fmt.Errorf("invalid range of Excel file '%s': %w", l.FilePath, err)
+		}
+	}
+	headerRow := l.HeaderRow
+	if headerRow == 0 {
+		headerRow = area.firstRow
+	}
+
+	file, err := excelize.OpenFile(l.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file '%s': %w", l.FilePath, err)
+	}
+	defer file.Close()
+
+	sheet, err := l.sheetName(file)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := file.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("error reading sheet '%s' of Excel file '%s': %w", sheet, l.FilePath, err)
+	}
+	if headerRow > len(rows) {
+		return nil, fmt.Errorf("sheet '%s' of Excel file '%s' must contain a header row and at least one data row", sheet, l.FilePath)
+	}
+
+	var headerColumns []xlsxColumn
+	header := rows[headerRow-1]
+	for col := area.firstCol; col <= min(area.lastCol, len(header)); col++ {
+		if name := strings.TrimSpace(header[col-1]); name != "" {
+			headerColumns = append(headerColumns, xlsxColumn{name: name, index: col})
+		}
+	}
+	if len(headerColumns) == 0 {
+		return nil, fmt.Errorf("sheet '%s' of Excel file '%s': header row %d is empty", sheet, l.FilePath, headerRow)
+	}
+
+	// Determine which columns to include in the result
+	targetColumns := headerColumns
+	if len(columns) > 0 {
+		targetColumns = slices.DeleteFunc(slices.Clone(headerColumns), func(c xlsxColumn) bool { return !slices.Contains(columns, c.name) })
+	}
+
+	cells, err := newXLSXCells(file, sheet, l.Location)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Excel file '%s': %w", l.FilePath, err)
+	}
+	var records []DataRecord
+	for rowNum := headerRow + 1; rowNum <= min(area.lastRow, len(rows)); rowNum++ {
+		row := rows[rowNum-1]
+		cellText := func(col int) string {
+			if col > len(row) {
+				return ""
+			}
+			return row[col-1]
+		}
+		if !slices.ContainsFunc(headerColumns, func(c xlsxColumn) bool { return cellText(c.index) != "" }) {
+			continue
+		}
+		record := make(DataRecord)
+		for _, column := range targetColumns {
+			value, err := cells.value(column.index, rowNum, cellText(column.index))
+			if err != nil {
+				return nil, fmt.Errorf("sheet '%s' of Excel file '%s': %w", sheet, l.FilePath, err)
+			}
+			record[column.name] = value
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// sheetName returns the name of the configured sheet of the workbook
+func (l *XLSXLoader) sheetName(file *excelize.File) (string, error) {
+	sheets := file.GetSheetList()
+	switch {
+	case l.Sheet != "":
+		if !slices.Contains(sheets, l.Sheet) {
+			return "", fmt.Errorf("Excel file '%s' has no sheet '%s'; its sheets are %s", l.FilePath, l.Sheet, strings.Join(sheets, ", "))
+		}
+		return l.Sheet, nil
+	case l.SheetIndex > len(sheets):
+		return "", fmt.Errorf("Excel file '%s' has no sheet %d; it has %d sheets", l.FilePath, l.SheetIndex, len(sheets))
+	case l.SheetIndex > 0:
+		return sheets[l.SheetIndex-1], nil
+	case len(sheets) == 0:
+		return "", fmt.Errorf("Excel file '%s' has no sheets", l.FilePath)
+	}
+	return sheets[0], nil
+}
+
+// xlsxCells converts the raw cell values of a sheet into typed record values
+type xlsxCells struct {
+	file       *excelize.File
+	sheet      string
+	location   *time.Location
+	date1904   bool
+	dateStyles map[int]bool // Whether the number format of a style ID is a date format
+}
+
+// newXLSXCells prepares the cell conversion of a sheet; dates are read as wall clock times in loc
+func newXLSXCells(file *excelize.File, sheet string, loc *time.Location) (*xlsxCells, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	props, err := file.GetWorkbookProps()
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxCells{
+		file:       file,
+		sheet:      sheet,
+		location:   loc,
+		date1904:   props.Date1904 != nil && *props.Date1904,
+		dateStyles: make(map[int]bool),
+	}, nil
+}
+
+// value returns the record value of a cell from its raw text, type and number format
+// Numbers become exact decimals, numbers in a date format time.Time (or hh:mm:ss text for a time of day),
+// booleans bool and text strings. Cells holding an error value such as #N/A are reported.
+func (c *xlsxCells) value(col, row int, raw string) (any, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	cell, err := excelize.CoordinatesToCellName(col, row)
+	if err != nil {
+		return nil, err
+	}
+	cellType, err := c.file.GetCellType(c.sheet, cell)
+	if err != nil {
+		return nil, fmt.Errorf("cell %s: %w", cell, err)
+	}
+	switch cellType {
+	case excelize.CellTypeBool:
+		return raw == "1", nil
+	case excelize.CellTypeError:
+		return nil, fmt.Errorf("cell %s holds the error value %s", cell, raw)
+	case excelize.CellTypeDate:
+		return c.isoDateValue(raw), nil
+	case excelize.CellTypeSharedString, excelize.CellTypeInlineString, excelize.CellTypeFormula:
+		return convertValue(raw), nil
+	}
+
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return convertValue(raw), nil
+	}
+	isDate, err := c.isDateCell(cell)
+	if err != nil {
+		return nil, fmt.Errorf("cell %s: %w", cell, err)
+	}
+	if isDate {
+		return c.serialDateValue(cell, number)
+	}
+	// Excel keeps 15 significant digits, so binary noise such as 0.30000000000000004 is dropped
+	return jsonNumberValue(json.Number(strconv.FormatFloat(number, 'g', 15, 64))), nil
+}
+
+// serialDateValue converts an Excel date serial into a time in the loader's time zone
+// Serials below 1 hold only a time of day and are returned as hh:mm:ss text, the form of TIME columns.
+func (c *xlsxCells) serialDateValue(cell string, serial float64) (any, error) {
+	t, err := excelize.ExcelDateToTime(serial, c.date1904)
+	if err != nil {
+		return nil, fmt.Errorf("cell %s: %w", cell, err)
+	}
+	if serial < 1 {
+		return t.Format(time.TimeOnly), nil
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), c.location), nil
+}
+
+// isoDateValue parses the ISO 8601 text of a date cell (cell type "d"); text it cannot parse is kept as is
+func (c *xlsxCells) isoDateValue(raw string) any {
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, raw, c.location); err == nil {
+			return t
+		}
+	}
+	return raw
+}
+
+// isDateCell reports whether the number format of a cell displays a date or time
+func (c *xlsxCells) isDateCell(cell string) (bool, error) {
+	styleID, err := c.file.GetCellStyle(c.sheet, cell)
+	if err != nil {
+		return false, err
+	}
+	if isDate, ok := c.dateStyles[styleID]; ok {
+		return isDate, nil
+	}
+	style, err := c.file.GetStyle(styleID)
+	if err != nil {
+		return false, err
+	}
+	isDate := isDateNumFmt(style.NumFmt)
+	if style.CustomNumFmt != nil {
+		isDate = isDateFormatCode(*style.CustomNumFmt)
+	}
+	c.dateStyles[styleID] = isDate
+	return isDate, nil
+}
+
+// isDateNumFmt reports whether a built-in number format ID is a date or time format,
+// including the East Asian date formats 27-36 and 50-58
+func isDateNumFmt(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58)
+}
+
+// isDateFormatCode reports whether a custom number format code displays a date or time:
+// it uses y, m, d, h or s outside quoted text, escaped characters and [...] sections such as colors,
+// or an elapsed time section such as [h]
+func isDateFormatCode(code string) bool {
+	section, _, _ := strings.Cut(strings.ToLower(code), ";")
+	for i := 0; i < len(section); i++ {
+		switch ch := section[i]; {
+		case ch == '\\' || ch == '_' || ch == '*':
+			i++ // The next character is literal text or padding
+		case ch == '"':
+			end := strings.IndexByte(section[i+1:], '"')
+			if end < 0 {
+				return false
+			}
+			i += end + 1
+		case ch == '[':
+			end := strings.IndexByte(section[i:], ']')
+			if end < 0 {
+				return false
+			}
+			if content := section[i+1 : i+end]; content != "" && strings.Trim(content, "hms") == "" {
+				return true
+			}
+			i += end
+		case strings.IndexByte("ymdhs", ch) >= 0:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// createTempXLSX writes a workbook with the given cells, keyed by sheet name and cell reference
+func createTempXLSX(t *testing.T, name string, sheets map[string]map[string]any, build func(f *excelize.File)) string {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	for sheet, cells := range sheets {
+		if _, err := f.NewSheet(sheet); err != nil {
+			t.Fatalf("Failed to create sheet %s: %v", sheet, err)
+		}
+		for cell, value := range cells {
+			if err := f.SetCellValue(sheet, cell, value); err != nil {
+				t.Fatalf("Failed to set cell %s!%s: %v", sheet, cell, err)
+			}
+		}
+	}
+	if build != nil {
+		build(f)
+	}
+	filePath := filepath.Join(t.TempDir(), name)
+	if err := f.SaveAs(filePath); err != nil {
+		t.Fatalf("Failed to create temp Excel file %s: %v", name, err)
+	}
+	return filePath
+}
+
+// setNumFmt applies a custom number format to a cell
+func setNumFmt(t *testing.T, f *excelize.File, sheet, cell, format string) {
+	t.Helper()
+	style, err := f.NewStyle(&excelize.Style{CustomNumFmt: &format})
+	if err != nil {
+		t.Fatalf("Failed to create style: %v", err)
+	}
+	if err := f.SetCellStyle(sheet, cell, cell, style); err != nil {
+		t.Fatalf("Failed to set style: %v", err)
+	}
+}
+
+func TestXLSXLoader_Load_Success(t *testing.T) {
+	tokyo := time.FixedZone("+09:00", 9*3600)
+	products := map[string]any{
+		"A1": "id", "B1": "name", "C1": "price", "D1": "active", "E1": "released", "F1": "code",
+		"A2": 1, "B2": "Apple", "C2": 0.1 + 0.2, "D2": true, "E2": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "F2": "00123",
+		"A3": 2, "B3": "Banana", "C3": 1234.5, "D3": false, "F3": "2024-05-06T07:08:09Z",
+		// Row 4 is blank and skipped
+		"A5": 3, "B5": "Cherry", "C5": 1e20, "E5": 45000.5,
+	}
+	dated := func(f *excelize.File) { setNumFmt(t, f, "Products", "E5", "yyyy/mm/dd hh:mm") }
+
+	tests := []struct {
+		name     string
+		options  XLSXOptions
+		location *time.Location
+		columns  []string
+		expected []DataRecord
+	}{
+		{
+			name:    "typed values of all columns",
+			options: XLSXOptions{Sheet: "Products"},
+			expected: []DataRecord{
+				{"id": json.Number("1"), "name": "Apple", "price": json.Number("0.3"), "active": true, "released": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "code": "00123"},
+				{"id": json.Number("2"), "name": "Banana", "price": json.Number("1234.5"), "active": false, "released": nil, "code": time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)},
+				{"id": json.Number("3"), "name": "Cherry", "price": json.Number("100000000000000000000"), "active": nil, "released": time.Date(2023, 3, 15, 12, 0, 0, 0, time.UTC), "code": nil},
+			},
+		},
+		{
+			name:     "filter columns and read dates in the source time zone",
+			options:  XLSXOptions{SheetIndex: 2},
+			location: tokyo,
+			columns:  []string{"id", "released", "missing"},
+			expected: []DataRecord{
+				{"id": json.Number("1"), "released": time.Date(2024, 1, 2, 3, 4, 5, 0, tokyo)},
+				{"id": json.Number("2"), "released": nil},
+				{"id": json.Number("3"), "released": time.Date(2023, 3, 15, 12, 0, 0, 0, tokyo)},
+			},
+		},
+		{
+			name:     "range limits rows and columns",
+			options:  XLSXOptions{Sheet: "Products", Range: "B1:C3"},
+			expected: []DataRecord{{"name": "Apple", "price": json.Number("0.3")}, {"name": "Banana", "price": json.Number("1234.5")}},
+		},
+		{
+			name:     "header row inside the range",
+			options:  XLSXOptions{Sheet: "Products", Range: "A1:B5", HeaderRow: 2},
+			expected: []DataRecord{{"1": json.Number("2"), "Apple": "Banana"}, {"1": json.Number("3"), "Apple": "Cherry"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := createTempXLSX(t, "products.xlsx", map[string]map[string]any{"Products": products}, dated)
+			loader, err := GetLoaderWithOptions(filePath, LoaderOptions{XLSX: tt.options, Location: tt.location})
+			if err != nil {
+				t.Fatalf("GetLoaderWithOptions() returned error: %v", err)
+			}
+			records, err := loader.Load(tt.columns)
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(records, tt.expected) {
+				t.Errorf("Load() = %v, want %v", records, tt.expected)
+			}
+		})
+	}
+}
+
+func TestXLSXLoaderTimeOfDay(t *testing.T) {
+	filePath := createTempXLSX(t, "shifts.xlsx", map[string]map[string]any{"Shifts": {"A1": "starts", "A2": 0.375}}, func(f *excelize.File) {
+		setNumFmt(t, f, "Shifts", "A2", "[h]:mm")
+	})
+	loader := NewXLSXLoader(filePath)
+	loader.Sheet = "Shifts"
+	records, err := loader.Load(nil)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	expected := []DataRecord{{"starts": "09:00:00"}}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Load() = %v, want %v", records, expected)
+	}
+}
+
+func TestXLSXLoader_Load_Error(t *testing.T) {
+	sheets := map[string]map[string]any{"Data": {"A1": "id", "A2": 1}, "Empty": {}, "Blank": {"B3": 1}}
+	tests := []struct {
+		name          string
+		options       XLSXOptions
+		expectedError string
+	}{
+		{"unknown sheet", XLSXOptions{Sheet: "Missing"}, "has no sheet 'Missing'; its sheets are Sheet1, "},
+		{"sheet index out of range", XLSXOptions{SheetIndex: 9}, "has no sheet 9; it has 4 sheets"},
+		{"empty sheet", XLSXOptions{Sheet: "Empty"}, "sheet 'Empty' of Excel file"},
+		{"header row beyond the data", XLSXOptions{Sheet: "Data", HeaderRow: 5}, "must contain a header row and at least one data row"},
+		{"empty header row", XLSXOptions{Sheet: "Blank", HeaderRow: 3, Range: "A3:A9"}, "header row 3 is empty"},
+		{"invalid range", XLSXOptions{Sheet: "Data", Range: "A1"}, "'A1' is not a cell range"},
+	}
+
+	filePath := createTempXLSX(t, "data.xlsx", sheets, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := NewXLSXLoader(filePath)
+			loader.WithOptions(tt.options)
+			_, err := loader.Load(nil)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Load() error = %v, want error containing %q", err, tt.expectedError)
+			}
+		})
+	}
+
+	t.Run("file not found", func(t *testing.T) {
+		loader := NewXLSXLoader(filepath.Join(t.TempDir(), "missing.xlsx"))
+		if _, err := loader.Load(nil); err == nil || !strings.Contains(err.Error(), "no such file or directory") {
+			t.Errorf("Load() error = %v, want file not found", err)
+		}
+	})
+}
+
+func TestParseXLSXRange(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected xlsxRange
+		wantErr  bool
+	}{
+		{ref: "B3:F200", expected: xlsxRange{firstCol: 2, firstRow: 3, lastCol: 6, lastRow: 200}},
+		{ref: "F200:B3", expected: xlsxRange{firstCol: 2, firstRow: 3, lastCol: 6, lastRow: 200}},
+		{ref: "$A$1:$C$10", expected: xlsxRange{firstCol: 1, firstRow: 1, lastCol: 3, lastRow: 10}},
+		{ref: "A1", wantErr: true},
+		{ref: "A:C", wantErr: true},
+		{ref: "A1:ZZZZ1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := parseXLSXRange(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseXLSXRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.expected {
+				t.Errorf("parseXLSXRange() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsDateFormatCode(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected bool
+	}{
+		{"yyyy-mm-dd", true},
+		{"d/m/yy h:mm", true},
+		{"[$-409]mmm d, yyyy", true},
+		{"[h]:mm:ss", true},
+		{"hh:mm AM/PM", true},
+		{"0.00", false},
+		{"#,##0 \"days\"", false},
+		{"[Magenta]0.00;[Red]-0.00", false},
+		{"0.00\\h", false},
+		{"General", false},
+		{"0_);(0)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := isDateFormatCode(tt.code); got != tt.expected {
+				t.Errorf("isDateFormatCode(%q) = %v, want %v", tt.code, got, tt.expected)
+			}
+		})
+	}
+}